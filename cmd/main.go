@@ -23,6 +23,8 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/Loopring/relay-cluster/dao"
+	"github.com/Loopring/relay-cluster/kms"
 	"github.com/Loopring/relay-cluster/node"
 	"github.com/Loopring/relay-lib/log"
 	"github.com/Loopring/relay-lib/params"
@@ -39,6 +41,7 @@ func main() {
 	app.Copyright = "Copyright 2013-2017 The Loopring Authors"
 	globalFlags := globalFlags()
 	app.Flags = append(app.Flags, globalFlags...)
+	app.Commands = []cli.Command{encryptPrivateKeysCommand()}
 
 	app.Before = func(ctx *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
@@ -126,3 +129,43 @@ func setGlobalConfig(ctx *cli.Context) *node.GlobalConfig {
 
 	return globalConfig
 }
+
+// encryptPrivateKeysCommand seals auth private keys of orders saved in plaintext, it can be run again while relays
+// without kms are still saving orders.
+func encryptPrivateKeysCommand() cli.Command {
+	return cli.Command{
+		Name:   "encrypt-privkeys",
+		Usage:  "encrypt auth private keys of orders saved in plaintext by kms in config",
+		Action: encryptPrivateKeys,
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "rows encrypted per query",
+				Value: 1000,
+			},
+		},
+	}
+}
+
+func encryptPrivateKeys(ctx *cli.Context) error {
+	globalConfig := node.LoadConfig(ctx.GlobalString("config"))
+
+	logger := log.Initialize(globalConfig.Log)
+	defer func() {
+		if nil != logger {
+			logger.Sync()
+		}
+	}()
+
+	if err := kms.Initialize(globalConfig.Kms); err != nil {
+		return err
+	}
+	if !kms.IsEnabled() {
+		return fmt.Errorf("kms is not enabled in config")
+	}
+
+	rds := dao.NewDb(&globalConfig.Mysql)
+	nums, err := rds.EncryptPrivateKeys(ctx.Int("batch-size"))
+	log.Infof("encrypt private keys, %d rows encrypted", nums)
+	return err
+}
@@ -0,0 +1,81 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/jinzhu/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// MaxCursorPageSize limits the rows of a page, larger page sizes are cut to it.
+const MaxCursorPageSize = 100
+
+// PageCursor is the position of the last row returned by a keyset page query,
+// rows are always sorted by (sort column DESC, id DESC) so that new rows never shift later pages.
+// Clients only see it as an opaque token.
+type PageCursor struct {
+	Value int64 `json:"v"`
+	ID    int   `json:"i"`
+}
+
+func EncodeCursor(value int64, id int) string {
+	data, _ := json.Marshal(&PageCursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns nil for an empty token, which means the first page.
+func DecodeCursor(token string) (*PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &PageCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// cursorScope restricts db to the rows after cursor and applies the keyset ordering,
+// limit is pageSize+1 so that the caller knows whether there is a next page.
+// Tables paged by it index (owner, column), the primary key id completes the key of innodb secondary indexes.
+func cursorScope(db *gorm.DB, column string, cursor *PageCursor, pageSize int) *gorm.DB {
+	if cursor != nil {
+		db = db.Where(column+" < ? OR ("+column+" = ? AND id < ?)", cursor.Value, cursor.Value, cursor.ID)
+	}
+	return db.Order(column + " DESC").Order("id DESC").Limit(pageSize + 1)
+}
+
+func normalizeCursorPageSize(pageSize, defaultSize int) int {
+	if pageSize <= 0 {
+		return defaultSize
+	}
+	if pageSize > MaxCursorPageSize {
+		return MaxCursorPageSize
+	}
+	return pageSize
+}
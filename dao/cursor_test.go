@@ -0,0 +1,153 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao_test
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Loopring/relay-cluster/dao"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/jinzhu/gorm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPageCursor(t *testing.T) {
+	token := dao.EncodeCursor(1531900000, 1024)
+	cursor, err := dao.DecodeCursor(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.Value != 1531900000 || cursor.ID != 1024 {
+		t.Fatalf("cursor decoded as %d-%d", cursor.Value, cursor.ID)
+	}
+
+	if cursor, err := dao.DecodeCursor(""); err != nil || cursor != nil {
+		t.Fatalf("empty token should be the first page")
+	}
+
+	if _, err := dao.DecodeCursor("not-a-cursor"); err != dao.ErrInvalidCursor {
+		t.Fatalf("invalid token should be rejected")
+	}
+}
+
+var errQueryRecorded = errors.New("query recorded")
+
+// queryRecorder records the queries sent by gorm instead of running them.
+type queryRecorder struct {
+	query string
+	args  []interface{}
+}
+
+func (r *queryRecorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return nil, errQueryRecorded
+}
+
+func (r *queryRecorder) Prepare(query string) (*sql.Stmt, error) {
+	return nil, errQueryRecorded
+}
+
+func (r *queryRecorder) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	r.query, r.args = query, args
+	return nil, errQueryRecorded
+}
+
+func (r *queryRecorder) QueryRow(query string, args ...interface{}) *sql.Row {
+	r.query, r.args = query, args
+	return nil
+}
+
+func newRecordingRds(t *testing.T) (*dao.RdsService, *queryRecorder) {
+	recorder := &queryRecorder{}
+	db, err := gorm.Open("mysql", recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rds := &dao.RdsService{}
+	rds.Db = db.LogMode(false)
+	return rds, recorder
+}
+
+// indexColumns returns the columns of the index name of model in order.
+func indexColumns(rds *dao.RdsService, model interface{}, name string) []string {
+	var columns []string
+	for _, field := range rds.Db.NewScope(model).GetStructFields() {
+		for _, index := range strings.Split(field.TagSettings["INDEX"], ",") {
+			if index == name {
+				columns = append(columns, field.DBName)
+			}
+		}
+	}
+	return columns
+}
+
+func TestCursorQueries(t *testing.T) {
+	rds, recorder := newRecordingRds(t)
+	token := dao.EncodeCursor(1531900000, 1024)
+	owner := "0x1B978a1D302335a6F2Ebe4B8823B5E17c3C84135"
+
+	cases := []struct {
+		name   string
+		query  func() error
+		column string
+		model  interface{}
+		index  string
+		filter string
+	}{
+		{"orders", func() error {
+			_, err := rds.OrderCursorQuery(map[string]interface{}{"owner": owner}, nil, token, 20)
+			return err
+		}, "create_time", &dao.Order{}, "owner_create_time", "owner"},
+		{"fills", func() error {
+			_, err := rds.FillsCursorQuery(map[string]interface{}{"owner": owner}, token, 20)
+			return err
+		}, "create_time", &dao.FillEvent{}, "owner_create_time", "owner"},
+		{"rings", func() error {
+			_, err := rds.RingMinedCursorQuery(map[string]interface{}{"delegate_address": owner}, token, 20)
+			return err
+		}, "time", &dao.RingMinedEvent{}, "delegate_address_time", "delegate_address"},
+		{"transactions", func() error {
+			_, _, err := rds.GetTxViewByOwnerWithCursor(owner, "LRC", types.TX_STATUS_UNKNOWN, 0, token, 20)
+			return err
+		}, "create_time", &dao.TransactionView{}, "owner_create_time", "owner"},
+	}
+
+	for _, c := range cases {
+		if err := c.query(); err != errQueryRecorded {
+			t.Fatalf("%s: expected the query recorded, got err:%v", c.name, err)
+		}
+
+		keyset := "(" + c.column + " < ? OR (" + c.column + " = ? AND id < ?))"
+		order := "ORDER BY " + c.column + " DESC,id DESC LIMIT 21"
+		if !strings.Contains(recorder.query, keyset) || !strings.HasSuffix(recorder.query, order) {
+			t.Errorf("%s: query isn't paged after the cursor, got %s", c.name, recorder.query)
+		}
+		if !strings.Contains(recorder.query, c.filter) {
+			t.Errorf("%s: query isn't filtered by %s, got %s", c.name, c.filter, recorder.query)
+		}
+		if n := len(recorder.args); n < 3 || !reflect.DeepEqual(recorder.args[n-3:], []interface{}{int64(1531900000), int64(1531900000), 1024}) {
+			t.Errorf("%s: cursor args not matched, got %v", c.name, recorder.args)
+		}
+
+		if columns := indexColumns(rds, c.model, c.index); !reflect.DeepEqual(columns, []string{c.filter, c.column}) {
+			t.Errorf("%s: index %s should cover the keyset, got %v", c.name, c.index, columns)
+		}
+	}
+}
@@ -29,6 +29,8 @@ type PageResult struct {
 	PageIndex int           `json:"pageIndex"`
 	PageSize  int           `json:"pageSize"`
 	Total     int           `json:"total"`
+	// NextCursor is only set by cursor queries, empty means there is no more data
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type RdsService struct {
@@ -56,6 +58,10 @@ func NewDb(options *libdao.MysqlOptions) *RdsService {
 	tables = append(tables, &TransactionView{})
 	tables = append(tables, &CheckPoint{})
 	tables = append(tables, &OrderPendingTransaction{})
+	tables = append(tables, &OrderHistory{})
+	tables = append(tables, &OrderGroup{})
+	tables = append(tables, &ScheduledOrder{})
+	tables = append(tables, &StopOrder{})
 	tables = append(tables, &TicketReceiver{})
 	tables = append(tables, &CityPartner{})
 	tables = append(tables, &CityPartnerReceived{})
@@ -69,3 +75,18 @@ func NewDb(options *libdao.MysqlOptions) *RdsService {
 
 	return &s
 }
+
+// Begin returns a RdsService whose queries run in a new transaction, it must be ended by Commit or Rollback.
+func (s *RdsService) Begin() *RdsService {
+	tx := *s
+	tx.Db = s.Db.Begin()
+	return &tx
+}
+
+func (s *RdsService) Commit() error {
+	return s.Db.Commit().Error
+}
+
+func (s *RdsService) Rollback() error {
+	return s.Db.Rollback().Error
+}
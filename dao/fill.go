@@ -29,10 +29,10 @@ type FillEvent struct {
 	ID              int    `gorm:"column:id;primary_key;" json:"id"`
 	Protocol        string `gorm:"column:contract_address;type:varchar(42)" json:"protocol"`
 	DelegateAddress string `gorm:"column:delegate_address;type:varchar(42)" json:"delegateAddress"`
-	Owner           string `gorm:"column:owner;type:varchar(42)" json:"owner"`
+	Owner           string `gorm:"column:owner;type:varchar(42);index:owner_create_time" json:"owner"`
 	RingIndex       int64  `gorm:"column:ring_index;" json:"ringIndex"`
 	BlockNumber     int64  `gorm:"column:block_number" json:"blockNumber"`
-	CreateTime      int64  `gorm:"column:create_time" json:"createTime"`
+	CreateTime      int64  `gorm:"column:create_time;index:owner_create_time" json:"createTime"`
 	RingHash        string `gorm:"column:ring_hash;varchar(82)" json:"ringHash"`
 	FillIndex       int64  `gorm:"column:fill_index" json:"fillIndex"`
 	TxHash          string `gorm:"column:tx_hash;type:varchar(82)" json:"txHash"`
@@ -147,6 +147,33 @@ func (s *RdsService) FillsPageQuery(query map[string]interface{}, pageIndex, pag
 	return
 }
 
+func (s *RdsService) FillsCursorQuery(query map[string]interface{}, token string, pageSize int) (res PageResult, err error) {
+	fills := make([]FillEvent, 0)
+	res = PageResult{Data: make([]interface{}, 0)}
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		return res, err
+	}
+	pageSize = normalizeCursorPageSize(pageSize, 20)
+	res.PageSize = pageSize
+
+	err = cursorScope(s.Db.Where(query).Where("fork=?", false), "create_time", cursor, pageSize).Find(&fills).Error
+	if err != nil {
+		return res, err
+	}
+
+	if len(fills) > pageSize {
+		fills = fills[:pageSize]
+		last := fills[pageSize-1]
+		res.NextCursor = EncodeCursor(last.CreateTime, last.ID)
+	}
+	for _, fill := range fills {
+		res.Data = append(res.Data, fill)
+	}
+	return
+}
+
 func (s *RdsService) GetLatestFills(query map[string]interface{}, limit int) (res []FillEvent, err error) {
 	fills := make([]FillEvent, 0)
 	err = s.Db.Where(query).Where("fork=?", false).Order("create_time desc").Limit(limit).Find(&fills).Error
@@ -25,6 +25,7 @@ import (
 	util "github.com/Loopring/relay-lib/marketutil"
 	"github.com/Loopring/relay-lib/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/jinzhu/gorm"
 	"math/big"
 	"strconv"
 	"strings"
@@ -37,16 +38,16 @@ type Order struct {
 	ID                    int     `gorm:"column:id;primary_key;"`
 	Protocol              string  `gorm:"column:protocol;type:varchar(42)"`
 	DelegateAddress       string  `gorm:"column:delegate_address;type:varchar(42)"`
-	Owner                 string  `gorm:"column:owner;type:varchar(42)"`
+	Owner                 string  `gorm:"column:owner;type:varchar(42);index:owner_create_time"`
 	AuthAddress           string  `gorm:"column:auth_address;type:varchar(42)"`
-	PrivateKey            string  `gorm:"column:priv_key;type:varchar(128)"`
+	PrivateKey            string  `gorm:"column:priv_key;type:varchar(256)"`
 	WalletAddress         string  `gorm:"column:wallet_address;type:varchar(42)"`
 	OrderHash             string  `gorm:"column:order_hash;type:varchar(82)"`
 	TokenS                string  `gorm:"column:token_s;type:varchar(42)"`
 	TokenB                string  `gorm:"column:token_b;type:varchar(42)"`
 	AmountS               string  `gorm:"column:amount_s;type:varchar(40)"`
 	AmountB               string  `gorm:"column:amount_b;type:varchar(40)"`
-	CreateTime            int64   `gorm:"column:create_time;type:bigint"`
+	CreateTime            int64   `gorm:"column:create_time;type:bigint;index:owner_create_time"`
 	ValidSince            int64   `gorm:"column:valid_since;type:bigint"`
 	ValidUntil            int64   `gorm:"column:valid_until;type:bigint"`
 	LrcFee                string  `gorm:"column:lrc_fee;type:varchar(40)"`
@@ -91,8 +92,11 @@ func (o *Order) ConvertDown(state *types.OrderState) error {
 	o.DelegateAddress = src.DelegateAddress.Hex()
 	o.Owner = src.Owner.Hex()
 
-	auth, _ := src.AuthPrivateKey.MarshalText()
-	o.PrivateKey = string(auth)
+	privKey, err := sealPrivateKey(src.AuthPrivateKey)
+	if err != nil {
+		return err
+	}
+	o.PrivateKey = privKey
 	o.AuthAddress = src.AuthAddr.Hex()
 	o.WalletAddress = src.WalletAddress.Hex()
 
@@ -121,7 +125,7 @@ func (o *Order) ConvertDown(state *types.OrderState) error {
 	return nil
 }
 
-// convert dao/order to types/orderState
+// convert dao/order to types/orderState, the auth private key isn't converted, see DecryptPrivateKey.
 func (o *Order) ConvertUp(state *types.OrderState) error {
 	state.RawOrder.AmountS, _ = new(big.Int).SetString(o.AmountS, 0)
 	state.RawOrder.AmountB, _ = new(big.Int).SetString(o.AmountB, 0)
@@ -144,12 +148,6 @@ func (o *Order) ConvertUp(state *types.OrderState) error {
 	if len(o.AuthAddress) > 0 {
 		state.RawOrder.AuthAddr = common.HexToAddress(o.AuthAddress)
 	}
-	if len(o.PrivateKey) > 0 {
-		authPrivateKey, err := crypto.NewPrivateKeyCrypto(false, o.PrivateKey)
-		if err == nil {
-			state.RawOrder.AuthPrivateKey = authPrivateKey
-		}
-	}
 	state.RawOrder.WalletAddress = common.HexToAddress(o.WalletAddress)
 
 	state.RawOrder.BuyNoMoreThanAmountB = o.BuyNoMoreThanAmountB
@@ -182,6 +180,11 @@ func (o *Order) ConvertUp(state *types.OrderState) error {
 	return nil
 }
 
+// DecryptPrivateKey returns the auth private key of market orders, it should only be used for orders provided to miners.
+func (o *Order) DecryptPrivateKey() (crypto.EthPrivateKeyCrypto, error) {
+	return openPrivateKey(o.PrivateKey)
+}
+
 func (s *RdsService) GetOrderByHash(orderhash common.Hash) (*Order, error) {
 	order := &Order{}
 	err := s.Db.Where("order_hash = ?", orderhash.Hex()).First(order).Error
@@ -315,6 +318,9 @@ func (s *RdsService) GetOrderBook(delegate, tokenS, tokenB common.Address, lengt
 	return list, err
 }
 
+// expired orders are swept to ORDER_EXPIRE periodically, opened orders expired since last sweep are included too
+const expiredOrderCondition = "status = ? or (valid_until < ? and status in (?))"
+
 func (s *RdsService) OrderPageQuery(query map[string]interface{}, statusList []int, pageIndex, pageSize int) (PageResult, error) {
 	var (
 		orders        []Order
@@ -332,7 +338,7 @@ func (s *RdsService) OrderPageQuery(query map[string]interface{}, statusList []i
 		pageSize = 20
 	}
 
-	pageResult = PageResult{data, pageIndex, pageSize, 0}
+	pageResult = PageResult{Data: data, PageIndex: pageIndex, PageSize: pageSize}
 
 	openedStatus := []types.OrderStatus{types.ORDER_NEW, types.ORDER_PARTIAL}
 	now := time.Now().Unix()
@@ -340,15 +346,13 @@ func (s *RdsService) OrderPageQuery(query map[string]interface{}, statusList []i
 	if len(statusList) == 1 {
 		if statusList[0] == 6 {
 			if err = s.Db.Where(query).
-				Where("valid_until < ?", now).
-				Where("status in (?)", openedStatus).
+				Where(expiredOrderCondition, types.ORDER_EXPIRE, now, openedStatus).
 				Offset((pageIndex - 1) * pageSize).Order("create_time DESC").Limit(pageSize).Find(&orders).Error; err != nil {
 				return pageResult, err
 			}
 
 			err = s.Db.Model(&Order{}).Where(query).
-				Where("valid_until < ?", now).
-				Where("status in (?)", openedStatus).Count(&pageResult.Total).Error
+				Where(expiredOrderCondition, types.ORDER_EXPIRE, now, openedStatus).Count(&pageResult.Total).Error
 
 			if err != nil {
 				return pageResult, err
@@ -423,6 +427,53 @@ func (s *RdsService) OrderPageQuery(query map[string]interface{}, statusList []i
 	return pageResult, err
 }
 
+// OrderCursorQuery is the keyset version of OrderPageQuery, it skips the total count.
+func (s *RdsService) OrderCursorQuery(query map[string]interface{}, statusList []int, token string, pageSize int) (PageResult, error) {
+	var (
+		orders     []Order
+		pageResult = PageResult{Data: make([]interface{}, 0)}
+	)
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		return pageResult, err
+	}
+	pageSize = normalizeCursorPageSize(pageSize, 20)
+	pageResult.PageSize = pageSize
+
+	openedStatus := []types.OrderStatus{types.ORDER_NEW, types.ORDER_PARTIAL}
+	now := time.Now().Unix()
+
+	db := s.Db.Where(query)
+	if len(statusList) == 1 {
+		if statusList[0] == int(types.ORDER_EXPIRE) {
+			db = db.Where(expiredOrderCondition, types.ORDER_EXPIRE, now, openedStatus)
+		} else {
+			db = db.Where("status = ?", statusList[0])
+		}
+	} else if len(statusList) > 1 {
+		db = db.Where("status in (?)", statusList)
+		if allContain(statusList, openedStatus) {
+			db = db.Where("valid_since < ?", now).Where("valid_until >= ? ", now)
+		}
+	}
+
+	if err = cursorScope(db, "create_time", cursor, pageSize).Find(&orders).Error; err != nil {
+		return pageResult, err
+	}
+
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		last := orders[pageSize-1]
+		pageResult.NextCursor = EncodeCursor(last.CreateTime, last.ID)
+	}
+	for _, v := range orders {
+		pageResult.Data = append(pageResult.Data, v)
+	}
+
+	return pageResult, nil
+}
+
 func containStatus(status int, statusList []types.OrderStatus) bool {
 	if len(statusList) == 0 {
 		return false
@@ -543,53 +594,172 @@ func (s *RdsService) UpdateOrderStatus(orderhash common.Hash, status types.Order
 		Update("status", status).Error
 }
 
-func (s *RdsService) FlexCancelOrderByHash(owner common.Address, orderhash common.Hash, validStatus []types.OrderStatus, status types.OrderStatus) int64 {
+func (s *RdsService) FlexCancelOrderByHash(owner common.Address, orderhash common.Hash, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
 	now := time.Now().Unix()
 
-	return s.Db.Model(&Order{}).
+	db := s.Db.Model(&Order{}).
 		Where("owner=?", owner.Hex()).
 		Where("order_hash=?", orderhash.Hex()).
-		Where("valid_until >= ? ", now).
-		Where("status in (?)", validStatus).
-		Update("status", status).RowsAffected
+		Where("valid_until >= ? ", now)
+	return s.flexCancelOrders(db, validStatus, status)
 }
 
-func (s *RdsService) FlexCancelOrderByOwner(owner common.Address, validStatus []types.OrderStatus, status types.OrderStatus) int64 {
+func (s *RdsService) FlexCancelOrderByOwner(owner common.Address, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
 	now := time.Now().Unix()
-	return s.Db.Model(&Order{}).
+	db := s.Db.Model(&Order{}).
 		Where("owner=?", owner.Hex()).
-		Where("valid_until >= ? ", now).
-		Where("status in (?)", validStatus).
-		Update("status", status).RowsAffected
+		Where("valid_until >= ? ", now)
+	return s.flexCancelOrders(db, validStatus, status)
 }
 
-func (s *RdsService) FlexCancelOrderByTime(owner common.Address, cutoff int64, validStatus []types.OrderStatus, status types.OrderStatus) int64 {
+// FlexCancelOrderByTime cancels orders valid since before cutoff, same as the cutoff of the contract.
+func (s *RdsService) FlexCancelOrderByTime(owner common.Address, cutoff int64, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
 	now := time.Now().Unix()
-	since := now
-	if since > cutoff {
-		since = cutoff
-	}
 
-	return s.Db.Model(&Order{}).
+	db := s.Db.Model(&Order{}).
 		Where("owner=?", owner.Hex()).
-		Where("valid_until >= ? ", now).
-		Where("status in (?)", validStatus).
-		Update("status", status).RowsAffected
+		Where("valid_since < ?", cutoff).
+		Where("valid_until >= ? ", now)
+	return s.flexCancelOrders(db, validStatus, status)
 }
 
-func (s *RdsService) FlexCancelOrderByMarket(owner common.Address, cutoff int64, market string, validStatus []types.OrderStatus, status types.OrderStatus) int64 {
+// FlexCancelOrderByMarket cancels orders of market, only those valid since before cutoff if it's greater than 0.
+func (s *RdsService) FlexCancelOrderByMarket(owner common.Address, cutoff int64, market string, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
 	now := time.Now().Unix()
-	since := now
-	if cutoff > 0 && since > cutoff {
-		since = cutoff
-	}
 
-	return s.Db.Model(&Order{}).
+	db := s.Db.Model(&Order{}).
 		Where("owner=?", owner.Hex()).
 		Where("market=?", market).
-		Where("valid_until >= ? ", now).
+		Where("valid_until >= ? ", now)
+	if cutoff > 0 {
+		db = db.Where("valid_since < ?", cutoff)
+	}
+	return s.flexCancelOrders(db, validStatus, status)
+}
+
+func (s *RdsService) FlexCancelOrderByHashes(owner common.Address, orderhashList []common.Hash, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
+	now := time.Now().Unix()
+
+	var hashes []string
+	for _, v := range orderhashList {
+		hashes = append(hashes, v.Hex())
+	}
+	db := s.Db.Model(&Order{}).
+		Where("owner=?", owner.Hex()).
+		Where("order_hash in (?)", hashes).
+		Where("valid_until >= ? ", now)
+	return s.flexCancelOrders(db, validStatus, status)
+}
+
+// FlexCancelOrderByPair cancels orders selling token1 for token2 and orders selling token2 for token1.
+func (s *RdsService) FlexCancelOrderByPair(owner common.Address, token1, token2 common.Address, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
+	now := time.Now().Unix()
+
+	db := s.Db.Model(&Order{}).
+		Where("owner=?", owner.Hex()).
+		Where("(token_s=? and token_b=?) or (token_s=? and token_b=?)", token1.Hex(), token2.Hex(), token2.Hex(), token1.Hex()).
+		Where("valid_until >= ? ", now)
+	return s.flexCancelOrders(db, validStatus, status)
+}
+
+// flexCancelOrders updates the orders in validStatus selected by db to status, and returns the hashes of the orders
+// updated. Each order is updated apart, so that orders changed by others since selected aren't returned.
+func (s *RdsService) flexCancelOrders(db *gorm.DB, validStatus []types.OrderStatus, status types.OrderStatus) []common.Hash {
+	var (
+		list   []Order
+		hashes []common.Hash
+	)
+	if err := db.Where("status in (?)", validStatus).Find(&list).Error; err != nil {
+		log.Errorf("flex cancel orders, select orders error:%s", err.Error())
+		return hashes
+	}
+
+	for _, v := range list {
+		res := s.Db.Model(&Order{}).
+			Where("order_hash = ?", v.OrderHash).
+			Where("status in (?)", validStatus).
+			Update("status", status)
+		if res.Error != nil {
+			log.Errorf("flex cancel orders, update order:%s error:%s", v.OrderHash, res.Error.Error())
+			continue
+		}
+		if res.RowsAffected > 0 {
+			hashes = append(hashes, common.HexToHash(v.OrderHash))
+		}
+	}
+	return hashes
+}
+
+// RestoreFlexCancelledOrder updates the order to status if it's still flex-cancelled, it returns 0 otherwise.
+func (s *RdsService) RestoreFlexCancelledOrder(orderhash common.Hash, status types.OrderStatus, blockNumber *big.Int) (int64, error) {
+	ret := s.Db.Model(&Order{}).
+		Where("order_hash = ?", orderhash.Hex()).
+		Where("status = ?", types.ORDER_FLEX_CANCEL).
+		Updates(map[string]interface{}{"status": status, "updated_block": blockNumber.Int64()})
+	return ret.RowsAffected, ret.Error
+}
+
+// GetExpiredOrders returns at most limit orders in validStatus whose valid_until is before now.
+func (s *RdsService) GetExpiredOrders(now int64, validStatus []types.OrderStatus, limit int) ([]Order, error) {
+	var list []Order
+	err := s.Db.Model(&Order{}).
+		Where("valid_until < ?", now).
+		Where("status in (?)", validStatus).
+		Order("id").Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// SetOrdersExpired updates the orders still in validStatus and expired before now to status.
+func (s *RdsService) SetOrdersExpired(orderHashes []string, now int64, validStatus []types.OrderStatus, status types.OrderStatus) (int64, error) {
+	db := s.Db.Model(&Order{}).
+		Where("order_hash in (?)", orderHashes).
+		Where("valid_until < ?", now).
+		Where("status in (?)", validStatus).
+		Update("status", status)
+	return db.RowsAffected, db.Error
+}
+
+// GetOrdersAfterId returns at most limit orders in validStatus whose id is greater than cursor, ordered by id.
+func (s *RdsService) GetOrdersAfterId(cursor int, validStatus []types.OrderStatus, limit int) ([]Order, error) {
+	var list []Order
+	err := s.Db.Model(&Order{}).
+		Where("id > ?", cursor).
 		Where("status in (?)", validStatus).
-		Update("status", status).RowsAffected
+		Order("id").Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// UpdateOrderWhileReconcile updates amounts of the order read as old, it's skipped if the order changed since read.
+func (s *RdsService) UpdateOrderWhileReconcile(old *Order, status types.OrderStatus, dealtAmountS, dealtAmountB, cancelledAmountS, cancelledAmountB, blockNumber *big.Int) (int64, error) {
+	items := map[string]interface{}{
+		"status":             uint8(status),
+		"dealt_amount_s":     dealtAmountS.String(),
+		"dealt_amount_b":     dealtAmountB.String(),
+		"cancelled_amount_s": cancelledAmountS.String(),
+		"cancelled_amount_b": cancelledAmountB.String(),
+		"updated_block":      blockNumber.Int64(),
+	}
+	db := s.Db.Model(&Order{}).
+		Where("order_hash = ?", old.OrderHash).
+		Where("status = ? and updated_block = ?", old.Status, old.UpdatedBlock).
+		Where("dealt_amount_s = ? and dealt_amount_b = ?", old.DealtAmountS, old.DealtAmountB).
+		Where("cancelled_amount_s = ? and cancelled_amount_b = ?", old.CancelledAmountS, old.CancelledAmountB).
+		Update(items)
+	return db.RowsAffected, db.Error
+}
+
+// UpdateOrderWhileDust updates status of the order read as old, it's skipped if the order changed since read.
+func (s *RdsService) UpdateOrderWhileDust(old *Order, status types.OrderStatus) (int64, error) {
+	db := s.Db.Model(&Order{}).
+		Where("order_hash = ?", old.OrderHash).
+		Where("status = ? and updated_block = ?", old.Status, old.UpdatedBlock).
+		Where("dealt_amount_s = ? and dealt_amount_b = ?", old.DealtAmountS, old.DealtAmountB).
+		Where("split_amount_s = ? and split_amount_b = ?", old.SplitAmountS, old.SplitAmountB).
+		Where("cancelled_amount_s = ? and cancelled_amount_b = ?", old.CancelledAmountS, old.CancelledAmountB).
+		Update("status", uint8(status))
+	return db.RowsAffected, db.Error
 }
 
 func (s *RdsService) IsOrderOwner(owner common.Address) bool {
@@ -0,0 +1,127 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"time"
+)
+
+// OrderGroup is a member of an one-cancels-other group, an order belongs to one group at most.
+// TriggerOrderHash is the member filled first, it's empty until the group is triggered.
+// TriggerCancelled is set on the members flex-cancelled by the trigger, which are restored if the trigger is forked.
+type OrderGroup struct {
+	ID               int    `gorm:"column:id;primary_key;"`
+	GroupId          string `gorm:"column:group_id;type:varchar(82);index"`
+	OrderHash        string `gorm:"column:order_hash;type:varchar(82);unique_index"`
+	Owner            string `gorm:"column:owner;type:varchar(42)"`
+	TriggerOrderHash string `gorm:"column:trigger_order_hash;type:varchar(82)"`
+	TriggerTxHash    string `gorm:"column:trigger_tx_hash;type:varchar(82)"`
+	TriggerCancelled bool   `gorm:"column:trigger_cancelled"`
+	CreateTime       int64  `gorm:"column:create_time;type:bigint"`
+	UpdateTime       int64  `gorm:"column:update_time;type:bigint"`
+}
+
+// AddOrderGroup saves all members of the group in a transaction.
+func (s *RdsService) AddOrderGroup(groupId common.Hash, owner common.Address, orderhashList []common.Hash) error {
+	now := time.Now().Unix()
+
+	tx := s.Db.Begin()
+	for _, v := range orderhashList {
+		model := &OrderGroup{
+			GroupId:    groupId.Hex(),
+			OrderHash:  v.Hex(),
+			Owner:      owner.Hex(),
+			CreateTime: now,
+			UpdateTime: now,
+		}
+		if err := tx.Create(model).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (s *RdsService) GetOrderGroupByOrderHash(orderhash common.Hash) (OrderGroup, error) {
+	var group OrderGroup
+	err := s.Db.Where("order_hash = ?", orderhash.Hex()).First(&group).Error
+	return group, err
+}
+
+func (s *RdsService) GetOrderGroupsByOrderHashes(orderhashList []common.Hash) ([]OrderGroup, error) {
+	var (
+		list   []OrderGroup
+		hashes []string
+	)
+	for _, v := range orderhashList {
+		hashes = append(hashes, v.Hex())
+	}
+	err := s.Db.Where("order_hash in (?)", hashes).Find(&list).Error
+	return list, err
+}
+
+func (s *RdsService) GetOrderGroupMembers(groupId string) ([]OrderGroup, error) {
+	var list []OrderGroup
+	err := s.Db.Where("group_id = ?", groupId).Order("id").Find(&list).Error
+	return list, err
+}
+
+// TriggerOrderGroup marks the group triggered by the order, it returns 0 if the group has been triggered already.
+func (s *RdsService) TriggerOrderGroup(groupId string, orderhash, txhash common.Hash) (int64, error) {
+	ret := s.Db.Model(&OrderGroup{}).
+		Where("group_id = ?", groupId).
+		Where("trigger_order_hash = ?", "").
+		Updates(map[string]interface{}{
+			"trigger_order_hash": orderhash.Hex(),
+			"trigger_tx_hash":    txhash.Hex(),
+			"update_time":        time.Now().Unix(),
+		})
+	return ret.RowsAffected, ret.Error
+}
+
+// SetOrderGroupCancelled marks the members flex-cancelled by the trigger of the group.
+func (s *RdsService) SetOrderGroupCancelled(groupId string, orderhashList []common.Hash) error {
+	if len(orderhashList) == 0 {
+		return nil
+	}
+	var hashes []string
+	for _, v := range orderhashList {
+		hashes = append(hashes, v.Hex())
+	}
+	return s.Db.Model(&OrderGroup{}).
+		Where("group_id = ?", groupId).
+		Where("order_hash in (?)", hashes).
+		Updates(map[string]interface{}{"trigger_cancelled": true, "update_time": time.Now().Unix()}).Error
+}
+
+// ResetOrderGroupTrigger clears the trigger of the group if it's triggered by the order in tx, it returns 0 otherwise.
+func (s *RdsService) ResetOrderGroupTrigger(groupId string, orderhash, txhash common.Hash) (int64, error) {
+	ret := s.Db.Model(&OrderGroup{}).
+		Where("group_id = ?", groupId).
+		Where("trigger_order_hash = ?", orderhash.Hex()).
+		Where("trigger_tx_hash = ?", txhash.Hex()).
+		Updates(map[string]interface{}{
+			"trigger_order_hash": "",
+			"trigger_tx_hash":    "",
+			"trigger_cancelled":  false,
+			"update_time":        time.Now().Unix(),
+		})
+	return ret.RowsAffected, ret.Error
+}
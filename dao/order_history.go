@@ -0,0 +1,80 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"time"
+)
+
+// OrderHistory is a state of an order after a transition, it's only appended and never updated.
+type OrderHistory struct {
+	ID               int    `gorm:"column:id;primary_key;"`
+	OrderHash        string `gorm:"column:order_hash;type:varchar(82);index"`
+	Owner            string `gorm:"column:owner;type:varchar(42)"`
+	Status           uint8  `gorm:"column:status;type:tinyint(4)"`
+	DealtAmountS     string `gorm:"column:dealt_amount_s;type:varchar(40)"`
+	DealtAmountB     string `gorm:"column:dealt_amount_b;type:varchar(40)"`
+	SplitAmountS     string `gorm:"column:split_amount_s;type:varchar(40)"`
+	SplitAmountB     string `gorm:"column:split_amount_b;type:varchar(40)"`
+	CancelledAmountS string `gorm:"column:cancelled_amount_s;type:varchar(40)"`
+	CancelledAmountB string `gorm:"column:cancelled_amount_b;type:varchar(40)"`
+	BlockNumber      int64  `gorm:"column:block_number;type:bigint"`
+	EventType        string `gorm:"column:event_type;type:varchar(20)"`
+	TxHash           string `gorm:"column:tx_hash;type:varchar(82)"`
+	CreateTime       int64  `gorm:"column:create_time;type:bigint"`
+}
+
+func (h *OrderHistory) ConvertDown(state *types.OrderState, eventType string, txhash common.Hash) error {
+	h.OrderHash = state.RawOrder.Hash.Hex()
+	h.Owner = state.RawOrder.Owner.Hex()
+	h.Status = uint8(state.Status)
+	h.DealtAmountS = bigintString(state.DealtAmountS)
+	h.DealtAmountB = bigintString(state.DealtAmountB)
+	h.SplitAmountS = bigintString(state.SplitAmountS)
+	h.SplitAmountB = bigintString(state.SplitAmountB)
+	h.CancelledAmountS = bigintString(state.CancelledAmountS)
+	h.CancelledAmountB = bigintString(state.CancelledAmountB)
+	if state.UpdatedBlock != nil {
+		h.BlockNumber = state.UpdatedBlock.Int64()
+	}
+	h.EventType = eventType
+	if txhash != types.NilHash {
+		h.TxHash = txhash.Hex()
+	}
+	h.CreateTime = time.Now().Unix()
+
+	return nil
+}
+
+func bigintString(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	return amount.String()
+}
+
+// GetOrderHistory returns the transitions of an order in the order they happened.
+func (s *RdsService) GetOrderHistory(orderhash common.Hash) ([]OrderHistory, error) {
+	var list []OrderHistory
+	err := s.Db.Where("order_hash = ?", orderhash.Hex()).Order("id").Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,217 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Loopring/relay-cluster/kms"
+	"github.com/Loopring/relay-lib/crypto"
+	"github.com/Loopring/relay-lib/types"
+)
+
+// sealed private keys are about 220 chars
+const privateKeyColumnType = "varchar(256)"
+
+// sealPrivateKey encrypts the auth private key of market orders by kms, it's kept in plaintext if kms isn't enabled.
+func sealPrivateKey(key crypto.EthPrivateKeyCrypto) (string, error) {
+	text, err := key.MarshalText()
+	if err != nil || len(text) == 0 {
+		return "", err
+	}
+	if !kms.IsEnabled() {
+		return string(text), nil
+	}
+	return kms.Seal(text)
+}
+
+// openPrivateKey decrypts the auth private key sealed by kms or saved in plaintext before kms was enabled.
+func openPrivateKey(privKey string) (crypto.EthPrivateKeyCrypto, error) {
+	if len(privKey) == 0 {
+		return crypto.EthPrivateKeyCrypto{}, nil
+	}
+	if !kms.IsSealed(privKey) {
+		return crypto.NewPrivateKeyCrypto(false, privKey)
+	}
+
+	text, err := kms.Open(privKey)
+	if err != nil {
+		return crypto.EthPrivateKeyCrypto{}, err
+	}
+	return crypto.NewPrivateKeyCrypto(false, string(text))
+}
+
+// marshalRawOrder returns the order in json without its auth private key, which is sealed apart.
+func marshalRawOrder(src *types.Order) (rawOrder, privKey string, err error) {
+	if privKey, err = sealPrivateKey(src.AuthPrivateKey); err != nil {
+		return "", "", err
+	}
+
+	order := *src
+	order.AuthPrivateKey = crypto.EthPrivateKeyCrypto{}
+	data, err := json.Marshal(&order)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), privKey, nil
+}
+
+// EncryptPrivateKeys widens the priv_key columns and seals auth private keys saved in plaintext before kms
+// was enabled, including those in raw orders of scheduled orders and stop orders. It returns the number of
+// rows encrypted, and can be run again if it's interrupted.
+func (s *RdsService) EncryptPrivateKeys(batchSize int) (int, error) {
+	if !kms.IsEnabled() {
+		return 0, fmt.Errorf("kms is not enabled")
+	}
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("batch size invalid")
+	}
+
+	// columns of scheduled orders and stop orders are created wide enough
+	if err := s.Db.Model(&Order{}).ModifyColumn("priv_key", privateKeyColumnType).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	nums, err := s.encryptOrderPrivateKeys(batchSize)
+	total += nums
+	if err != nil {
+		return total, err
+	}
+	nums, err = s.encryptScheduledOrderPrivateKeys(batchSize)
+	total += nums
+	if err != nil {
+		return total, err
+	}
+	nums, err = s.encryptStopOrderPrivateKeys(batchSize)
+	total += nums
+	return total, err
+}
+
+func (s *RdsService) encryptOrderPrivateKeys(batchSize int) (int, error) {
+	total, lastId := 0, 0
+	for {
+		var list []Order
+		err := s.Db.Select("id, priv_key").
+			Where("id > ?", lastId).
+			Where("priv_key <> ''").
+			Order("id").Limit(batchSize).
+			Find(&list).Error
+		if err != nil {
+			return total, err
+		}
+
+		for _, v := range list {
+			lastId = v.ID
+			if kms.IsSealed(v.PrivateKey) {
+				continue
+			}
+			key, err := openPrivateKey(v.PrivateKey)
+			if err != nil {
+				return total, fmt.Errorf("order id:%d private key invalid, %s", v.ID, err.Error())
+			}
+			privKey, err := sealPrivateKey(key)
+			if err != nil {
+				return total, err
+			}
+			if err := s.Db.Model(&Order{}).Where("id = ?", v.ID).UpdateColumn("priv_key", privKey).Error; err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(list) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *RdsService) encryptScheduledOrderPrivateKeys(batchSize int) (int, error) {
+	total, lastId := 0, 0
+	for {
+		var list []ScheduledOrder
+		if err := s.Db.Where("id > ?", lastId).Order("id").Limit(batchSize).Find(&list).Error; err != nil {
+			return total, err
+		}
+
+		for _, v := range list {
+			lastId = v.ID
+			rawOrder, privKey, ok, err := sealRawOrder(v.RawOrder)
+			if err != nil {
+				return total, fmt.Errorf("scheduled order id:%d raw order invalid, %s", v.ID, err.Error())
+			}
+			if !ok {
+				continue
+			}
+			err = s.Db.Model(&ScheduledOrder{}).Where("id = ?", v.ID).
+				UpdateColumns(map[string]interface{}{"raw_order": rawOrder, "priv_key": privKey}).Error
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(list) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *RdsService) encryptStopOrderPrivateKeys(batchSize int) (int, error) {
+	total, lastId := 0, 0
+	for {
+		var list []StopOrder
+		if err := s.Db.Where("id > ?", lastId).Order("id").Limit(batchSize).Find(&list).Error; err != nil {
+			return total, err
+		}
+
+		for _, v := range list {
+			lastId = v.ID
+			rawOrder, privKey, ok, err := sealRawOrder(v.RawOrder)
+			if err != nil {
+				return total, fmt.Errorf("stop order id:%d raw order invalid, %s", v.ID, err.Error())
+			}
+			if !ok {
+				continue
+			}
+			err = s.Db.Model(&StopOrder{}).Where("id = ?", v.ID).
+				UpdateColumns(map[string]interface{}{"raw_order": rawOrder, "priv_key": privKey}).Error
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(list) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// sealRawOrder moves the auth private key out of a raw order saved before it was sealed apart,
+// ok is false if the raw order doesn't contain one.
+func sealRawOrder(data string) (rawOrder, privKey string, ok bool, err error) {
+	order := &types.Order{}
+	if err = json.Unmarshal([]byte(data), order); err != nil {
+		return "", "", false, err
+	}
+	if text, _ := order.AuthPrivateKey.MarshalText(); len(text) == 0 {
+		return "", "", false, nil
+	}
+
+	rawOrder, privKey, err = marshalRawOrder(order)
+	return rawOrder, privKey, err == nil, err
+}
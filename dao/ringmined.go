@@ -28,7 +28,7 @@ import (
 type RingMinedEvent struct {
 	ID                 int    `gorm:"column:id;primary_key" json:"id"`
 	Protocol           string `gorm:"column:contract_address;type:varchar(42)" json:"protocol"`
-	DelegateAddress    string `gorm:"column:delegate_address;type:varchar(42)" json:"delegateAddress"`
+	DelegateAddress    string `gorm:"column:delegate_address;type:varchar(42);index:delegate_address_time" json:"delegateAddress"`
 	RingIndex          string `gorm:"column:ring_index;type:varchar(40)" json:"ringIndex"`
 	RingHash           string `gorm:"column:ring_hash;type:varchar(82)" json:"ringHash"`
 	TxHash             string `gorm:"column:tx_hash;type:varchar(82)" json:"txHash"`
@@ -39,7 +39,7 @@ type RingMinedEvent struct {
 	BlockNumber        int64  `gorm:"column:block_number;type:bigint" json:"blockNumber"`
 	TotalLrcFee        string `gorm:"column:total_lrc_fee;type:varchar(40)" json:"totalLrcFee"`
 	TradeAmount        int    `gorm:"column:trade_amount" json:"tradeAmount"`
-	Time               int64  `gorm:"column:time;type:bigint" json:"timestamp"`
+	Time               int64  `gorm:"column:time;type:bigint;index:delegate_address_time" json:"timestamp"`
 	Status             uint8  `gorm:"column:status;type:tinyint(4)"`
 	Fork               bool   `gorm:"column:fork"`
 	GasLimit           string `gorm:"column:gas_limit;type:varchar(50)"`
@@ -126,6 +126,14 @@ func (s *RdsService) FindRingMined(txhash string) (*RingMinedEvent, error) {
 	return &model, err
 }
 
+func (s *RdsService) GetRingMinedForkEvents(from, to int64) ([]RingMinedEvent, error) {
+	var list []RingMinedEvent
+	err := s.Db.Where("block_number > ? and block_number <= ?", from, to).
+		Where("fork = ?", false).
+		Find(&list).Error
+	return list, err
+}
+
 func (s *RdsService) RollBackRingMined(from, to int64) error {
 	return s.Db.Model(&RingMinedEvent{}).Where("block_number > ? and block_number <= ?", from, to).Update("fork", true).Error
 }
@@ -150,6 +158,33 @@ func (s *RdsService) RingMinedPageQuery(query map[string]interface{}, pageIndex,
 	return
 }
 
+func (s *RdsService) RingMinedCursorQuery(query map[string]interface{}, token string, pageSize int) (res PageResult, err error) {
+	ringMined := make([]RingMinedEvent, 0)
+	res = PageResult{Data: make([]interface{}, 0)}
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		return res, err
+	}
+	pageSize = normalizeCursorPageSize(pageSize, 20)
+	res.PageSize = pageSize
+
+	err = cursorScope(s.Db.Where(query).Where("fork = ?", false), "time", cursor, pageSize).Find(&ringMined).Error
+	if err != nil {
+		return res, err
+	}
+
+	if len(ringMined) > pageSize {
+		ringMined = ringMined[:pageSize]
+		last := ringMined[pageSize-1]
+		res.NextCursor = EncodeCursor(last.Time, last.ID)
+	}
+	for _, rm := range ringMined {
+		res.Data = append(res.Data, rm)
+	}
+	return
+}
+
 func (s *RdsService) GetRingminedMethods(lastId int, limit int) ([]RingMinedEvent, error) {
 	var (
 		list []RingMinedEvent
@@ -0,0 +1,175 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"encoding/json"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// ScheduledOrder is an order valid since far in future, it's kept out of lpr_orders until released.
+// Retries counts the releases failed by errors, eg. kms or balance queries.
+type ScheduledOrder struct {
+	ID         int    `gorm:"column:id;primary_key;"`
+	OrderHash  string `gorm:"column:order_hash;type:varchar(82);unique_index"`
+	Owner      string `gorm:"column:owner;type:varchar(42);index"`
+	Market     string `gorm:"column:market;type:varchar(40)"`
+	ValidSince int64  `gorm:"column:valid_since;type:bigint;index"`
+	ValidUntil int64  `gorm:"column:valid_until;type:bigint"`
+	RawOrder   string `gorm:"column:raw_order;type:text"`
+	PrivateKey string `gorm:"column:priv_key;type:varchar(256)"`
+	Status     uint8  `gorm:"column:status;type:tinyint(4)"`
+	Reason     string `gorm:"column:reason;type:varchar(128)"`
+	Retries    int    `gorm:"column:retries;type:int"`
+	CreateTime int64  `gorm:"column:create_time;type:bigint"`
+	UpdateTime int64  `gorm:"column:update_time;type:bigint"`
+}
+
+func (o *ScheduledOrder) ConvertDown(src *types.Order) error {
+	rawOrder, privKey, err := marshalRawOrder(src)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	o.OrderHash = src.Hash.Hex()
+	o.Owner = src.Owner.Hex()
+	o.Market = src.Market
+	o.ValidSince = src.ValidSince.Int64()
+	o.ValidUntil = src.ValidUntil.Int64()
+	o.RawOrder = rawOrder
+	o.PrivateKey = privKey
+	o.Status = uint8(omtyp.SCHEDULED_ORDER_PENDING)
+	o.CreateTime = now
+	o.UpdateTime = now
+
+	return nil
+}
+
+// ConvertUp returns the order without its auth private key, see DecryptPrivateKey.
+func (o *ScheduledOrder) ConvertUp(dst *types.Order) error {
+	return json.Unmarshal([]byte(o.RawOrder), dst)
+}
+
+// DecryptPrivateKey sets the auth private key of dst, it should only be used for orders being released.
+func (o *ScheduledOrder) DecryptPrivateKey(dst *types.Order) error {
+	// raw orders saved before keys were sealed apart contain keys already
+	if len(o.PrivateKey) == 0 {
+		return nil
+	}
+	key, err := openPrivateKey(o.PrivateKey)
+	if err != nil {
+		return err
+	}
+	dst.AuthPrivateKey = key
+	return nil
+}
+
+func (s *RdsService) GetScheduledOrderByHash(orderhash common.Hash) (ScheduledOrder, error) {
+	var order ScheduledOrder
+	err := s.Db.Where("order_hash = ?", orderhash.Hex()).First(&order).Error
+	return order, err
+}
+
+// GetScheduledOrders returns orders of the owner in statusList by valid_since, all are returned if statusList is empty.
+func (s *RdsService) GetScheduledOrders(owner common.Address, statusList []omtyp.ScheduledOrderStatus) ([]ScheduledOrder, error) {
+	var list []ScheduledOrder
+	db := s.Db.Where("owner = ?", owner.Hex())
+	if len(statusList) > 0 {
+		db = db.Where("status in (?)", statusList)
+	}
+	err := db.Order("valid_since, id").Find(&list).Error
+	return list, err
+}
+
+// GetDueScheduledOrders returns at most limit pending orders whose valid_since arrived by id, after the order of afterId.
+func (s *RdsService) GetDueScheduledOrders(now int64, afterId int, limit int) ([]ScheduledOrder, error) {
+	var list []ScheduledOrder
+	err := s.Db.Where("status = ?", omtyp.SCHEDULED_ORDER_PENDING).
+		Where("valid_since <= ?", now).
+		Where("id > ?", afterId).
+		Order("id").Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// AddScheduledOrderRetry counts a failed release of the pending order.
+func (s *RdsService) AddScheduledOrderRetry(orderhash string) error {
+	return s.Db.Model(&ScheduledOrder{}).
+		Where("order_hash = ?", orderhash).
+		Where("status = ?", omtyp.SCHEDULED_ORDER_PENDING).
+		Updates(map[string]interface{}{
+			"retries":     gorm.Expr("retries + ?", 1),
+			"update_time": time.Now().Unix(),
+		}).Error
+}
+
+// SetScheduledOrderStatus updates a pending order, it returns 0 if the order isn't pending any more.
+func (s *RdsService) SetScheduledOrderStatus(orderhash string, status omtyp.ScheduledOrderStatus, reason string) (int64, error) {
+	ret := s.Db.Model(&ScheduledOrder{}).
+		Where("order_hash = ?", orderhash).
+		Where("status = ?", omtyp.SCHEDULED_ORDER_PENDING).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reason":      reason,
+			"update_time": time.Now().Unix(),
+		})
+	return ret.RowsAffected, ret.Error
+}
+
+// CancelScheduledOrders cancels the pending orders of owner in orderhashList, or all of them if the list is empty,
+// and returns the hashes of orders cancelled. Only orders of market are cancelled if it isn't empty, and only
+// orders valid since before cutoff are cancelled if it's greater than 0, same as the cutoff of the contract.
+func (s *RdsService) CancelScheduledOrders(owner common.Address, orderhashList []common.Hash, market string, cutoff int64) []common.Hash {
+	var (
+		list   []ScheduledOrder
+		hashes []common.Hash
+	)
+
+	db := s.Db.Model(&ScheduledOrder{}).
+		Where("owner = ?", owner.Hex()).
+		Where("status = ?", omtyp.SCHEDULED_ORDER_PENDING)
+	if len(orderhashList) > 0 {
+		var orderhashes []string
+		for _, v := range orderhashList {
+			orderhashes = append(orderhashes, v.Hex())
+		}
+		db = db.Where("order_hash in (?)", orderhashes)
+	}
+	if market != "" {
+		db = db.Where("market = ?", market)
+	}
+	if cutoff > 0 {
+		db = db.Where("valid_since < ?", cutoff)
+	}
+	if err := db.Find(&list).Error; err != nil {
+		return hashes
+	}
+
+	for _, v := range list {
+		if nums, err := s.SetScheduledOrderStatus(v.OrderHash, omtyp.SCHEDULED_ORDER_CANCELLED, ""); err == nil && nums > 0 {
+			hashes = append(hashes, common.HexToHash(v.OrderHash))
+		}
+	}
+	return hashes
+}
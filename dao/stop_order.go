@@ -0,0 +1,205 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package dao
+
+import (
+	"encoding/json"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// StopOrder is an order kept out of lpr_orders until the last trade price of its market meets the condition.
+// LastPrice is the price the order was triggered at, Retries counts the triggers failed by errors.
+type StopOrder struct {
+	ID           int     `gorm:"column:id;primary_key;"`
+	OrderHash    string  `gorm:"column:order_hash;type:varchar(82);unique_index"`
+	Owner        string  `gorm:"column:owner;type:varchar(42);index"`
+	Market       string  `gorm:"column:market;type:varchar(40);index"`
+	Condition    string  `gorm:"column:stop_condition;type:varchar(10)"`
+	TriggerPrice float64 `gorm:"column:trigger_price;type:double"`
+	LastPrice    float64 `gorm:"column:last_price;type:double"`
+	ValidSince   int64   `gorm:"column:valid_since;type:bigint"`
+	ValidUntil   int64   `gorm:"column:valid_until;type:bigint"`
+	RawOrder     string  `gorm:"column:raw_order;type:text"`
+	PrivateKey   string  `gorm:"column:priv_key;type:varchar(256)"`
+	Status       uint8   `gorm:"column:status;type:tinyint(4)"`
+	Reason       string  `gorm:"column:reason;type:varchar(128)"`
+	Retries      int     `gorm:"column:retries;type:int"`
+	CreateTime   int64   `gorm:"column:create_time;type:bigint"`
+	UpdateTime   int64   `gorm:"column:update_time;type:bigint"`
+}
+
+func (o *StopOrder) ConvertDown(src *types.Order, condition omtyp.StopCondition, triggerPrice float64) error {
+	rawOrder, privKey, err := marshalRawOrder(src)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	o.OrderHash = src.Hash.Hex()
+	o.Owner = src.Owner.Hex()
+	o.Market = src.Market
+	o.Condition = string(condition)
+	o.TriggerPrice = triggerPrice
+	o.ValidSince = src.ValidSince.Int64()
+	o.ValidUntil = src.ValidUntil.Int64()
+	o.RawOrder = rawOrder
+	o.PrivateKey = privKey
+	o.Status = uint8(omtyp.STOP_ORDER_PENDING)
+	o.CreateTime = now
+	o.UpdateTime = now
+
+	return nil
+}
+
+// ConvertUp returns the order without its auth private key, see DecryptPrivateKey.
+func (o *StopOrder) ConvertUp(dst *types.Order) error {
+	return json.Unmarshal([]byte(o.RawOrder), dst)
+}
+
+// DecryptPrivateKey sets the auth private key of dst, it should only be used for orders being triggered.
+func (o *StopOrder) DecryptPrivateKey(dst *types.Order) error {
+	// raw orders saved before keys were sealed apart contain keys already
+	if len(o.PrivateKey) == 0 {
+		return nil
+	}
+	key, err := openPrivateKey(o.PrivateKey)
+	if err != nil {
+		return err
+	}
+	dst.AuthPrivateKey = key
+	return nil
+}
+
+func (s *RdsService) GetStopOrderByHash(orderhash common.Hash) (StopOrder, error) {
+	var order StopOrder
+	err := s.Db.Where("order_hash = ?", orderhash.Hex()).First(&order).Error
+	return order, err
+}
+
+// GetStopOrders returns orders of the owner in statusList by id desc, all are returned if statusList is empty.
+func (s *RdsService) GetStopOrders(owner common.Address, statusList []omtyp.StopOrderStatus) ([]StopOrder, error) {
+	var list []StopOrder
+	db := s.Db.Where("owner = ?", owner.Hex())
+	if len(statusList) > 0 {
+		db = db.Where("status in (?)", statusList)
+	}
+	err := db.Order("id desc").Find(&list).Error
+	return list, err
+}
+
+// GetStopOrderMarkets returns the markets having pending orders.
+func (s *RdsService) GetStopOrderMarkets() ([]string, error) {
+	var markets []string
+	err := s.Db.Model(&StopOrder{}).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING).
+		Pluck("distinct(market)", &markets).Error
+	return markets, err
+}
+
+// GetTriggeredStopOrders returns at most limit pending orders of the market whose condition is met by lastPrice
+// by id, after the order of afterId.
+func (s *RdsService) GetTriggeredStopOrders(market string, lastPrice float64, afterId int, limit int) ([]StopOrder, error) {
+	var list []StopOrder
+	err := s.Db.Where("market = ?", market).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING).
+		Where("id > ?", afterId).
+		Where("(stop_condition = ? and trigger_price <= ?) or (stop_condition = ? and trigger_price >= ?)",
+			omtyp.STOP_CONDITION_GTE, lastPrice, omtyp.STOP_CONDITION_LTE, lastPrice).
+		Order("id").Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// SetStopOrderStatus updates a pending order, it returns 0 if the order isn't pending any more.
+func (s *RdsService) SetStopOrderStatus(orderhash string, status omtyp.StopOrderStatus, reason string, lastPrice float64) (int64, error) {
+	ret := s.Db.Model(&StopOrder{}).
+		Where("order_hash = ?", orderhash).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reason":      reason,
+			"last_price":  lastPrice,
+			"update_time": time.Now().Unix(),
+		})
+	return ret.RowsAffected, ret.Error
+}
+
+// AddStopOrderRetry counts a failed trigger of the pending order.
+func (s *RdsService) AddStopOrderRetry(orderhash string) error {
+	return s.Db.Model(&StopOrder{}).
+		Where("order_hash = ?", orderhash).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING).
+		Updates(map[string]interface{}{
+			"retries":     gorm.Expr("retries + ?", 1),
+			"update_time": time.Now().Unix(),
+		}).Error
+}
+
+// ExpireStopOrders updates the pending orders passed their valid_until to expired.
+func (s *RdsService) ExpireStopOrders(now int64) (int64, error) {
+	ret := s.Db.Model(&StopOrder{}).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING).
+		Where("valid_until < ?", now).
+		Updates(map[string]interface{}{
+			"status":      omtyp.STOP_ORDER_EXPIRED,
+			"update_time": now,
+		})
+	return ret.RowsAffected, ret.Error
+}
+
+// CancelStopOrders cancels the pending orders of owner in orderhashList, or all of them if the list is empty,
+// and returns the hashes of orders cancelled. Only orders of market are cancelled if it isn't empty, and only
+// orders valid since before cutoff are cancelled if it's greater than 0, same as the cutoff of the contract.
+func (s *RdsService) CancelStopOrders(owner common.Address, orderhashList []common.Hash, market string, cutoff int64) []common.Hash {
+	var (
+		list   []StopOrder
+		hashes []common.Hash
+	)
+
+	db := s.Db.Model(&StopOrder{}).
+		Where("owner = ?", owner.Hex()).
+		Where("status = ?", omtyp.STOP_ORDER_PENDING)
+	if len(orderhashList) > 0 {
+		var orderhashes []string
+		for _, v := range orderhashList {
+			orderhashes = append(orderhashes, v.Hex())
+		}
+		db = db.Where("order_hash in (?)", orderhashes)
+	}
+	if market != "" {
+		db = db.Where("market = ?", market)
+	}
+	if cutoff > 0 {
+		db = db.Where("valid_since < ?", cutoff)
+	}
+	if err := db.Find(&list).Error; err != nil {
+		return hashes
+	}
+
+	for _, v := range list {
+		if nums, err := s.SetStopOrderStatus(v.OrderHash, omtyp.STOP_ORDER_CANCELLED, "", 0); err == nil && nums > 0 {
+			hashes = append(hashes, common.HexToHash(v.OrderHash))
+		}
+	}
+	return hashes
+}
@@ -28,7 +28,7 @@ import (
 type TransactionView struct {
 	ID          int    `gorm:"column:id;primary_key;"`
 	Symbol      string `gorm:"column:symbol;type:varchar(20)"`
-	Owner       string `gorm:"column:owner;type:varchar(42)"`
+	Owner       string `gorm:"column:owner;type:varchar(42);index:owner_create_time"`
 	TxHash      string `gorm:"column:tx_hash;type:varchar(82)"`
 	BlockNumber int64  `gorm:"column:block_number"`
 	LogIndex    int64  `gorm:"column:tx_log_index"`
@@ -36,7 +36,7 @@ type TransactionView struct {
 	Nonce       int64  `gorm:"column:nonce"`
 	Type        uint8  `gorm:"column:tx_type"`
 	Status      uint8  `gorm:"column:status"`
-	CreateTime  int64  `gorm:"column:create_time"`
+	CreateTime  int64  `gorm:"column:create_time;index:owner_create_time"`
 	UpdateTime  int64  `gorm:"column:update_time"`
 	Fork        bool   `gorm:"column:fork"`
 }
@@ -143,6 +143,36 @@ func (s *RdsService) GetTxViewByOwner(owner string, symbol string, status types.
 	return txs, err
 }
 
+// GetTxViewByOwnerWithCursor returns the page after token and the token of the next page,
+// the next token is empty when there is no more data. Pages are sorted by create_time, which never changes,
+// while update_time changes with the tx status and would skip or repeat rows.
+func (s *RdsService) GetTxViewByOwnerWithCursor(owner string, symbol string, status types.TxStatus, typ txtyp.TxType, token string, limit int) ([]TransactionView, string, error) {
+	var (
+		txs  []TransactionView
+		next string
+	)
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		return txs, next, err
+	}
+	limit = normalizeCursorPageSize(limit, 10)
+
+	query := assembleTxViewQuery(owner, symbol, status, typ)
+
+	if err = cursorScope(s.Db.Where(query), "create_time", cursor, limit).Find(&txs).Error; err != nil {
+		return txs, next, err
+	}
+
+	if len(txs) > limit {
+		txs = txs[:limit]
+		last := txs[limit-1]
+		next = EncodeCursor(last.CreateTime, last.ID)
+	}
+
+	return txs, next, nil
+}
+
 func (s *RdsService) RollBackTxView(from, to int64) error {
 	return s.Db.Model(&TransactionView{}).Where("block_number > ? and block_number <= ?", from, to).Update("fork", true).Error
 }
@@ -1,3 +1,6 @@
+//go:build integration
+// +build integration
+
 /*
   Copyright 2017 Loopring Project Ltd (Loopring Foundation).
   Licensed under the Apache License, Version 2.0 (the "License");
@@ -0,0 +1,169 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Loopring/relay-lib/cache"
+	"github.com/Loopring/relay-lib/log"
+	"net/http"
+	"time"
+)
+
+const (
+	ApiKeyHeader             = "X-Api-Key"
+	ApiKeyQuotaPrefix        = "lpr_api_key_quota_"
+	DefaultApiKeyQuotaPeriod = 60
+)
+
+// ApiKeyOptions grants a partner keyed access to the jsonrpc endpoint.
+// Origin is optional, an empty origin accepts the key from any origin(eg. server to server calls).
+// Quota is the max requests allowed in QuotaPeriod seconds, 0 means unlimited.
+type ApiKeyOptions struct {
+	Name        string
+	Key         string
+	Origin      string
+	Quota       int64
+	QuotaPeriod int64
+}
+
+type apiKeyHandler struct {
+	next           http.Handler
+	keys           map[string]ApiKeyOptions
+	keyedOrigins   map[string]bool
+	allowedOrigins map[string]bool
+}
+
+// newApiKeyHandler returns srv untouched when there is no api key in config.
+// Otherwise requests carrying ApiKeyHeader are checked against the configured keys and quotas,
+// and origins that are only reachable by key are rejected when the key is missing. Empty allowedOrigins
+// means any origin as cors does, so keys are only required when allowedOrigins is restricted.
+func newApiKeyHandler(srv http.Handler, apiKeys []ApiKeyOptions, allowedOrigins []string) http.Handler {
+	if len(apiKeys) == 0 {
+		return srv
+	}
+
+	h := &apiKeyHandler{
+		next:           srv,
+		keys:           make(map[string]ApiKeyOptions),
+		keyedOrigins:   make(map[string]bool),
+		allowedOrigins: make(map[string]bool),
+	}
+	if len(allowedOrigins) == 0 {
+		h.allowedOrigins["*"] = true
+	}
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[origin] = true
+	}
+	for _, apiKey := range apiKeys {
+		if apiKey.Key == "" {
+			log.Errorf("api key of %s is empty, ignored", apiKey.Name)
+			continue
+		}
+		if apiKey.QuotaPeriod <= 0 {
+			apiKey.QuotaPeriod = DefaultApiKeyQuotaPeriod
+		}
+		h.keys[apiKey.Key] = apiKey
+		if apiKey.Origin != "" {
+			h.keyedOrigins[apiKey.Origin] = true
+		}
+	}
+
+	return h
+}
+
+func (h *apiKeyHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	// preflight requests never carry custom headers, leave them to cors
+	if req.Method == http.MethodOptions {
+		h.next.ServeHTTP(writer, req)
+		return
+	}
+
+	origin := req.Header.Get("Origin")
+	key := req.Header.Get(ApiKeyHeader)
+
+	if key == "" {
+		if origin != "" && h.keyedOrigins[origin] && !h.allowedOrigins[origin] && !h.allowedOrigins["*"] {
+			writeApiKeyError(writer, http.StatusUnauthorized, "api key required for origin "+origin)
+			return
+		}
+		h.next.ServeHTTP(writer, req)
+		return
+	}
+
+	apiKey, ok := h.keys[key]
+	if !ok {
+		writeApiKeyError(writer, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	if apiKey.Origin != "" && apiKey.Origin != origin {
+		writeApiKeyError(writer, http.StatusForbidden, "api key is not allowed for origin "+origin)
+		return
+	}
+	if !consumeApiKeyQuota(apiKey, time.Now().Unix()) {
+		writeApiKeyError(writer, http.StatusTooManyRequests, "api key quota exceeded")
+		return
+	}
+
+	h.next.ServeHTTP(writer, req)
+}
+
+// consumeApiKeyQuota counts requests in fixed windows of QuotaPeriod seconds,
+// the counter is shared by all relay nodes through redis.
+// Quota is not enforced when redis is unavailable.
+func consumeApiKeyQuota(apiKey ApiKeyOptions, now int64) bool {
+	if apiKey.Quota <= 0 {
+		return true
+	}
+
+	count, err := incrWindowCounter(ApiKeyQuotaPrefix+apiKey.Key, apiKey.QuotaPeriod, now)
+	if nil != err {
+		log.Errorf("failed to count quota of api key %s, err:%s", apiKey.Name, err.Error())
+		return true
+	}
+
+	return count <= apiKey.Quota
+}
+
+// incrWindowCounter increases the redis counter of the fixed window of period seconds that now falls in.
+func incrWindowCounter(prefix string, period int64, now int64) (int64, error) {
+	windowStart := now - now%period
+	cacheKey := fmt.Sprintf("%s_%d", prefix, windowStart)
+	count, err := cache.Incr(cacheKey)
+	if nil != err {
+		return 0, err
+	}
+	if count == 1 {
+		if err := cache.ExpireAt(cacheKey, windowStart+period); nil != err {
+			log.Errorf("failed to set expiration of counter %s, err:%s", cacheKey, err.Error())
+		}
+	}
+	return count, nil
+}
+
+func writeApiKeyError(writer http.ResponseWriter, status int, message string) {
+	res := NewJsonRpcRes()
+	res.Error = &JsonRpcError{Message: message}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if data, err := json.Marshal(res); nil == err {
+		writer.Write(data)
+	}
+}
@@ -109,6 +109,7 @@ func clientIp(req *http.Request) string {
 }
 
 type JsonRpcError struct {
+	Code    int    `json:"code,omitempty"`
 	Message string `json:"message"`
 }
 type JsonRpcRes struct {
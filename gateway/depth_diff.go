@@ -0,0 +1,159 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"strings"
+	"sync"
+)
+
+// DepthSnapshot is the full depth of a market at Sequence.
+type DepthSnapshot struct {
+	DelegateAddress string `json:"delegateAddress"`
+	Market          string `json:"market"`
+	Sequence        int64  `json:"sequence"`
+	Depth           AskBid `json:"depth"`
+}
+
+// DepthDiff contains the levels changed between PrevSequence and Sequence,
+// every level is [price, amount, size] as in Depth, a level with amount "0" has been removed.
+// A client should resync with GetDepthSnapshot when PrevSequence isn't the sequence it holds.
+type DepthDiff struct {
+	DelegateAddress string     `json:"delegateAddress"`
+	Market          string     `json:"market"`
+	PrevSequence    int64      `json:"prevSequence"`
+	Sequence        int64      `json:"sequence"`
+	Buy             [][]string `json:"buy"`
+	Sell            [][]string `json:"sell"`
+}
+
+const removedDepthAmount = "0"
+
+type depthState struct {
+	sequence int64
+	depth    Depth
+}
+
+// depthDiffTracker keeps the last depth pushed of every market,
+// sequences increase by one for every change of a market and are kept in memory of the relay node.
+type depthDiffTracker struct {
+	mtx    sync.Mutex
+	states map[string]*depthState
+}
+
+func newDepthDiffTracker() *depthDiffTracker {
+	return &depthDiffTracker{states: make(map[string]*depthState)}
+}
+
+func depthMarketKey(delegateAddress, market string) string {
+	return strings.ToLower(delegateAddress) + "_" + strings.ToLower(market)
+}
+
+// update replaces the depth of the market and returns the diff against the previous one,
+// diff is nil when the market is tracked for the first time or nothing changed.
+func (t *depthDiffTracker) update(depth Depth) (*DepthDiff, DepthSnapshot) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	key := depthMarketKey(depth.DelegateAddress, depth.Market)
+	state, ok := t.states[key]
+	if !ok {
+		state = &depthState{sequence: 1, depth: depth}
+		t.states[key] = state
+		return nil, newDepthSnapshot(depth, state.sequence)
+	}
+
+	buy := diffDepthLevels(state.depth.Depth.Buy, depth.Depth.Buy)
+	sell := diffDepthLevels(state.depth.Depth.Sell, depth.Depth.Sell)
+	if len(buy) == 0 && len(sell) == 0 {
+		return nil, newDepthSnapshot(depth, state.sequence)
+	}
+
+	diff := &DepthDiff{
+		DelegateAddress: depth.DelegateAddress,
+		Market:          depth.Market,
+		PrevSequence:    state.sequence,
+		Sequence:        state.sequence + 1,
+		Buy:             buy,
+		Sell:            sell,
+	}
+	state.sequence = diff.Sequence
+	state.depth = depth
+	return diff, newDepthSnapshot(depth, state.sequence)
+}
+
+// current returns the depth of the market pushed last time together with its sequence, it doesn't change the state,
+// so that a snapshot taken between two pushes is followed by the diff of the next push.
+func (t *depthDiffTracker) current(delegateAddress, market string) (DepthSnapshot, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	state, ok := t.states[depthMarketKey(delegateAddress, market)]
+	if !ok {
+		return DepthSnapshot{}, false
+	}
+	return newDepthSnapshot(state.depth, state.sequence), true
+}
+
+// track starts tracking a market with depth, a market tracked already keeps its state.
+func (t *depthDiffTracker) track(depth Depth) DepthSnapshot {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	key := depthMarketKey(depth.DelegateAddress, depth.Market)
+	state, ok := t.states[key]
+	if !ok {
+		state = &depthState{sequence: 1, depth: depth}
+		t.states[key] = state
+	}
+	return newDepthSnapshot(state.depth, state.sequence)
+}
+
+func newDepthSnapshot(depth Depth, sequence int64) DepthSnapshot {
+	return DepthSnapshot{DelegateAddress: depth.DelegateAddress, Market: depth.Market, Sequence: sequence, Depth: depth.Depth}
+}
+
+// diffDepthLevels returns the levels of next whose amount or size differs from prev,
+// and the levels of prev missing in next with amount and size "0".
+func diffDepthLevels(prev, next [][]string) [][]string {
+	prevLevels := make(map[string][]string)
+	for _, level := range prev {
+		if len(level) == 3 {
+			prevLevels[level[0]] = level
+		}
+	}
+
+	changed := make([][]string, 0)
+	nextPrices := make(map[string]bool)
+	for _, level := range next {
+		if len(level) != 3 {
+			continue
+		}
+		nextPrices[level[0]] = true
+		if p, ok := prevLevels[level[0]]; !ok || p[1] != level[1] || p[2] != level[2] {
+			changed = append(changed, level)
+		}
+	}
+	for _, level := range prev {
+		if len(level) == 3 && !nextPrices[level[0]] {
+			changed = append(changed, []string{level[0], removedDepthAmount, removedDepthAmount})
+		}
+	}
+	return changed
+}
@@ -0,0 +1,60 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"testing"
+)
+
+func newTestDepth(buy, sell [][]string) Depth {
+	return Depth{DelegateAddress: "0xDelegate", Market: "LRC-WETH", Depth: AskBid{Buy: buy, Sell: sell}}
+}
+
+func TestDepthDiffTrackerSnapshotBetweenPushes(t *testing.T) {
+	tracker := newDepthDiffTracker()
+
+	if _, ok := tracker.current("0xdelegate", "lrc-weth"); ok {
+		t.Fatalf("market shouldn't be tracked before the first push")
+	}
+
+	if diff, _ := tracker.update(newTestDepth([][]string{{"0.001", "10", "0.01"}}, [][]string{})); diff != nil {
+		t.Fatalf("first push shouldn't return diff")
+	}
+	diff, _ := tracker.update(newTestDepth([][]string{{"0.001", "20", "0.02"}}, [][]string{}))
+	if diff == nil || diff.PrevSequence != 1 || diff.Sequence != 2 {
+		t.Fatalf("second push expected diff 1->2, got %+v", diff)
+	}
+
+	// a snapshot taken between pushes doesn't advance the sequence, even if the depth has changed meanwhile
+	snapshot, ok := tracker.current("0xdelegate", "lrc-weth")
+	if !ok || snapshot.Sequence != 2 || snapshot.Depth.Buy[0][1] != "20" {
+		t.Fatalf("expected snapshot of sequence 2, got %+v", snapshot)
+	}
+	if tracked := tracker.track(newTestDepth([][]string{{"0.001", "30", "0.03"}}, [][]string{})); tracked.Sequence != 2 || tracked.Depth.Buy[0][1] != "20" {
+		t.Fatalf("track shouldn't change a tracked market, got %+v", tracked)
+	}
+
+	diff, _ = tracker.update(newTestDepth([][]string{{"0.001", "30", "0.03"}}, [][]string{{"0.002", "5", "0.01"}}))
+	if diff == nil || diff.PrevSequence != snapshot.Sequence || diff.Sequence != 3 {
+		t.Fatalf("expected diff following snapshot sequence %d, got %+v", snapshot.Sequence, diff)
+	}
+	if len(diff.Buy) != 1 || len(diff.Sell) != 1 {
+		t.Fatalf("expected one changed level on each side, got buy:%v sell:%v", diff.Buy, diff.Sell)
+	}
+}
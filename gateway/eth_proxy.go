@@ -0,0 +1,380 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Loopring/relay-lib/cache"
+	"github.com/Loopring/relay-lib/eth/accessor"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	EthProxyCachePrefix     = "lpr_eth_proxy_cache_"
+	EthProxyRateLimitPrefix = "lpr_eth_proxy_limit_"
+
+	defaultEthProxyPath              = "/eth"
+	defaultEthProxyCacheTtl          = 86400
+	defaultEthProxyConfirmBlocks     = 12
+	defaultEthProxyMaxLogsBlockRange = 1000
+	maxEthProxyRequestSize           = 1024 * 1024
+	ethProxyNodeTimeout              = 10 * time.Second
+
+	ethProxyErrMethodNotAllowed = -32601
+	ethProxyErrInvalidParams    = -32602
+	ethProxyErrInternal         = -32603
+	ethProxyErrParse            = -32700
+	ethProxyErrLimitExceeded    = -32005
+)
+
+// methods forwarded when config doesn't supply an allowlist
+var defaultEthProxyMethods = []string{
+	"eth_blockNumber",
+	"eth_gasPrice",
+	"eth_getBalance",
+	"eth_getTransactionCount",
+	"eth_getCode",
+	"eth_call",
+	"eth_estimateGas",
+	"eth_getTransactionByHash",
+	"eth_getTransactionReceipt",
+	"eth_getBlockByHash",
+	"eth_getBlockByNumber",
+	"eth_getLogs",
+	"eth_sendRawTransaction",
+}
+
+// results of these methods never change once their block is confirmed
+var ethProxyCacheableMethods = map[string]bool{
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+	"eth_getBlockByHash":        true,
+}
+
+// EthProxyOptions.Nodes are the urls of eth nodes tried in order, the raw urls of accessor are used when it's empty.
+type EthProxyOptions struct {
+	Enable            bool
+	Path              string
+	Nodes             []string
+	Methods           []EthProxyMethodOptions
+	CacheTtl          int64
+	ConfirmBlocks     int64
+	MaxLogsBlockRange int64
+}
+
+// EthProxyMethodOptions allows a method through the proxy,
+// RateLimit is the max requests per second of a single client ip, 0 means unlimited, see remoteIp.
+type EthProxyMethodOptions struct {
+	Name      string
+	RateLimit int64
+}
+
+// EthProxy forwards allowlisted ethereum jsonrpc requests to the configured eth nodes, a request failed by
+// a node, either unreachable or returning an error, is retried on the next one. Raw transactions are sent to
+// all nodes by accessor. The rate limit is applied to the ip of the peer, or the client ip forwarded by
+// a trusted proxy of GateWayOptions.
+type EthProxy struct {
+	options EthProxyOptions
+	methods map[string]EthProxyMethodOptions
+	nodes   []*ethProxyNode
+}
+
+type ethProxyNode struct {
+	url    string
+	client *rpc.Client
+}
+
+type ethProxyRequest struct {
+	Id     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func NewEthProxy(options EthProxyOptions) *EthProxy {
+	p := &EthProxy{}
+	if options.Path == "" {
+		options.Path = defaultEthProxyPath
+	}
+	if options.CacheTtl <= 0 {
+		options.CacheTtl = defaultEthProxyCacheTtl
+	}
+	if options.ConfirmBlocks <= 0 {
+		options.ConfirmBlocks = defaultEthProxyConfirmBlocks
+	}
+	if options.MaxLogsBlockRange <= 0 {
+		options.MaxLogsBlockRange = defaultEthProxyMaxLogsBlockRange
+	}
+	if len(options.Methods) == 0 {
+		for _, method := range defaultEthProxyMethods {
+			options.Methods = append(options.Methods, EthProxyMethodOptions{Name: method})
+		}
+	}
+
+	p.options = options
+	p.methods = make(map[string]EthProxyMethodOptions)
+	for _, method := range options.Methods {
+		p.methods[method.Name] = method
+	}
+	httpClient := &http.Client{Timeout: ethProxyNodeTimeout}
+	for _, url := range options.Nodes {
+		if client, err := rpc.DialHTTPWithClient(url, httpClient); nil != err {
+			log.Errorf("eth proxy, failed to dial eth node:%s, err:%s", url, err.Error())
+		} else {
+			p.nodes = append(p.nodes, &ethProxyNode{url: url, client: client})
+		}
+	}
+	return p
+}
+
+func (p *EthProxy) Path() string {
+	return p.options.Path
+}
+
+// ServeHTTP accepts both a single request and a batch of requests.
+func (p *EthProxy) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(writer, req.Body, maxEthProxyRequestSize))
+	if err != nil {
+		writeEthProxyResult(writer, newEthProxyError(nil, ethProxyErrParse, err.Error()))
+		return
+	}
+
+	ip := remoteIp(req)
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []ethProxyRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			writeEthProxyResult(writer, newEthProxyError(nil, ethProxyErrParse, err.Error()))
+			return
+		}
+		results := make([]JsonRpcRes, 0)
+		for _, r := range reqs {
+			results = append(results, p.handle(r, ip))
+		}
+		writeEthProxyResult(writer, results)
+	} else {
+		var r ethProxyRequest
+		if err := json.Unmarshal(body, &r); err != nil {
+			writeEthProxyResult(writer, newEthProxyError(nil, ethProxyErrParse, err.Error()))
+			return
+		}
+		writeEthProxyResult(writer, p.handle(r, ip))
+	}
+}
+
+func (p *EthProxy) handle(req ethProxyRequest, ip string) JsonRpcRes {
+	method, ok := p.methods[req.Method]
+	if !ok {
+		return newEthProxyError(req.Id, ethProxyErrMethodNotAllowed, "method "+req.Method+" is not allowed")
+	}
+
+	if method.RateLimit > 0 {
+		if count, err := incrWindowCounter(EthProxyRateLimitPrefix+method.Name+"_"+ip, 1, time.Now().Unix()); nil != err {
+			log.Errorf("eth proxy, failed to count requests of %s, err:%s", method.Name, err.Error())
+		} else if count > method.RateLimit {
+			return newEthProxyError(req.Id, ethProxyErrLimitExceeded, "rate limit of "+method.Name+" exceeded")
+		}
+	}
+
+	if req.Method == "eth_getLogs" {
+		if err := p.checkLogsRange(req.Params); nil != err {
+			return newEthProxyError(req.Id, ethProxyErrInvalidParams, err.Error())
+		}
+	}
+
+	cacheKey := ""
+	if ethProxyCacheableMethods[req.Method] {
+		cacheKey = ethProxyCacheKey(req.Method, req.Params)
+		if data, err := cache.Get(cacheKey); nil == err && len(data) > 0 {
+			return newEthProxyResult(req.Id, json.RawMessage(data))
+		}
+	}
+
+	result, err := p.forward(req.Method, req.Params)
+	if nil != err {
+		if rpcErr, ok := err.(rpc.Error); ok {
+			return newEthProxyError(req.Id, rpcErr.ErrorCode(), rpcErr.Error())
+		}
+		log.Errorf("eth proxy, failed to forward %s, err:%s", req.Method, err.Error())
+		return newEthProxyError(req.Id, ethProxyErrInternal, err.Error())
+	}
+
+	if cacheKey != "" && p.isConfirmed(result) {
+		if err := cache.Set(cacheKey, []byte(result), p.options.CacheTtl); nil != err {
+			log.Errorf("eth proxy, failed to cache %s, err:%s", req.Method, err.Error())
+		}
+	}
+
+	return newEthProxyResult(req.Id, result)
+}
+
+func (p *EthProxy) forward(method string, params []json.RawMessage) (json.RawMessage, error) {
+	// raw transactions are sent to all nodes by accessor
+	if method == "eth_sendRawTransaction" {
+		var (
+			tx     string
+			txHash string
+		)
+		if len(params) != 1 || nil != json.Unmarshal(params[0], &tx) {
+			return nil, errors.New("eth_sendRawTransaction needs the signed transaction data")
+		}
+		if err := accessor.SendRawTransaction(&txHash, tx); nil != err {
+			return nil, err
+		}
+		return json.Marshal(txHash)
+	}
+
+	args := make([]interface{}, 0)
+	for _, param := range params {
+		args = append(args, param)
+	}
+
+	err := errors.New("no eth node to forward " + method)
+	for _, node := range p.nodes {
+		var result json.RawMessage
+		if err = node.client.Call(&result, method, args...); nil != err {
+			log.Debugf("eth proxy, eth node:%s failed to call %s, err:%s", node.url, method, err.Error())
+			continue
+		}
+		if len(result) == 0 {
+			result = json.RawMessage("null")
+		}
+		return result, nil
+	}
+	return nil, err
+}
+
+// isConfirmed reports whether the transaction, receipt or block in result
+// is at least ConfirmBlocks deep, pending or missing results are never cached.
+func (p *EthProxy) isConfirmed(result json.RawMessage) bool {
+	var item struct {
+		BlockNumber *types.Big `json:"blockNumber"`
+		Number      *types.Big `json:"number"`
+	}
+	if err := json.Unmarshal(result, &item); nil != err {
+		return false
+	}
+	blockNumber := item.BlockNumber
+	if nil == blockNumber {
+		blockNumber = item.Number
+	}
+	if nil == blockNumber {
+		return false
+	}
+
+	latest, err := latestBlockNumber()
+	if nil != err {
+		return false
+	}
+	return new(big.Int).Sub(latest, blockNumber.BigInt()).Int64() >= p.options.ConfirmBlocks
+}
+
+func (p *EthProxy) checkLogsRange(params []json.RawMessage) error {
+	if len(params) != 1 {
+		return errors.New("eth_getLogs needs a filter object")
+	}
+	var filter struct {
+		FromBlock string `json:"fromBlock"`
+		ToBlock   string `json:"toBlock"`
+		BlockHash string `json:"blockHash"`
+	}
+	if err := json.Unmarshal(params[0], &filter); nil != err {
+		return err
+	}
+	if filter.BlockHash != "" {
+		return nil
+	}
+
+	latest, err := latestBlockNumber()
+	if nil != err {
+		return err
+	}
+	from, err := resolveBlockParameter(filter.FromBlock, latest)
+	if nil != err {
+		return err
+	}
+	to, err := resolveBlockParameter(filter.ToBlock, latest)
+	if nil != err {
+		return err
+	}
+	if new(big.Int).Sub(to, from).Int64() > p.options.MaxLogsBlockRange {
+		return fmt.Errorf("block range of eth_getLogs can't exceed %d", p.options.MaxLogsBlockRange)
+	}
+	return nil
+}
+
+func resolveBlockParameter(param string, latest *big.Int) (*big.Int, error) {
+	switch param {
+	case "", "latest", "pending":
+		return latest, nil
+	case "earliest":
+		return big.NewInt(0), nil
+	}
+	if number, ok := new(big.Int).SetString(param, 0); ok {
+		return number, nil
+	}
+	return nil, errors.New("invalid block parameter:" + param)
+}
+
+func latestBlockNumber() (*big.Int, error) {
+	var blockNumber types.Big
+	if err := accessor.BlockNumber(&blockNumber); nil != err {
+		return nil, err
+	}
+	return blockNumber.BigInt(), nil
+}
+
+func ethProxyCacheKey(method string, params []json.RawMessage) string {
+	data, _ := json.Marshal(params)
+	return EthProxyCachePrefix + method + "_" + common.ToHex(crypto.Keccak256(data))
+}
+
+func newEthProxyResult(id json.RawMessage, result json.RawMessage) JsonRpcRes {
+	res := NewJsonRpcRes()
+	res.Id = id
+	res.Result = result
+	return res
+}
+
+func newEthProxyError(id json.RawMessage, code int, message string) JsonRpcRes {
+	res := NewJsonRpcRes()
+	res.Id = id
+	res.Error = &JsonRpcError{Code: code, Message: message}
+	return res
+}
+
+func writeEthProxyResult(writer http.ResponseWriter, res interface{}) {
+	if data, err := json.Marshal(res); nil != err {
+		writer.Write([]byte("{\"error\":{\"message\":\"" + err.Error() + "\"}}"))
+	} else {
+		writer.Write(data)
+	}
+}
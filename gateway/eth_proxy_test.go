@@ -0,0 +1,81 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"github.com/Loopring/relay-lib/log"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// failures of nodes are logged
+func init() {
+	if !log.IsInit() {
+		cfg := zap.NewDevelopmentConfig()
+		cfg.Level = zap.NewAtomicLevelAt(zap.FatalLevel)
+		log.Initialize(cfg)
+	}
+}
+
+// newTestEthNode answers every jsonrpc request with result, or with rpcErr when it's not nil
+func newTestEthNode(result string, rpcErr *JsonRpcError) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		var r ethProxyRequest
+		if err := json.NewDecoder(req.Body).Decode(&r); nil != err {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": r.Id}
+		if nil != rpcErr {
+			res["error"] = rpcErr
+		} else {
+			res["result"] = result
+		}
+		json.NewEncoder(writer).Encode(res)
+	}))
+}
+
+func TestEthProxyFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		http.Error(writer, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	failed := newTestEthNode("", &JsonRpcError{Code: -32000, Message: "header not found"})
+	defer failed.Close()
+	healthy := newTestEthNode("0x10", nil)
+	defer healthy.Close()
+
+	p := NewEthProxy(EthProxyOptions{Nodes: []string{down.URL, failed.URL, healthy.URL}})
+	res := p.handle(ethProxyRequest{Id: json.RawMessage("1"), Method: "eth_blockNumber"}, "1.2.3.4")
+	if nil != res.Error {
+		t.Fatalf("expected result of the healthy node, got error:%s", res.Error.Message)
+	}
+	if result, _ := json.Marshal(res.Result); string(result) != `"0x10"` {
+		t.Fatalf("expected result 0x10, got %s", result)
+	}
+
+	p = NewEthProxy(EthProxyOptions{Nodes: []string{down.URL, failed.URL}})
+	res = p.handle(ethProxyRequest{Id: json.RawMessage("2"), Method: "eth_blockNumber"}, "1.2.3.4")
+	if nil == res.Error || res.Error.Code != -32000 {
+		t.Fatalf("expected error of the last node, got %+v", res)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"github.com/Loopring/relay-cluster/accountmanager"
 	"github.com/Loopring/relay-cluster/ordermanager/manager"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
 	"github.com/Loopring/relay-cluster/ordermanager/viewer"
 	"github.com/Loopring/relay-lib/broadcast"
 	"github.com/Loopring/relay-lib/broadcast/matrix"
@@ -46,6 +47,8 @@ type Gateway struct {
 	isBroadcast      bool
 	maxBroadcastTime int
 	marketCap        marketcap.MarketCapProvider
+
+	maxValidSinceInterval int64
 }
 
 var gateway Gateway
@@ -64,23 +67,30 @@ type GatewayFiltersOptions struct {
 		MinTokeSAmount        map[string]string
 		MinTokenSUsdAmount    float64
 		MaxValidSinceInterval int64
+		// orders valid since later than MaxValidSinceInterval are scheduled, 0 disables scheduling
+		MaxScheduledValidSinceInterval int64
 	}
 	PowFilter struct {
 		Difficulty string
 	}
 }
 
+// TrustedProxies are ips or cidrs of the proxies in front of the relay, client ips forwarded by them are used for
+// per ip limits, otherwise the address of the peer is used.
 type GateWayOptions struct {
 	IsBroadcast      bool
 	MaxBroadcastTime int
 	MatrixPubOptions []matrix.MatrixPublisherOption
 	MatrixSubOptions []matrix.MatrixSubscriberOption
+	TrustedProxies   []string
 }
 
 func Initialize(filterOptions *GatewayFiltersOptions, options *GateWayOptions, om viewer.OrderViewer, marketCap marketcap.MarketCapProvider, am accountmanager.AccountManager) {
 	gateway = Gateway{filters: make([]Filter, 0), om: om, isBroadcast: options.IsBroadcast, maxBroadcastTime: options.MaxBroadcastTime, am: am}
 
 	gateway.marketCap = marketCap
+	setTrustedProxies(options.TrustedProxies)
+	gateway.maxValidSinceInterval = filterOptions.BaseFilter.MaxValidSinceInterval
 
 	// new pow filter
 	powFilter := &PowFilter{Difficulty: types.HexToBigint(filterOptions.PowFilter.Difficulty)}
@@ -95,6 +105,8 @@ func Initialize(filterOptions *GatewayFiltersOptions, options *GateWayOptions, o
 		MinTokeSAmount:        make(map[string]*big.Int),
 		MinTokenSUsdAmount:    filterOptions.BaseFilter.MinTokenSUsdAmount,
 		MaxValidSinceInterval: filterOptions.BaseFilter.MaxValidSinceInterval,
+
+		MaxScheduledValidSinceInterval: filterOptions.BaseFilter.MaxScheduledValidSinceInterval,
 	}
 	for k, v := range filterOptions.BaseFilter.MinTokeSAmount {
 		minAmount := big.NewInt(0)
@@ -170,6 +182,12 @@ func HandleInputOrder(input eventemitter.EventData) (orderHash string, err error
 				return orderHash, err
 			}
 		}
+
+		// orders valid since far in future are kept by order manager until validSince arrives
+		if isScheduledOrder(order) {
+			return orderHash, manager.ScheduleOrder(order)
+		}
+
 		state = &types.OrderState{}
 		state.RawOrder = *order
 		eventemitter.Emit(eventemitter.NewOrder, state)
@@ -188,6 +206,43 @@ func HandleInputOrder(input eventemitter.EventData) (orderHash string, err error
 	return orderHash, err
 }
 
+// HandleInputStopOrder validates the order the same as HandleInputOrder, but the order is kept by order manager
+// until the last trade price of its market meets the condition.
+func HandleInputStopOrder(order *types.Order, condition omtyp.StopCondition, triggerPrice float64) (orderHash string, err error) {
+	order.Hash = order.GenerateHash()
+	orderHash = order.Hash.Hex()
+
+	if order.OrderType != types.ORDER_TYPE_MARKET {
+		return orderHash, fmt.Errorf("only market order can be stop order")
+	}
+	market, err := util.WrapMarketByAddress(order.TokenB.Hex(), order.TokenS.Hex())
+	if err != nil {
+		return orderHash, err
+	}
+	order.Market = market
+	order.Side = util.GetSide(order.TokenS.Hex(), order.TokenB.Hex())
+
+	if _, err = gateway.om.GetOrderByHash(order.Hash); err == nil {
+		return orderHash, errors.New("order existed, please not submit again")
+	}
+	if err = generatePrice(order); err != nil {
+		return orderHash, err
+	}
+	for _, v := range gateway.filters {
+		valid, err := v.filter(order)
+		if !valid {
+			log.Errorf(err.Error())
+			return orderHash, err
+		}
+	}
+
+	return orderHash, manager.AddStopOrder(order, condition, triggerPrice)
+}
+
+func isScheduledOrder(order *types.Order) bool {
+	return order.ValidSince.Int64()-gateway.maxValidSinceInterval > time.Now().Unix()
+}
+
 func generatePrice(order *types.Order) error {
 	tokenS, err := util.AddressToToken(order.TokenS)
 	if err != nil {
@@ -230,6 +285,8 @@ type BaseFilter struct {
 	MinTokeSAmount        map[string]*big.Int
 	MinTokenSUsdAmount    float64
 	MaxValidSinceInterval int64
+
+	MaxScheduledValidSinceInterval int64
 }
 
 func (f *BaseFilter) filter(o *types.Order) (bool, error) {
@@ -290,9 +347,17 @@ func (f *BaseFilter) filter(o *types.Order) (bool, error) {
 
 	now := time.Now().Unix()
 
-	// validSince check
+	// validSince check, orders valid since later than MaxValidSinceInterval are scheduled
 	if o.ValidSince.Int64()-f.MaxValidSinceInterval > now {
-		return false, fmt.Errorf("valid since is too small, order must be valid before %d second timestamp", now-f.MaxValidSinceInterval)
+		if f.MaxScheduledValidSinceInterval <= 0 {
+			return false, fmt.Errorf("valid since is too small, order must be valid before %d second timestamp", now-f.MaxValidSinceInterval)
+		}
+		if o.ValidSince.Int64()-f.MaxScheduledValidSinceInterval > now {
+			return false, fmt.Errorf("valid since is too small, order must be valid before %d second timestamp", now+f.MaxScheduledValidSinceInterval)
+		}
+		if o.ValidUntil.Cmp(o.ValidSince) <= 0 {
+			return false, fmt.Errorf("scheduled order's validUntil must be later than validSince")
+		}
 	}
 
 	// validUntil check
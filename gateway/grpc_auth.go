@@ -0,0 +1,93 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
+)
+
+// metadata keys authenticating an owner on a grpc call, either by a sign of an auth nonce
+// for AuthPurposeGrpc, which is used up by the call, or by a session token got from socketio auth.
+const (
+	grpcMetadataToken = "x-relay-token"
+	grpcMetadataOwner = "x-relay-owner"
+	grpcMetadataNonce = "x-relay-nonce"
+	grpcMetadataV     = "x-relay-v"
+	grpcMetadataR     = "x-relay-r"
+	grpcMetadataS     = "x-relay-s"
+)
+
+func grpcMetadataValue(md metadata.MD, key string) string {
+	if values := md[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcAuthOwner returns the owner authenticated by the metadata of ctx.
+func grpcAuthOwner(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("owner isn't authenticated, metadata not found")
+	}
+
+	if token := grpcMetadataValue(md, grpcMetadataToken); len(token) > 0 {
+		return getSocketIOSessionOwner(token)
+	}
+
+	v, err := strconv.ParseUint(grpcMetadataValue(md, grpcMetadataV), 10, 8)
+	if err != nil {
+		return "", errors.New("owner isn't authenticated, " + grpcMetadataToken + " or sign must be supplied")
+	}
+	sign := AuthSign{
+		Owner: grpcMetadataValue(md, grpcMetadataOwner),
+		Nonce: grpcMetadataValue(md, grpcMetadataNonce),
+		V:     uint8(v),
+		R:     grpcMetadataValue(md, grpcMetadataR),
+		S:     grpcMetadataValue(md, grpcMetadataS),
+	}
+	if err := verifyAuthSign(sign, AuthPurposeGrpc); err != nil {
+		return "", err
+	}
+	return strings.ToLower(sign.Owner), nil
+}
+
+// checkOwnerAuth returns nil when owner is authenticated by the metadata of ctx, or anonymous owner queries are allowed.
+func (g *GrpcServiceImpl) checkOwnerAuth(ctx context.Context, owner string) error {
+	if g.anonymousOwnerQueries {
+		return nil
+	}
+	if len(owner) == 0 {
+		return status.Error(codes.InvalidArgument, "owner can't be empty")
+	}
+	authOwner, err := grpcAuthOwner(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if authOwner != strings.ToLower(owner) {
+		return status.Error(codes.PermissionDenied, "owner "+owner+" isn't authenticated")
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"github.com/Loopring/relay-lib/crypto"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const grpcAuthTestPrivateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+type memAuthNonceStore struct {
+	mtx    sync.Mutex
+	nonces map[string]string
+}
+
+func (s *memAuthNonceStore) save(nonce, member string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.nonces[nonce] = member
+	return nil
+}
+
+func (s *memAuthNonceStore) use(nonce, member string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.nonces[nonce] != member {
+		return false
+	}
+	delete(s.nonces, nonce)
+	return true
+}
+
+func useMemAuthNonces(t *testing.T) {
+	saved := authNonces
+	authNonces = &memAuthNonceStore{nonces: make(map[string]string)}
+	t.Cleanup(func() { authNonces = saved })
+}
+
+func signedGrpcContext(t *testing.T, owner, nonce, purpose string) context.Context {
+	c, err := crypto.NewPrivateKeyCrypto(false, grpcAuthTestPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crypto.Initialize(c)
+
+	sig, err := c.Sign(crypto.GenerateHash([]byte(nonce+"|"+strings.ToLower(owner)+"|"+purpose)), c.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, r, s := crypto.SigToVRS(sig)
+	md := metadata.Pairs(
+		grpcMetadataOwner, owner,
+		grpcMetadataNonce, nonce,
+		grpcMetadataV, strconv.Itoa(int(v)),
+		grpcMetadataR, types.BytesToBytes32(r).Hex(),
+		grpcMetadataS, types.BytesToBytes32(s).Hex(),
+	)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestCheckOwnerAuth(t *testing.T) {
+	useMemAuthNonces(t)
+	c, _ := crypto.NewPrivateKeyCrypto(false, grpcAuthTestPrivateKey)
+	signer := c.Address().Hex()
+	other := common.HexToAddress("0x1").Hex()
+	nonce := func(owner, purpose string) string {
+		n, err := newAuthNonce(owner, purpose)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	signed := func(owner string) context.Context {
+		return signedGrpcContext(t, owner, nonce(owner, AuthPurposeGrpc), AuthPurposeGrpc)
+	}
+	replayed := signed(signer)
+
+	cases := []struct {
+		name      string
+		anonymous bool
+		ctx       context.Context
+		owner     string
+		code      codes.Code
+	}{
+		{"anonymous", true, context.Background(), "", codes.OK},
+		{"empty owner", false, signed(signer), "", codes.InvalidArgument},
+		{"no metadata", false, context.Background(), signer, codes.Unauthenticated},
+		{"signed by owner", false, replayed, signer, codes.OK},
+		{"replayed sign", false, replayed, signer, codes.Unauthenticated},
+		{"owner in other case", false, signed(strings.ToLower(signer)), common.HexToAddress(signer).Hex(), codes.OK},
+		{"signed for other owner", false, signed(signer), other, codes.PermissionDenied},
+		{"claimed other owner", false, signed(other), other, codes.Unauthenticated},
+		{"unknown nonce", false, signedGrpcContext(t, signer, "0123", AuthPurposeGrpc), signer, codes.Unauthenticated},
+		{"nonce of other purpose", false, signedGrpcContext(t, signer, nonce(signer, AuthPurposeSession), AuthPurposeGrpc), signer, codes.Unauthenticated},
+	}
+
+	for _, c := range cases {
+		g := &GrpcServiceImpl{anonymousOwnerQueries: c.anonymous}
+		err := g.checkOwnerAuth(c.ctx, c.owner)
+		st, ok := status.FromError(err)
+		if !ok || st.Code() != c.code {
+			t.Errorf("%s: expected code %s, got err:%v", c.name, c.code, err)
+		}
+	}
+}
+
+func TestNewAuthNonce(t *testing.T) {
+	useMemAuthNonces(t)
+	if _, err := newAuthNonce("0x123", AuthPurposeSession); err == nil {
+		t.Errorf("expected invalid owner rejected")
+	}
+	if _, err := newAuthNonce(common.HexToAddress("0x1").Hex(), "login"); err == nil {
+		t.Errorf("expected invalid purpose rejected")
+	}
+}
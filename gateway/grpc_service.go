@@ -0,0 +1,456 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"errors"
+	"github.com/Loopring/relay-cluster/dao"
+	relayv1 "github.com/Loopring/relay-cluster/grpcapi/v1"
+	txtyp "github.com/Loopring/relay-cluster/txmanager/types"
+	"github.com/Loopring/relay-lib/kafka"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultGrpcStreamBufferSize = 256
+	grpcConsumerGroupPrefix     = "GrpcService_"
+)
+
+// AnonymousOwnerQueries serves orders, balances, transactions and fills of owners without authentication,
+// it should only be enabled when the port is reachable by trusted backends only.
+type GrpcOptions struct {
+	Enable                bool
+	Port                  string
+	StreamBufferSize      int
+	AnonymousOwnerQueries bool
+}
+
+// GrpcServiceImpl serves the versioned gRPC api of grpcapi for backend services,
+// queries are answered by walletService and subscriptions are fed by the same kafka topics as socketio.
+// Data of an owner is only served to calls authenticated as the owner, see checkOwnerAuth.
+type GrpcServiceImpl struct {
+	port                  string
+	bufferSize            int
+	anonymousOwnerQueries bool
+	walletService         *WalletServiceImpl
+	consumer              *kafka.ConsumerRegister
+	server                *grpc.Server
+
+	mtx              sync.RWMutex
+	orderSubscribers map[*grpcOrderSubscriber]bool
+	fillSubscribers  map[*grpcFillSubscriber]bool
+}
+
+type grpcOrderSubscriber struct {
+	owner  string
+	market string
+	orders chan *relayv1.Order
+}
+
+type grpcFillSubscriber struct {
+	owner  string
+	market string
+	fills  chan *relayv1.Fill
+}
+
+// NewGrpcService consumes with a group of instanceId, the instance id of socketio, so that offsets survive restarts.
+func NewGrpcService(options *GrpcOptions, walletService *WalletServiceImpl, brokers []string, instanceId string) *GrpcServiceImpl {
+	g := &GrpcServiceImpl{}
+	g.port = options.Port
+	g.bufferSize = options.StreamBufferSize
+	if g.bufferSize <= 0 {
+		g.bufferSize = defaultGrpcStreamBufferSize
+	}
+	g.anonymousOwnerQueries = options.AnonymousOwnerQueries
+	g.walletService = walletService
+	g.orderSubscribers = make(map[*grpcOrderSubscriber]bool)
+	g.fillSubscribers = make(map[*grpcFillSubscriber]bool)
+
+	// every node pushes to its own streams, so every node needs its own consumer group
+	groupId := grpcConsumerGroupPrefix + instanceId
+	log.Infof("grpc service consumes with group %s", groupId)
+
+	g.consumer = &kafka.ConsumerRegister{}
+	g.consumer.Initialize(brokers)
+	if err := g.consumer.RegisterTopicAndHandler(kafka.Kafka_Topic_SocketIO_Order_Updated, groupId, types.OrderState{}, g.handleOrderUpdate); err != nil {
+		log.Fatalf("Failed init grpc consumer, %s", err.Error())
+	}
+	if err := g.consumer.RegisterTopicAndHandler(kafka.Kafka_Topic_SocketIO_Trades_Updated, groupId, dao.FillEvent{}, g.handleFill); err != nil {
+		log.Fatalf("Failed init grpc consumer, %s", err.Error())
+	}
+
+	return g
+}
+
+func (g *GrpcServiceImpl) Start() {
+	listener, err := net.Listen("tcp", ":"+g.port)
+	if err != nil {
+		log.Errorf("grpc service failed to listen on %s, %s", g.port, err.Error())
+		return
+	}
+
+	g.server = grpc.NewServer()
+	relayv1.RegisterRelayServiceServer(g.server, g)
+	log.Infof("grpc endpoint opened on %s", g.port)
+	if err := g.server.Serve(listener); err != nil {
+		log.Errorf("grpc service stopped, %s", err.Error())
+	}
+}
+
+func (g *GrpcServiceImpl) Stop() {
+	if g.server != nil {
+		g.server.Stop()
+	}
+	g.consumer.Close()
+}
+
+func (g *GrpcServiceImpl) GetOrders(ctx context.Context, req *relayv1.OrderQuery) (*relayv1.OrderPage, error) {
+	if err := g.checkOwnerAuth(ctx, req.Owner); err != nil {
+		return nil, err
+	}
+
+	query := &OrderQuery{
+		Owner:           req.Owner,
+		Market:          req.Market,
+		Status:          req.Status,
+		DelegateAddress: req.DelegateAddress,
+		OrderHash:       req.OrderHash,
+		Side:            req.Side,
+		OrderType:       req.OrderType,
+		PageIndex:       int(req.PageIndex),
+		PageSize:        int(req.PageSize),
+	}
+	if req.UseCursor {
+		cursor := req.Cursor
+		query.Cursor = &cursor
+	}
+
+	res, err := g.walletService.GetOrders(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &relayv1.OrderPage{PageIndex: int32(res.PageIndex), PageSize: int32(res.PageSize), Total: int32(res.Total), NextCursor: res.NextCursor}
+	for _, v := range res.Data {
+		page.Orders = append(page.Orders, orderJsonToProto(v.(OrderJsonResult)))
+	}
+	return page, nil
+}
+
+// GetFills serves fills of a market publicly as socketio trades, fills of an owner need authentication.
+func (g *GrpcServiceImpl) GetFills(ctx context.Context, req *relayv1.FillQuery) (*relayv1.FillPage, error) {
+	if req.Owner != "" || req.Market == "" {
+		if err := g.checkOwnerAuth(ctx, req.Owner); err != nil {
+			return nil, err
+		}
+	}
+
+	query := FillQuery{
+		DelegateAddress: req.DelegateAddress,
+		Market:          req.Market,
+		Owner:           req.Owner,
+		OrderHash:       req.OrderHash,
+		RingHash:        req.RingHash,
+		Side:            req.Side,
+		OrderType:       req.OrderType,
+		PageIndex:       int(req.PageIndex),
+		PageSize:        int(req.PageSize),
+	}
+	if req.UseCursor {
+		cursor := req.Cursor
+		query.Cursor = &cursor
+	}
+
+	res, err := g.walletService.GetFills(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &relayv1.FillPage{PageIndex: int32(res.PageIndex), PageSize: int32(res.PageSize), Total: int32(res.Total), NextCursor: res.NextCursor}
+	for _, v := range res.Data {
+		fill := v.(dao.FillEvent)
+		page.Fills = append(page.Fills, fillToProto(&fill))
+	}
+	return page, nil
+}
+
+func (g *GrpcServiceImpl) GetDepth(ctx context.Context, req *relayv1.DepthQuery) (*relayv1.Depth, error) {
+	res, err := g.walletService.GetDepth(DepthQuery{DelegateAddress: req.DelegateAddress, Market: req.Market})
+	if err != nil {
+		return nil, err
+	}
+
+	return &relayv1.Depth{
+		DelegateAddress: res.DelegateAddress,
+		Market:          res.Market,
+		Buy:             depthEntriesToProto(res.Depth.Buy),
+		Sell:            depthEntriesToProto(res.Depth.Sell),
+	}, nil
+}
+
+func (g *GrpcServiceImpl) GetBalance(ctx context.Context, req *relayv1.BalanceQuery) (*relayv1.Balance, error) {
+	if err := g.checkOwnerAuth(ctx, req.Owner); err != nil {
+		return nil, err
+	}
+
+	res, err := g.walletService.GetBalance(CommonTokenRequest{Owner: req.Owner, DelegateAddress: req.DelegateAddress})
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &relayv1.Balance{Owner: res.Address, DelegateAddress: res.DelegateAddress}
+	for _, token := range res.Tokens {
+		balance.Tokens = append(balance.Tokens, &relayv1.TokenBalance{Symbol: token.Token, Balance: token.Balance, Allowance: token.Allowance})
+	}
+	return balance, nil
+}
+
+func (g *GrpcServiceImpl) GetTransactions(ctx context.Context, req *relayv1.TransactionQuery) (*relayv1.TransactionPage, error) {
+	if err := g.checkOwnerAuth(ctx, req.Owner); err != nil {
+		return nil, err
+	}
+
+	query := TransactionQuery{
+		Owner:     req.Owner,
+		Symbol:    req.Symbol,
+		Status:    req.Status,
+		TxType:    req.TxType,
+		PageIndex: int(req.PageIndex),
+		PageSize:  int(req.PageSize),
+	}
+	if req.UseCursor {
+		cursor := req.Cursor
+		query.Cursor = &cursor
+	}
+
+	res, err := g.walletService.GetTransactions(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &relayv1.TransactionPage{PageIndex: int32(res.PageIndex), PageSize: int32(res.PageSize), Total: int32(res.Total), NextCursor: res.NextCursor}
+	for _, v := range res.Data {
+		page.Transactions = append(page.Transactions, transactionToProto(v.(txtyp.TransactionJsonResult)))
+	}
+	return page, nil
+}
+
+func (g *GrpcServiceImpl) SubscribeOrderUpdates(req *relayv1.OrderSubscription, stream relayv1.RelayService_SubscribeOrderUpdatesServer) error {
+	if req.Owner == "" && req.Market == "" {
+		return errors.New("owner or market must be supplied")
+	}
+	if err := g.checkOwnerAuth(stream.Context(), req.Owner); err != nil {
+		return err
+	}
+
+	sub := &grpcOrderSubscriber{owner: strings.ToLower(req.Owner), market: strings.ToUpper(req.Market), orders: make(chan *relayv1.Order, g.bufferSize)}
+	g.mtx.Lock()
+	g.orderSubscribers[sub] = true
+	g.mtx.Unlock()
+
+	defer func() {
+		g.mtx.Lock()
+		delete(g.orderSubscribers, sub)
+		g.mtx.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case order := <-sub.orders:
+			if err := stream.Send(order); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (g *GrpcServiceImpl) SubscribeFills(req *relayv1.FillSubscription, stream relayv1.RelayService_SubscribeFillsServer) error {
+	if req.Owner == "" && req.Market == "" {
+		return errors.New("owner or market must be supplied")
+	}
+	if req.Owner != "" {
+		if err := g.checkOwnerAuth(stream.Context(), req.Owner); err != nil {
+			return err
+		}
+	}
+
+	sub := &grpcFillSubscriber{owner: strings.ToLower(req.Owner), market: strings.ToUpper(req.Market), fills: make(chan *relayv1.Fill, g.bufferSize)}
+	g.mtx.Lock()
+	g.fillSubscribers[sub] = true
+	g.mtx.Unlock()
+
+	defer func() {
+		g.mtx.Lock()
+		delete(g.fillSubscribers, sub)
+		g.mtx.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case fill := <-sub.fills:
+			if err := stream.Send(fill); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleOrderUpdate never blocks the kafka consumer, updates are dropped for subscribers whose buffer is full
+func (g *GrpcServiceImpl) handleOrderUpdate(input interface{}) error {
+	state := input.(*types.OrderState)
+	order := orderJsonToProto(orderStateToJson(*state))
+	owner := strings.ToLower(state.RawOrder.Owner.Hex())
+	market := strings.ToUpper(state.RawOrder.Market)
+
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	for sub := range g.orderSubscribers {
+		if (sub.owner != "" && sub.owner != owner) || (sub.market != "" && sub.market != market) {
+			continue
+		}
+		select {
+		case sub.orders <- order:
+		default:
+			log.Errorf("grpc order subscriber of %s-%s is too slow, order %s dropped", sub.owner, sub.market, order.Hash)
+		}
+	}
+	return nil
+}
+
+func (g *GrpcServiceImpl) handleFill(input interface{}) error {
+	event := input.(*dao.FillEvent)
+	fill := fillToProto(event)
+	owner := strings.ToLower(event.Owner)
+	market := strings.ToUpper(event.Market)
+
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	for sub := range g.fillSubscribers {
+		if (sub.owner != "" && sub.owner != owner) || (sub.market != "" && sub.market != market) {
+			continue
+		}
+		select {
+		case sub.fills <- fill:
+		default:
+			log.Errorf("grpc fill subscriber of %s-%s is too slow, fill %s dropped", sub.owner, sub.market, fill.OrderHash)
+		}
+	}
+	return nil
+}
+
+func orderJsonToProto(src OrderJsonResult) *relayv1.Order {
+	return &relayv1.Order{
+		Hash:             src.RawOrder.Hash,
+		Protocol:         src.RawOrder.Protocol,
+		DelegateAddress:  src.RawOrder.DelegateAddress,
+		Owner:            src.RawOrder.Owner,
+		TokenS:           src.RawOrder.TokenS,
+		TokenB:           src.RawOrder.TokenB,
+		AmountS:          src.RawOrder.AmountS,
+		AmountB:          src.RawOrder.AmountB,
+		ValidSince:       src.RawOrder.ValidSince,
+		ValidUntil:       src.RawOrder.ValidUntil,
+		LrcFee:           src.RawOrder.LrcFee,
+		WalletAddress:    src.RawOrder.WalletAddress,
+		Market:           src.RawOrder.Market,
+		Side:             src.RawOrder.Side,
+		OrderType:        src.RawOrder.OrderType,
+		CreateTime:       src.RawOrder.CreateTime,
+		Status:           src.Status,
+		DealtAmountS:     src.DealtAmountS,
+		DealtAmountB:     src.DealtAmountB,
+		CancelledAmountS: src.CancelledAmountS,
+		CancelledAmountB: src.CancelledAmountB,
+	}
+}
+
+func fillToProto(src *dao.FillEvent) *relayv1.Fill {
+	return &relayv1.Fill{
+		Protocol:        src.Protocol,
+		DelegateAddress: src.DelegateAddress,
+		Owner:           src.Owner,
+		RingIndex:       src.RingIndex,
+		RingHash:        src.RingHash,
+		FillIndex:       src.FillIndex,
+		TxHash:          src.TxHash,
+		BlockNumber:     src.BlockNumber,
+		CreateTime:      src.CreateTime,
+		OrderHash:       src.OrderHash,
+		PreOrderHash:    src.PreOrderHash,
+		NextOrderHash:   src.NextOrderHash,
+		TokenS:          src.TokenS,
+		TokenB:          src.TokenB,
+		AmountS:         src.AmountS,
+		AmountB:         src.AmountB,
+		LrcReward:       src.LrcReward,
+		LrcFee:          src.LrcFee,
+		SplitS:          src.SplitS,
+		SplitB:          src.SplitB,
+		Market:          src.Market,
+		Side:            src.Side,
+		OrderType:       src.OrderType,
+	}
+}
+
+// depth entries of GetDepth are [price, amount, size]
+func depthEntriesToProto(src [][]string) []*relayv1.DepthEntry {
+	entries := make([]*relayv1.DepthEntry, 0)
+	for _, v := range src {
+		if len(v) < 3 {
+			continue
+		}
+		entries = append(entries, &relayv1.DepthEntry{Price: v[0], Amount: v[1], Size: v[2]})
+	}
+	return entries
+}
+
+func transactionToProto(src txtyp.TransactionJsonResult) *relayv1.Transaction {
+	return &relayv1.Transaction{
+		Protocol:    src.Protocol.Hex(),
+		Owner:       src.Owner.Hex(),
+		From:        src.From.Hex(),
+		To:          src.To.Hex(),
+		TxHash:      src.TxHash.Hex(),
+		Symbol:      src.Symbol,
+		Market:      src.Content.Market,
+		OrderHash:   src.Content.OrderHash,
+		Fill:        src.Content.Fill,
+		BlockNumber: src.BlockNumber,
+		Value:       src.Value,
+		LogIndex:    src.LogIndex,
+		Type:        src.Type,
+		Status:      src.Status,
+		CreateTime:  src.CreateTime,
+		UpdateTime:  src.UpdateTime,
+		GasPrice:    src.GasPrice,
+		GasLimit:    src.GasLimit,
+		GasUsed:     src.GasUsed,
+		Nonce:       src.Nonce,
+	}
+}
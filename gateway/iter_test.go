@@ -1,3 +1,6 @@
+//go:build integration
+// +build integration
+
 /*
 
   Copyright 2017 Loopring Project Ltd (Loopring Foundation).
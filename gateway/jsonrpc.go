@@ -28,7 +28,19 @@ import (
 )
 
 type JsonrpcOptions struct {
-	Port string
+	Port     string
+	Cors     CorsOptions
+	EthProxy EthProxyOptions
+}
+
+// CorsOptions left empty keep the former behaviour, AllowCredentials is true unless it's set false explicitly.
+type CorsOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	MaxAge           int
+	AllowCredentials *bool
+	ApiKeys          []ApiKeyOptions
 }
 
 func (*JsonrpcServiceImpl) Ping(val string, val2 int) (res string, err error) {
@@ -43,12 +55,16 @@ type JsonrpcService interface {
 
 type JsonrpcServiceImpl struct {
 	port          string
+	cors          CorsOptions
+	ethProxy      EthProxyOptions
 	walletService *WalletServiceImpl
 }
 
-func NewJsonrpcService(port string, walletService *WalletServiceImpl) *JsonrpcServiceImpl {
+func NewJsonrpcService(options *JsonrpcOptions, walletService *WalletServiceImpl) *JsonrpcServiceImpl {
 	l := &JsonrpcServiceImpl{}
-	l.port = port
+	l.port = options.Port
+	l.cors = options.Cors
+	l.ethProxy = options.EthProxy
 	l.walletService = walletService
 	return l
 }
@@ -74,27 +90,62 @@ func (j *JsonrpcServiceImpl) Start() {
 	lprServer.Handle("/", handler)
 	lprServer.HandleFunc("/city_partner/add_customer/", j.walletService.CreateCustomerInvitationInfo)
 	lprServer.HandleFunc("/city_partner/activate_customer", j.walletService.ActivateCustomerInvitation)
+	if j.ethProxy.Enable {
+		ethProxy := NewEthProxy(j.ethProxy)
+		lprServer.Handle(ethProxy.Path(), ethProxy)
+	}
 
-	httpServer := &http.Server{Handler: newCorsHandler(lprServer, []string{"*"})}
+	httpServer := &http.Server{Handler: newCorsHandler(newApiKeyHandler(lprServer, j.cors.ApiKeys, j.cors.AllowedOrigins), j.cors)}
 	//httpServer.Handler = newCorsHandler(handler, []string{"*"})
 	go httpServer.Serve(listener)
-	log.Info(fmt.Sprintf("HTTP endpoint opened on " + j.port))
+	log.Infof("HTTP endpoint opened on %s", j.port)
 
 	return
 }
 
-func newCorsHandler(srv *http.ServeMux, allowedOrigins []string) http.Handler {
-	// disable CORS support if user has not specified a custom CORS configuration
+// newCorsHandler keeps the former behaviour (any origin, POST/GET, 600s max-age, credentials allowed)
+// for every option that is left empty in config.
+func newCorsHandler(srv http.Handler, options CorsOptions) http.Handler {
+	allowedOrigins := options.AllowedOrigins
 	if len(allowedOrigins) == 0 {
-		return srv
+		allowedOrigins = []string{"*"}
+	} else {
+		// partners holding api keys must be able to call from their own domains
+		for _, apiKey := range options.ApiKeys {
+			if apiKey.Origin != "" {
+				allowedOrigins = append(allowedOrigins, apiKey.Origin)
+			}
+		}
+	}
+
+	allowedMethods := options.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{"POST", "GET"}
+	}
+
+	allowedHeaders := options.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = []string{"*"}
+	} else if len(options.ApiKeys) > 0 {
+		allowedHeaders = append(allowedHeaders, ApiKeyHeader)
+	}
+
+	maxAge := options.MaxAge
+	if maxAge <= 0 {
+		maxAge = 600
+	}
+
+	allowCredentials := true
+	if options.AllowCredentials != nil {
+		allowCredentials = *options.AllowCredentials
 	}
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   allowedOrigins,
-		AllowedMethods:   []string{"POST", "GET"},
-		MaxAge:           600,
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
+		AllowedMethods:   allowedMethods,
+		MaxAge:           maxAge,
+		AllowedHeaders:   allowedHeaders,
+		AllowCredentials: allowCredentials,
 	})
 
 	return c.Handler(srv)
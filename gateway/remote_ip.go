@@ -0,0 +1,101 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"github.com/Loopring/relay-lib/log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustedProxies are the proxies in front of the relay, X-Forwarded-For and X-Real-Ip are only believed from them.
+var trustedProxies []*net.IPNet
+
+// setTrustedProxies parses ips or cidrs of the trusted proxies, invalid ones are ignored.
+func setTrustedProxies(proxies []string) {
+	trustedProxies = make([]*net.IPNet, 0)
+	for _, v := range proxies {
+		if !strings.Contains(v, "/") {
+			if strings.Contains(v, ":") {
+				v += "/128"
+			} else {
+				v += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			log.Errorf("gateway, trusted proxy:%s invalid, ignored", v)
+			continue
+		}
+		trustedProxies = append(trustedProxies, ipNet)
+	}
+}
+
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, v := range trustedProxies {
+		if v.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIp returns the ip limits are applied to. It's the address of the peer unless the peer is a trusted proxy,
+// then it's the rightmost address of X-Forwarded-For which isn't a trusted proxy, or X-Real-Ip. Addresses on the
+// left of X-Forwarded-For are supplied by clients and never believed.
+func remoteIp(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	if !isTrustedProxy(ip) {
+		return normalizeIp(ip)
+	}
+
+	if forwarded := req.Header.Get(XForwardedFor); forwarded != "" {
+		addrs := strings.Split(forwarded, ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(addrs[i])
+			if addr == "" {
+				continue
+			}
+			if !isTrustedProxy(addr) {
+				return normalizeIp(addr)
+			}
+			ip = addr
+		}
+		return normalizeIp(ip)
+	}
+	if realIp := strings.TrimSpace(req.Header.Get(XRealIP)); realIp != "" {
+		return normalizeIp(realIp)
+	}
+	return normalizeIp(ip)
+}
+
+func normalizeIp(ip string) string {
+	if ip == "::1" {
+		return "127.0.0.1"
+	}
+	return ip
+}
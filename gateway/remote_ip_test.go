@@ -0,0 +1,59 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRemoteIp(t *testing.T) {
+	setTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	defer setTrustedProxies(nil)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIp     string
+		expected   string
+	}{
+		{"peer", "1.2.3.4:5678", "", "", "1.2.3.4"},
+		{"spoofed by untrusted peer", "1.2.3.4:5678", "5.6.7.8", "5.6.7.8", "1.2.3.4"},
+		{"forwarded by trusted proxy", "10.1.2.3:80", "5.6.7.8", "", "5.6.7.8"},
+		{"spoofed behind trusted proxy", "10.1.2.3:80", "9.9.9.9, 5.6.7.8", "", "5.6.7.8"},
+		{"trusted proxy chain", "192.168.1.1:80", "5.6.7.8, 10.0.0.2", "", "5.6.7.8"},
+		{"only trusted proxies", "10.1.2.3:80", "10.0.0.2", "", "10.0.0.2"},
+		{"real ip from trusted proxy", "10.1.2.3:80", "", "5.6.7.8", "5.6.7.8"},
+		{"loopback", "[::1]:80", "", "", "127.0.0.1"},
+	}
+
+	for _, c := range cases {
+		req := &http.Request{RemoteAddr: c.remoteAddr, Header: http.Header{}}
+		if c.forwarded != "" {
+			req.Header.Set(XForwardedFor, c.forwarded)
+		}
+		if c.realIp != "" {
+			req.Header.Set(XRealIP, c.realIp)
+		}
+		if ip := remoteIp(req); ip != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, ip)
+		}
+	}
+}
@@ -20,130 +20,240 @@ package gateway
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
+	"github.com/Loopring/relay-lib/log"
 	"github.com/gorilla/websocket"
-	"log"
+	"net"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	maxMessageSize = 4096
+
+	WebsocketOpSubscribe   = "subscribe"
+	WebsocketOpUnsubscribe = "unsubscribe"
+	WebsocketOpAuth        = "auth"
+	WebsocketOpUnauth      = "unauth"
+	WebsocketOpPing        = "ping"
+
+	websocketEventPong  = "pong"
+	websocketEventError = "error"
+	websocketConnPrefix = "ws_"
 )
 
+// WebsocketRequest is a message sent by a native websocket client,
+// Event is an event of socketio without postfix, Params is the json sent with `_req` or `_end` of the event.
+type WebsocketRequest struct {
+	Op     string          `json:"op"`
+	Event  string          `json:"event"`
+	Params json.RawMessage `json:"params"`
+}
+
+// WebsocketResponse is a message pushed to a native websocket client, Data is the same as the socketio response of Event.
+type WebsocketResponse struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+var websocketConnSeq int64
+
+// SocketClient is a native websocket connection, it implements socketio.Conn
+// so that subscriptions, auth and pushes are all handled by SocketIOServiceImpl.
 type SocketClient struct {
-	node *SocketNode
+	id      string
+	conn    *websocket.Conn
+	request *http.Request
+	so      *SocketIOServiceImpl
 
-	conn *websocket.Conn
+	ctxMtx sync.RWMutex
+	ctx    interface{}
+
+	writeMtx  sync.Mutex
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSocketClient(so *SocketIOServiceImpl, conn *websocket.Conn, r *http.Request) *SocketClient {
+	return &SocketClient{
+		id:      websocketConnPrefix + strconv.FormatInt(atomic.AddInt64(&websocketConnSeq, 1), 10),
+		conn:    conn,
+		request: r,
+		so:      so,
+		quit:    make(chan struct{}),
+	}
+}
+
+func (c *SocketClient) ID() string {
+	return c.id
+}
 
-	send chan interface{}
+func (c *SocketClient) Close() error {
+	err := error(nil)
+	c.closeOnce.Do(func() {
+		close(c.quit)
+		err = c.conn.Close()
+	})
+	return err
 }
 
+func (c *SocketClient) URL() url.URL {
+	return *c.request.URL
+}
+
+func (c *SocketClient) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *SocketClient) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *SocketClient) RemoteHeader() http.Header {
+	return c.request.Header
+}
+
+func (c *SocketClient) Context() interface{} {
+	c.ctxMtx.RLock()
+	defer c.ctxMtx.RUnlock()
+	return c.ctx
+}
+
+func (c *SocketClient) SetContext(v interface{}) {
+	c.ctxMtx.Lock()
+	defer c.ctxMtx.Unlock()
+	c.ctx = v
+}
+
+func (c *SocketClient) Namespace() string {
+	return "/"
+}
+
+// Emit writes event as a WebsocketResponse, the `_res` postfix of socketio events is trimmed.
+func (c *SocketClient) Emit(event string, v ...interface{}) {
+	resp := WebsocketResponse{Event: strings.TrimSuffix(event, EventPostfixRes)}
+	if len(v) > 0 {
+		if s, ok := v[0].(string); ok && json.Valid([]byte(s)) {
+			resp.Data = json.RawMessage(s)
+		} else if data, err := json.Marshal(v[0]); err == nil {
+			resp.Data = data
+		}
+	}
+	if err := c.writeJSON(resp); err != nil {
+		log.Debugf("[WEBSOCKET] write to connection %s failed, err:%s", c.id, err.Error())
+		c.Close()
+	}
+}
+
+func (c *SocketClient) writeJSON(v interface{}) error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(v)
+}
+
+func (c *SocketClient) writePing() error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+// read handles requests until the connection is closed, then the connection is removed from the service.
 func (c *SocketClient) read() {
 	defer func() {
-		c.node.unregister <- c
-		c.conn.Close()
+		c.so.removeConn(c.id)
+		c.Close()
 	}()
-	fmt.Print("read ------->1")
+
+	pongWait := time.Second * time.Duration(c.so.options.PingTimeout)
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
-	fmt.Print("read ------->2")
 	for {
-		fmt.Print("read ------->33")
-		req := &WebsocketRequest{}
-		err := c.conn.ReadJSON(&req)
-		fmt.Print(req)
-		fmt.Print(err)
-		fmt.Print("read ------->3333")
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("error: %v", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Debugf("[WEBSOCKET] read from connection %s failed, err:%s", c.id, err.Error())
 			}
-			break
+			return
 		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
 
-		fmt.Print("read ------->4")
-		resp, err := c.handler(*req)
-		if err == nil {
-			c.send <- resp
+		req := WebsocketRequest{}
+		if err := json.Unmarshal(message, &req); err != nil {
+			c.emitError("invalid request, " + err.Error())
+			continue
 		}
+		c.handle(req)
 	}
 }
 
-func (c *SocketClient) handler(req WebsocketRequest) (interface{}, error) {
-	walletService := WalletServiceImpl{}
-	fmt.Print("read ------->5")
-	fmt.Println(req)
-	fmt.Print("read ------->6")
+func (c *SocketClient) handle(req WebsocketRequest) {
+	s := c.so.socketIOConn(c)
+	params := strings.TrimSpace(string(req.Params))
+	if params == "null" {
+		params = ""
+	}
+	// params copied from socketio clients are json strings
+	var quoted string
+	if err := json.Unmarshal(req.Params, &quoted); err == nil {
+		params = quoted
+	}
 
-	if method, ok := req["method"]; ok {
-		switch method {
-		case "portfolio":
-			if v, ok := req["owner"]; ok {
-				return walletService.GetPortfolio(SingleOwner{v})
-			} else {
-				return nil, errors.New("owner must be applied")
-			}
-		case "balance":
-			//balanceQuery := CommonTokenRequest{ContractVersion: req["contractVersion"], Owner: req["owner"]}
-			//return walletService.GetBalance(balanceQuery)
-		case "tickers":
-			return walletService.GetTickers(SingleMarket{req["market"]})
-		case "transactions":
-			pageIndex, _ := strconv.Atoi(req["pageIndex"])
-			pageSize, _ := strconv.Atoi(req["pageSize"])
-			txnQuery := TransactionQuery{ThxHash: req["thxHash"], Owner: req["owner"], PageIndex: pageIndex, PageSize: pageSize}
-			return walletService.GetTransactions(txnQuery)
-		case "marketcap":
-			return walletService.GetPriceQuote(PriceQuoteQuery{req["currency"]})
-		case "depth":
-			//length, _ := strconv.Atoi(req["length"])
-			//depthQuery := DepthQuery{Length: length, Market: req["market"], ContractVersion: req["contractVersion"]}
-			//return walletService.GetDepth(depthQuery)
-		case "trends":
-			query := TrendQuery{Market: req["market"]}
-			return walletService.GetTrend(query)
+	switch req.Op {
+	case WebsocketOpSubscribe:
+		if !c.validEvent(req.Event) {
+			return
+		}
+		if len(params) == 0 {
+			params = "{}"
 		}
+		c.so.handleSubscribe(req.Event, s, params)
+	case WebsocketOpUnsubscribe:
+		if !c.validEvent(req.Event) {
+			return
+		}
+		c.so.handleUnsubscribe(req.Event, s, params)
+	case WebsocketOpAuth:
+		c.so.handleAuth(s, params)
+	case WebsocketOpUnauth:
+		c.so.handleAuthEnd(s, params)
+	case WebsocketOpPing:
+		s.Emit(websocketEventPong)
+	default:
+		c.emitError("unsupported op " + req.Op)
+	}
+}
 
+func (c *SocketClient) validEvent(event string) bool {
+	if _, ok := c.so.eventTypeRoute[event]; !ok {
+		c.emitError("unsupported event " + event)
+		return false
 	}
+	return true
+}
 
-	return nil, errors.New("method must be applied")
+func (c *SocketClient) emitError(err string) {
+	respJson, _ := json.Marshal(SocketIOJsonResp{Error: err})
+	c.so.socketIOConn(c).Emit(websocketEventError, string(respJson))
 }
 
-func (c *SocketClient) write() {
-	ticker := time.NewTicker(pingPeriod)
-	defer func() {
-		ticker.Stop()
-		c.conn.Close()
-	}()
+// ping keeps the connection alive, the client is closed by read when no pong is received in PingTimeout.
+func (c *SocketClient) ping() {
+	ticker := time.NewTicker(time.Second * time.Duration(c.so.options.PingInterval))
+	defer ticker.Stop()
 	for {
-		fmt.Print("read ------->7")
 		select {
-		case message, ok := <-c.send:
-			fmt.Println("read ------->8")
-			fmt.Println(message)
-			fmt.Println(ok)
-			fmt.Println(json.Marshal(message))
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-			err := c.conn.WriteJSON(&message)
-			if err != nil {
-				fmt.Println("write error occurs")
-				fmt.Println(err)
-				return
-			}
-
+		case <-c.quit:
+			return
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.writePing(); err != nil {
+				c.Close()
 				return
 			}
 		}
@@ -16,7 +16,6 @@ import (
 	"github.com/googollee/go-socket.io"
 	"github.com/robfig/cron"
 	"gopkg.in/googollee/go-engine.io.v1"
-	"net"
 	"net/http"
 	"reflect"
 	"strings"
@@ -94,6 +93,7 @@ const (
 	eventKeyLatestTransaction   = "latestTransaction"
 	eventKeyPendingTx           = "pendingTx"
 	eventKeyDepth               = "depth"
+	eventKeyDepthDiff           = "depthDiff"
 	eventKeyOrderBook           = "orderBook"
 	eventKeyTrades              = "trades"
 	eventKeyOrders              = "orders"
@@ -116,12 +116,17 @@ type SocketIOService interface {
 }
 
 type SocketIOServiceImpl struct {
-	port           string
+	options        WebsocketOptions
 	walletService  WalletServiceImpl
 	connIdMap      *sync.Map
 	cron           *cron.Cron
 	consumer       *kafka.ConsumerRegister
 	eventTypeRoute map[string]InvokeInfo
+	ipMtx          sync.Mutex
+	ipConnections  map[string]int
+	stats          socketIOStats
+	instanceId     string
+	registry       *socketIORegistry
 }
 
 type SocketMsgHandler struct {
@@ -129,9 +134,12 @@ type SocketMsgHandler struct {
 	Handler func(data interface{}) error
 }
 
-func NewSocketIOService(port string, walletService WalletServiceImpl, brokers []string) *SocketIOServiceImpl {
+func NewSocketIOService(options *WebsocketOptions, walletService WalletServiceImpl, brokers []string) *SocketIOServiceImpl {
 	so := &SocketIOServiceImpl{}
-	so.port = port
+	so.applyConnOptions(options)
+	so.instanceId = socketIOInstanceId(options)
+	so.registry = newSocketIORegistry(so.instanceId)
+	so.ipConnections = make(map[string]int)
 	so.walletService = walletService
 	so.connIdMap = &sync.Map{}
 	so.cron = cron.New()
@@ -144,15 +152,12 @@ func NewSocketIOService(port string, walletService WalletServiceImpl, brokers []
 		kafka.Kafka_Topic_SocketIO_Trades_Updated: {dao.FillEvent{}, so.broadcastTrades},
 		kafka.Kafka_Topic_SocketIO_Trends_Updated: {market.TrendUpdateMsg{}, so.broadcastTrends},
 
-		kafka.Kafka_Topic_SocketIO_Order_Updated: {types.OrderState{}, so.handleOrderUpdate},
-		kafka.Kafka_Topic_SocketIO_Cutoff:        {types.CutoffEvent{}, so.handleCutOff},
-		kafka.Kafka_Topic_SocketIO_Cutoff_Pair:   {types.CutoffPairEvent{}, so.handleCutOffPair},
+		kafka.Kafka_Topic_SocketIO_Cutoff:      {types.CutoffEvent{}, so.handleCutOff},
+		kafka.Kafka_Topic_SocketIO_Cutoff_Pair: {types.CutoffPairEvent{}, so.handleCutOffPair},
 
-		kafka.Kafka_Topic_SocketIO_BalanceUpdated:      {types.BalanceUpdateEvent{}, so.handleBalanceUpdate},
-		kafka.Kafka_Topic_SocketIO_Transaction_Updated: {txtyp.TransactionView{}, so.handleTransactionUpdate},
-		Kafka_Topic_SocketIO_Order_Transfer:            {OrderTransfer{}, so.handleOrderTransfer},
-		Kafka_Topic_SocketIO_Scan_Login:                {LoginInfo{}, so.handleScanLogin},
-		Kafka_Topic_SocketIO_Notify_Circulr:            {NotifyCirculrBody{}, so.handleCirculrNotify},
+		Kafka_Topic_SocketIO_Order_Transfer: {OrderTransfer{}, so.handleOrderTransfer},
+		Kafka_Topic_SocketIO_Scan_Login:     {LoginInfo{}, so.handleScanLogin},
+		Kafka_Topic_SocketIO_Notify_Circulr: {NotifyCirculrBody{}, so.handleCirculrNotify},
 	}
 
 	so.eventTypeRoute = map[string]InvokeInfo{
@@ -161,6 +166,7 @@ func NewSocketIOService(port string, walletService WalletServiceImpl, brokers []
 		eventKeyTrends:            {"GetTrend", TrendQuery{}, true, emitTypeByEvent, DefaultCronSpec5Minute},
 		eventKeyMarketCap:         {"GetPriceQuote", PriceQuoteQuery{}, true, emitTypeByCron, DefaultCronSpec5Minute},
 		eventKeyDepth:             {"GetDepth", DepthQuery{}, true, emitTypeByEvent, DefaultCronSpec5Minute},
+		eventKeyDepthDiff:         {"GetDepthSnapshot", DepthQuery{}, true, emitTypeByEvent, DefaultCronSpec1Minute},
 		eventKeyOrderBook:         {"GetUnmergedOrderBook", DepthQuery{}, true, emitTypeByEvent, DefaultCronSpec5Minute},
 		eventKeyTrades:            {"GetLatestFills", FillQuery{}, true, emitTypeByEvent, DefaultCronSpec5Minute},
 		eventKeyEstimatedGasPrice: {"GetEstimateGasPrice", nil, true, emitTypeByEvent, DefaultCronSpec5Minute},
@@ -181,33 +187,29 @@ func NewSocketIOService(port string, walletService WalletServiceImpl, brokers []
 		eventKeyCirculrNotify:      {"", nil, true, emitTypeByEvent, DefaultCronSpec30Day},
 	}
 
-	var groupId string
-	addrs, err := net.InterfaceAddrs()
-	if err != nil {
-		groupId = "DefaultSocketioTopic" + time.Now().String()
-	} else {
-		groupId = addrs[1].String()
-	}
-
-	for k, v := range topicList {
-		err = so.consumer.RegisterTopicAndHandler(k, groupId, v.Data, v.Handler)
-		if err != nil {
-			log.Fatalf("Failed init socketio consumer, %s", err.Error())
+	if so.options.TargetedPush {
+		if err := checkKafkaTopic(brokers, socketIONodeTopic(so.instanceId)); err != nil {
+			log.Fatalf("Failed init socketio targeted push, %s", err.Error())
 		}
 	}
+
+	// balance, transaction and order topics are added according to TargetedPush
+	so.registerConsumers(topicList)
 	return so
 }
 
 func (so *SocketIOServiceImpl) Start() {
 	server, err := socketio.NewServer(&engineio.Options{
-		PingInterval: time.Second * 60 * 60,
-		PingTimeout:  time.Second * 60 * 60,
+		PingInterval: time.Second * time.Duration(so.options.PingInterval),
+		PingTimeout:  time.Second * time.Duration(so.options.PingTimeout),
 	})
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
 	server.OnConnect("/", func(s socketio.Conn) error {
-		so.connIdMap.Store(s.ID(), s)
+		if so.addConn(s) == nil {
+			return s.Close()
+		}
 		return nil
 	})
 	server.OnEvent("/", "test", func(s socketio.Conn, msg string) {
@@ -217,30 +219,18 @@ func (so *SocketIOServiceImpl) Start() {
 		fmt.Println(s.RemoteAddr())
 	})
 
-	for v, event := range so.eventTypeRoute {
+	server.OnEvent("/", eventKeyAuth+EventPostfixReq, so.handleAuth)
+	server.OnEvent("/", eventKeyAuth+EventPostfixEnd, so.handleAuthEnd)
+
+	for v := range so.eventTypeRoute {
 		aliasOfV := v
-		aliasOfEvent := event
 
 		server.OnEvent("/", aliasOfV+EventPostfixReq, func(s socketio.Conn, msg string) {
-			context := make(map[string]string)
-			if s != nil && s.Context() != nil {
-				context = s.Context().(map[string]string)
-			}
-			context[aliasOfV] = msg
-			s.SetContext(context)
-			so.connIdMap.Store(s.ID(), s)
-
-			if len(aliasOfEvent.MethodName) != 0 {
-				so.EmitNowByEventType(aliasOfV, s, msg)
-			}
+			so.handleSubscribe(aliasOfV, s, msg)
 		})
 
 		server.OnEvent("/", aliasOfV+EventPostfixEnd, func(s socketio.Conn, msg string) {
-			if s != nil && s.Context() != nil {
-				businesses := s.Context().(map[string]string)
-				delete(businesses, aliasOfV)
-				s.SetContext(businesses)
-			}
+			so.handleUnsubscribe(aliasOfV, s, msg)
 		})
 	}
 
@@ -255,6 +245,8 @@ func (so *SocketIOServiceImpl) Start() {
 			so.cron.AddFunc(spec, func() { so.broadcastLoopringTicker(nil) })
 		case eventKeyDepth:
 			so.cron.AddFunc(spec, func() { so.broadcastDepth(nil) })
+		case eventKeyDepthDiff:
+			so.cron.AddFunc(spec, func() { so.broadcastDepthDiff(nil) })
 		case eventKeyOrderBook:
 			so.cron.AddFunc(spec, func() { so.broadcastOrderBook(nil) })
 		case eventKeyTrades:
@@ -277,51 +269,93 @@ func (so *SocketIOServiceImpl) Start() {
 			}
 
 			so.cron.AddFunc(spec, func() {
-				so.connIdMap.Range(func(key, value interface{}) bool {
-					v := value.(socketio.Conn)
-					if v.Context() != nil {
-						businesses := v.Context().(map[string]string)
-						eventContext, ok := businesses[copyOfK]
-						if ok {
-							//log.Infof("[SOCKETIO-EMIT]cron emit by key : %s, connId : %s", copyOfK, v.ID())
-							so.EmitNowByEventType(copyOfK, v, eventContext)
-						}
-					}
-					return true
+				results := newSharedResults()
+				so.rangeSubscriptions(copyOfK, func(v socketio.Conn, sub SocketIOSubscription) {
+					//log.Infof("[SOCKETIO-EMIT]cron emit by key : %s, connId : %s", copyOfK, v.ID())
+					so.emitSharedResult(copyOfK, v, sub, results)
 				})
 			})
 
 		}
 	}
 
+	so.cron.AddFunc(DefaultCronSpec1Minute, so.reportStats)
+	so.cron.AddFunc(DefaultCronSpec1Minute, so.refreshRegistry)
 	so.cron.Start()
 
 	server.OnError("/", func(e error) {
 		fmt.Println("meet error:", e)
 		infos := strings.Split(e.Error(), "SOCKETFORLOOPRING")
 		if len(infos) == 2 {
-			so.connIdMap.Delete(infos[0])
+			so.removeConn(infos[0])
 		}
 
 	})
 
 	server.OnDisconnect("/", func(s socketio.Conn, msg string) {
 		s.Close()
-		so.connIdMap.Delete(s.ID())
+		so.removeConn(s.ID())
 		fmt.Println("closed", msg)
 	})
 	go server.Serve()
 	defer server.Close()
 
 	http.Handle("/socket.io/", NewServer(*server))
-	log.Info("Serving at localhost: " + so.port)
-	log.Fatal(http.ListenAndServe(":"+so.port, nil).Error())
+	log.Info("Serving at localhost: " + so.options.Port)
+	log.Fatal(http.ListenAndServe(":"+so.options.Port, nil).Error())
+
+}
 
+// handleSubscribe adds the subscription in msg to the connection and pushes the current data at once.
+func (so *SocketIOServiceImpl) handleSubscribe(eventKey string, s socketio.Conn, msg string) {
+	c := so.socketIOConn(s)
+	subs := ensureSubscriptions(c)
+	sub := SocketIOSubscription{Id: parseSubscriptionId(eventKey, msg), Query: msg}
+	if err := so.checkSubscriptionAuth(subs, eventKey, msg); err != nil {
+		errJson, _ := json.Marshal(SocketIOJsonResp{Error: err.Error(), Code: socketIOCodeUnauthorized})
+		emitSubscription(c, eventKey, sub, string(errJson))
+		return
+	}
+	if len(subs.list(eventKey)) >= MaxSubscriptionsPerEvent && !subs.has(eventKey, sub.Id) {
+		errJson, _ := json.Marshal(SocketIOJsonResp{Error: fmt.Sprintf("too many subscriptions of %s, max is %d", eventKey, MaxSubscriptionsPerEvent)})
+		emitSubscription(c, eventKey, sub, string(errJson))
+		return
+	}
+	subs.add(eventKey, sub.Id, sub.Query)
+	so.registerSubscriptionOwner(eventKey, sub.Query)
+
+	if len(so.eventTypeRoute[eventKey].MethodName) != 0 {
+		so.EmitNowByEventType(eventKey, c, sub)
+	}
 }
 
-func (so *SocketIOServiceImpl) EmitNowByEventType(bk string, v socketio.Conn, bv string) {
+// handleUnsubscribe ends the subscription of the id in msg, or all subscriptions of eventKey when there is no id.
+func (so *SocketIOServiceImpl) handleUnsubscribe(eventKey string, s socketio.Conn, msg string) {
+	if subs := connSubscriptions(s); subs != nil {
+		id := ""
+		if len(strings.TrimSpace(msg)) > 0 {
+			if id = parseSubscriptionId(eventKey, msg); id == eventKey {
+				// no id in msg, end all subscriptions of the event
+				id = ""
+			}
+		}
+		subs.remove(eventKey, id)
+	}
+}
+
+func (so *SocketIOServiceImpl) EmitNowByEventType(bk string, v socketio.Conn, sub SocketIOSubscription) {
 	if invokeInfo, ok := so.eventTypeRoute[bk]; ok {
-		so.handleAfterEmit(bk, invokeInfo.Query, invokeInfo.MethodName, v, bv)
+		so.handleAfterEmit(bk, invokeInfo.Query, invokeInfo.MethodName, v, sub)
+	}
+}
+
+// emitSharedResult is EmitNowByEventType with the result shared by subscriptions of the same query.
+func (so *SocketIOServiceImpl) emitSharedResult(bk string, v socketio.Conn, sub SocketIOSubscription, results *sharedResults) {
+	if invokeInfo, ok := so.eventTypeRoute[bk]; ok {
+		result := results.get(so.queryKey(bk, sub.Query), func() string {
+			return so.handleWith(bk, invokeInfo.Query, invokeInfo.MethodName, sub.Query)
+		})
+		emitSubscription(v, bk, sub, result)
 	}
 }
 
@@ -363,9 +397,9 @@ func (so *SocketIOServiceImpl) handleWith(eventType string, query interface{}, m
 	}
 }
 
-func (so *SocketIOServiceImpl) handleAfterEmit(eventType string, query interface{}, methodName string, conn socketio.Conn, ctx string) {
-	result := so.handleWith(eventType, query, methodName, ctx)
-	conn.Emit(eventType+EventPostfixRes, result)
+func (so *SocketIOServiceImpl) handleAfterEmit(eventType string, query interface{}, methodName string, conn socketio.Conn, sub SocketIOSubscription) {
+	result := so.handleWith(eventType, query, methodName, sub.Query)
+	emitSubscription(conn, eventType, sub, result)
 }
 
 func (so *SocketIOServiceImpl) broadcastTpTickers(input interface{}) (err error) {
@@ -387,24 +421,16 @@ func (so *SocketIOServiceImpl) broadcastTpTickers(input interface{}) (err error)
 		tickerMap[mkt] = string(respJson[:])
 	}
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyTickers]
-			if ok {
-				var singleMarket SingleMarket
-				err = json.Unmarshal([]byte(ctx), &singleMarket)
-				if err != nil {
-					return true
-				}
-				tks, ok := tickerMap[strings.ToUpper(singleMarket.Market)]
-				if ok {
-					v.Emit(eventKeyTickers+EventPostfixRes, tks)
-				}
-			}
+	so.rangeSubscriptions(eventKeyTickers, func(v socketio.Conn, sub SocketIOSubscription) {
+		var singleMarket SingleMarket
+		err = json.Unmarshal([]byte(sub.Query), &singleMarket)
+		if err != nil {
+			return
+		}
+		tks, ok := tickerMap[strings.ToUpper(singleMarket.Market)]
+		if ok {
+			emitSubscription(v, eventKeyTickers, sub, tks)
 		}
-		return true
 	})
 	return nil
 }
@@ -422,17 +448,9 @@ func (so *SocketIOServiceImpl) broadcastLoopringTicker(input interface{}) (err e
 
 	respJson, _ := json.Marshal(resp)
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			_, ok := businesses[eventKeyLoopringTickers]
-			if ok {
-				//log.Info("emit loopring ticker info")
-				v.Emit(eventKeyLoopringTickers+EventPostfixRes, string(respJson[:]))
-			}
-		}
-		return true
+	so.rangeSubscriptions(eventKeyLoopringTickers, func(v socketio.Conn, sub SocketIOSubscription) {
+		//log.Info("emit loopring ticker info")
+		emitSubscription(v, eventKeyLoopringTickers, sub, string(respJson[:]))
 	})
 	return nil
 }
@@ -444,6 +462,37 @@ func (so *SocketIOServiceImpl) broadcastDepth(input interface{}) (err error) {
 	return nil
 }
 
+// broadcastDepthDiff pushes only the changed levels of depth to subscribers of depthDiff,
+// nothing is pushed when the depth of a market doesn't change.
+func (so *SocketIOServiceImpl) broadcastDepthDiff(input interface{}) (err error) {
+	markets := so.getConnectedMarketForDepth(eventKeyDepthDiff, nil)
+	if input != nil {
+		depthQuery := input.(DepthQuery)
+		mk := depthMarketKey(depthQuery.DelegateAddress, depthQuery.Market)
+		if !markets[mk] {
+			return nil
+		}
+		markets = map[string]bool{mk: true}
+	}
+
+	respMap := make(map[string]string, 0)
+	for mk := range markets {
+		mktAndDelegate := strings.Split(mk, "_")
+		diff, err := so.walletService.updateDepthDiff(DepthQuery{mktAndDelegate[0], mktAndDelegate[1]})
+		if err != nil {
+			log.Errorf("failed to get depth diff of %s, err:%s", mk, err.Error())
+			continue
+		}
+		if diff == nil {
+			continue
+		}
+		respJson, _ := json.Marshal(SocketIOJsonResp{Data: diff})
+		respMap[mk] = string(respJson[:])
+	}
+	so.pushDepthData(eventKeyDepthDiff, respMap)
+	return nil
+}
+
 func (so *SocketIOServiceImpl) broadcastOrderBook(input interface{}) (err error) {
 	markets := so.getConnectedMarketForDepth(eventKeyOrderBook, input)
 	respMap := so.getDepthPushData(eventKeyOrderBook, markets)
@@ -479,23 +528,15 @@ func (so *SocketIOServiceImpl) getDepthPushData(eventKey string, markets map[str
 }
 
 func (so *SocketIOServiceImpl) pushDepthData(eventKey string, respMap map[string]string) {
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKey]
-			if ok {
-				dQuery := &DepthQuery{}
-				err := json.Unmarshal([]byte(ctx), dQuery)
-				if err == nil && len(dQuery.DelegateAddress) > 0 && len(dQuery.Market) > 0 {
-					depthKey := strings.ToLower(dQuery.DelegateAddress) + "_" + strings.ToLower(dQuery.Market)
-					if len(respMap[depthKey]) > 0 {
-						v.Emit(eventKey+EventPostfixRes, respMap[depthKey])
-					}
-				}
+	so.rangeSubscriptions(eventKey, func(v socketio.Conn, sub SocketIOSubscription) {
+		dQuery := &DepthQuery{}
+		err := json.Unmarshal([]byte(sub.Query), dQuery)
+		if err == nil && len(dQuery.DelegateAddress) > 0 && len(dQuery.Market) > 0 {
+			depthKey := strings.ToLower(dQuery.DelegateAddress) + "_" + strings.ToLower(dQuery.Market)
+			if len(respMap[depthKey]) > 0 {
+				emitSubscription(v, eventKey, sub, respMap[depthKey])
 			}
 		}
-		return true
 	})
 }
 
@@ -530,21 +571,13 @@ func (so *SocketIOServiceImpl) broadcastTrades(input interface{}) (err error) {
 		respMap[mk] = string(respJson[:])
 	}
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyTrades]
-			if ok {
-				fQuery := &FillQuery{}
-				err := json.Unmarshal([]byte(ctx), fQuery)
-				if err == nil && len(fQuery.DelegateAddress) > 0 && len(fQuery.Market) > 0 {
-					fillKey := strings.ToLower(fQuery.DelegateAddress) + "_" + strings.ToLower(fQuery.Market)
-					v.Emit(eventKeyTrades+EventPostfixRes, respMap[fillKey])
-				}
-			}
+	so.rangeSubscriptions(eventKeyTrades, func(v socketio.Conn, sub SocketIOSubscription) {
+		fQuery := &FillQuery{}
+		err := json.Unmarshal([]byte(sub.Query), fQuery)
+		if err == nil && len(fQuery.DelegateAddress) > 0 && len(fQuery.Market) > 0 {
+			fillKey := strings.ToLower(fQuery.DelegateAddress) + "_" + strings.ToLower(fQuery.Market)
+			emitSubscription(v, eventKeyTrades, sub, respMap[fillKey])
 		}
-		return true
 	})
 	return nil
 }
@@ -554,22 +587,14 @@ func (so *SocketIOServiceImpl) broadcastMarketCap(input interface{}) (err error)
 	cnyResp := so.getPriceQuoteResp(priceQuoteCNY)
 	usdResp := so.getPriceQuoteResp(priceQuoteUSD)
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyMarketCap]
-			if ok {
-				query := &PriceQuoteQuery{}
-				err := json.Unmarshal([]byte(ctx), query)
-				if err == nil && strings.ToLower(priceQuoteCNY) == strings.ToLower(query.Currency) {
-					v.Emit(eventKeyMarketCap+EventPostfixRes, cnyResp)
-				} else if err == nil && strings.ToLower(priceQuoteUSD) == strings.ToLower(query.Currency) {
-					v.Emit(eventKeyMarketCap+EventPostfixRes, usdResp)
-				}
-			}
+	so.rangeSubscriptions(eventKeyMarketCap, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &PriceQuoteQuery{}
+		err := json.Unmarshal([]byte(sub.Query), query)
+		if err == nil && strings.ToLower(priceQuoteCNY) == strings.ToLower(query.Currency) {
+			emitSubscription(v, eventKeyMarketCap, sub, cnyResp)
+		} else if err == nil && strings.ToLower(priceQuoteUSD) == strings.ToLower(query.Currency) {
+			emitSubscription(v, eventKeyMarketCap, sub, usdResp)
 		}
-		return true
 	})
 	return nil
 }
@@ -587,17 +612,9 @@ func (so *SocketIOServiceImpl) broadcastGasPrice(input interface{}) (err error)
 
 	respJson, _ := json.Marshal(resp)
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			_, ok := businesses[eventKeyEstimatedGasPrice]
-			if ok {
-				//log.Info("emit loopring gas price info")
-				v.Emit(eventKeyEstimatedGasPrice+EventPostfixRes, string(respJson[:]))
-			}
-		}
-		return true
+	so.rangeSubscriptions(eventKeyEstimatedGasPrice, func(v socketio.Conn, sub SocketIOSubscription) {
+		//log.Info("emit loopring gas price info")
+		emitSubscription(v, eventKeyEstimatedGasPrice, sub, string(respJson[:]))
 	})
 	return nil
 }
@@ -615,17 +632,11 @@ func (so *SocketIOServiceImpl) broadcastGlobalTicker(input interface{}) (err err
 
 	respJson, _ := json.Marshal(resp)
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			_, ok := businesses[eventKeyGlobalTicker]
-			if ok && len(string(respJson[:])) > 0 {
-				//log.Info("emit loopring gas price info")
-				v.Emit(eventKeyGlobalTicker+EventPostfixRes, string(respJson[:]))
-			}
+	so.rangeSubscriptions(eventKeyGlobalTicker, func(v socketio.Conn, sub SocketIOSubscription) {
+		if len(string(respJson[:])) > 0 {
+			//log.Info("emit loopring gas price info")
+			emitSubscription(v, eventKeyGlobalTicker, sub, string(respJson[:]))
 		}
-		return true
 	})
 	return nil
 }
@@ -647,20 +658,12 @@ func (so *SocketIOServiceImpl) broadcastGlobalTrend(input interface{}) (err erro
 		respMap[k] = string(respJson[:])
 	}
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyGlobalTrend]
-			if ok {
-				query := &SingleToken{}
-				err := json.Unmarshal([]byte(ctx), query)
-				if err == nil && len(query.Token) > 0 && len(respMap[strings.ToUpper(query.Token)]) > 0 {
-					v.Emit(eventKeyGlobalTrend+EventPostfixRes, respMap[strings.ToUpper(query.Token)])
-				}
-			}
+	so.rangeSubscriptions(eventKeyGlobalTrend, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &SingleToken{}
+		err := json.Unmarshal([]byte(sub.Query), query)
+		if err == nil && len(query.Token) > 0 && len(respMap[strings.ToUpper(query.Token)]) > 0 {
+			emitSubscription(v, eventKeyGlobalTrend, sub, respMap[strings.ToUpper(query.Token)])
 		}
-		return true
 	})
 	return nil
 }
@@ -684,20 +687,12 @@ func (so *SocketIOServiceImpl) broadcastGlobalMarketTicker(input interface{}) (e
 		respMap[k] = string(respJson[:])
 	}
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyGlobalMarketTicker]
-			if ok {
-				query := &SingleToken{}
-				err := json.Unmarshal([]byte(ctx), query)
-				if err == nil && len(query.Token) > 0 && len(respMap[strings.ToUpper(query.Token)]) > 0 {
-					v.Emit(eventKeyGlobalMarketTicker+EventPostfixRes, respMap[strings.ToUpper(query.Token)])
-				}
-			}
+	so.rangeSubscriptions(eventKeyGlobalMarketTicker, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &SingleToken{}
+		err := json.Unmarshal([]byte(sub.Query), query)
+		if err == nil && len(query.Token) > 0 && len(respMap[strings.ToUpper(query.Token)]) > 0 {
+			emitSubscription(v, eventKeyGlobalMarketTicker, sub, respMap[strings.ToUpper(query.Token)])
 		}
-		return true
 	})
 	return nil
 }
@@ -727,41 +722,25 @@ func (so *SocketIOServiceImpl) getConnectedMarketForDepth(eventKey string, input
 	}
 
 	count := 0
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
+	so.rangeSubscriptions(eventKey, func(v socketio.Conn, sub SocketIOSubscription) {
 		count++
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			DCtx, ok := businesses[eventKey]
-			if ok {
-				dQuery := &DepthQuery{}
-				err := json.Unmarshal([]byte(DCtx), dQuery)
-				if err == nil && len(dQuery.DelegateAddress) > 0 && len(dQuery.Market) > 0 {
-					markets[strings.ToLower(dQuery.DelegateAddress)+"_"+strings.ToLower(dQuery.Market)] = true
-				}
-			}
+		dQuery := &DepthQuery{}
+		err := json.Unmarshal([]byte(sub.Query), dQuery)
+		if err == nil && len(dQuery.DelegateAddress) > 0 && len(dQuery.Market) > 0 {
+			markets[strings.ToLower(dQuery.DelegateAddress)+"_"+strings.ToLower(dQuery.Market)] = true
 		}
-		return true
 	})
 	return markets
 }
 
 func (so *SocketIOServiceImpl) getConnectedMarketForFill() map[string]bool {
 	markets := make(map[string]bool, 0)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			fCtx, ok := businesses[eventKeyTrades]
-			if ok {
-				fQuery := &FillQuery{}
-				err := json.Unmarshal([]byte(fCtx), fQuery)
-				if err == nil && len(fQuery.DelegateAddress) > 0 && len(fQuery.Market) > 0 {
-					markets[strings.ToLower(fQuery.DelegateAddress)+"_"+strings.ToLower(fQuery.Market)] = true
-				}
-			}
+	so.rangeSubscriptions(eventKeyTrades, func(v socketio.Conn, sub SocketIOSubscription) {
+		fQuery := &FillQuery{}
+		err := json.Unmarshal([]byte(sub.Query), fQuery)
+		if err == nil && len(fQuery.DelegateAddress) > 0 && len(fQuery.Market) > 0 {
+			markets[strings.ToLower(fQuery.DelegateAddress)+"_"+strings.ToLower(fQuery.Market)] = true
 		}
-		return true
 	})
 	return markets
 }
@@ -783,25 +762,16 @@ func (so *SocketIOServiceImpl) broadcastTrends(input interface{}) (err error) {
 
 	respJson, _ := json.Marshal(resp)
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyTrends]
-
-			if ok {
-				trendQuery := &TrendQuery{}
-				err = json.Unmarshal([]byte(ctx), trendQuery)
-				if err != nil {
-					log.Error("trend query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(trendQuery.Market) == strings.ToUpper(trendQuery.Market) &&
-					strings.ToUpper(trendQuery.Interval) == strings.ToUpper(trendQuery.Interval) {
-					log.Info("emit trend " + ctx)
-					v.Emit(eventKeyTrends+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	so.rangeSubscriptions(eventKeyTrends, func(v socketio.Conn, sub SocketIOSubscription) {
+		trendQuery := &TrendQuery{}
+		err = json.Unmarshal([]byte(sub.Query), trendQuery)
+		if err != nil {
+			log.Error("trend query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(trendQuery.Market) == strings.ToUpper(trendQuery.Market) &&
+			strings.ToUpper(trendQuery.Interval) == strings.ToUpper(trendQuery.Interval) {
+			log.Info("emit trend " + sub.Query)
+			emitSubscription(v, eventKeyTrends, sub, string(respJson[:]))
 		}
-		return true
 	})
 	return nil
 }
@@ -832,36 +802,33 @@ func (so *SocketIOServiceImpl) notifyBalanceUpdateByDelegateAddress(owner, deleg
 	}
 
 	req := CommonTokenRequest{delegateAddress, owner}
-	resp := SocketIOJsonResp{}
-	balance, err := so.walletService.GetBalance(req)
+	// balance is only queried when someone subscribes it
+	results := newSharedResults()
+	getBalanceResp := func() string {
+		resp := SocketIOJsonResp{}
+		balance, err := so.walletService.GetBalance(req)
 
-	if err != nil {
-		resp = SocketIOJsonResp{Error: err.Error()}
-	} else {
-		resp.Data = balance
-	}
+		if err != nil {
+			resp = SocketIOJsonResp{Error: err.Error()}
+		} else {
+			resp.Data = balance
+		}
 
-	respJson, _ := json.Marshal(resp)
+		respJson, _ := json.Marshal(resp)
+		return string(respJson[:])
+	}
 
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyBalance]
-			if ok {
-				query := &CommonTokenRequest{}
-				err = json.Unmarshal([]byte(ctx), query)
-				if err != nil {
-					return true
-				}
+	so.rangeSubscriptions(eventKeyBalance, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &CommonTokenRequest{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		if err != nil {
+			return
+		}
 
-				if strings.ToLower(query.Owner) == strings.ToLower(req.Owner) && strings.ToLower(query.DelegateAddress) == strings.ToLower(req.DelegateAddress) {
-					//log.Info("emit balance info")
-					v.Emit(eventKeyBalance+EventPostfixRes, string(respJson[:]))
-				}
-			}
+		if strings.ToLower(query.Owner) == strings.ToLower(req.Owner) && strings.ToLower(query.DelegateAddress) == strings.ToLower(req.DelegateAddress) {
+			//log.Info("emit balance info")
+			emitSubscription(v, eventKeyBalance, sub, results.get("", getBalanceResp))
 		}
-		return true
 	})
 	return nil
 }
@@ -873,36 +840,30 @@ func (so *SocketIOServiceImpl) handleTransactions(input interface{}) (err error)
 	req := input.(*txtyp.TransactionView)
 	owner := req.Owner.Hex()
 	//log.Infof("received owner is %s ", owner)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyTransaction]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				txQuery := &TransactionQuery{}
-				//log.Info("txQuery owner is " + txQuery.Owner)
-				err = json.Unmarshal([]byte(ctx), txQuery)
+	results := newSharedResults()
+	so.rangeSubscriptions(eventKeyTransaction, func(v socketio.Conn, sub SocketIOSubscription) {
+		txQuery := &TransactionQuery{}
+		//log.Info("txQuery owner is " + txQuery.Owner)
+		err = json.Unmarshal([]byte(sub.Query), txQuery)
+		if err != nil {
+			log.Error("tx query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
+			log.Info("emit trend " + sub.Query)
+
+			respJson := results.get(so.queryKey(eventKeyTransaction, sub.Query), func() string {
+				txs, err := so.walletService.GetTransactions(*txQuery)
+				resp := SocketIOJsonResp{}
+
 				if err != nil {
-					log.Error("tx query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
-					log.Info("emit trend " + ctx)
-
-					txs, err := so.walletService.GetTransactions(*txQuery)
-					resp := SocketIOJsonResp{}
-
-					if err != nil {
-						resp = SocketIOJsonResp{Error: err.Error()}
-					} else {
-						resp.Data = txs
-					}
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyTransaction+EventPostfixRes, string(respJson[:]))
+					resp = SocketIOJsonResp{Error: err.Error()}
+				} else {
+					resp.Data = txs
 				}
-			}
+				respJson, _ := json.Marshal(resp)
+				return string(respJson[:])
+			})
+			emitSubscription(v, eventKeyTransaction, sub, respJson)
 		}
-		return true
 	})
 
 	return nil
@@ -915,36 +876,31 @@ func (so *SocketIOServiceImpl) handleLatestTransactions(input interface{}) (err
 	req := input.(*txtyp.TransactionView)
 	owner := req.Owner.Hex()
 	//log.Infof("received owner is %s ", owner)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyLatestTransaction]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				txQuery := &TransactionQuery{}
-				//log.Info("txQuery owner is " + txQuery.Owner)
-				err = json.Unmarshal([]byte(ctx), txQuery)
+	results := newSharedResults()
+	so.rangeSubscriptions(eventKeyLatestTransaction, func(v socketio.Conn, sub SocketIOSubscription) {
+		txQuery := &TransactionQuery{}
+		//log.Info("txQuery owner is " + txQuery.Owner)
+		err = json.Unmarshal([]byte(sub.Query), txQuery)
+		if err != nil {
+			log.Error("tx query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
+			log.Info("emit trend " + sub.Query)
+
+			respJson := results.get(so.queryKey(eventKeyLatestTransaction, sub.Query), func() string {
+				txs, err := so.walletService.GetLatestTransactions(*txQuery)
+				resp := SocketIOJsonResp{}
+
 				if err != nil {
-					log.Error("tx query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
-					log.Info("emit trend " + ctx)
-
-					txs, err := so.walletService.GetLatestTransactions(*txQuery)
-					resp := SocketIOJsonResp{}
-
-					if err != nil {
-						resp = SocketIOJsonResp{Error: err.Error()}
-					} else {
-						resp.Data = txs
-					}
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyTransaction+EventPostfixRes, string(respJson[:]))
+					resp = SocketIOJsonResp{Error: err.Error()}
+				} else {
+					resp.Data = txs
 				}
-			}
+				respJson, _ := json.Marshal(resp)
+				return string(respJson[:])
+			})
+			// latest transactions are pushed as transactions
+			emitSubscriptionAs(v, eventKeyLatestTransaction, eventKeyTransaction+EventPostfixRes, sub, respJson)
 		}
-		return true
 	})
 
 	return nil
@@ -964,35 +920,29 @@ func (so *SocketIOServiceImpl) handlePendingTransaction(input interface{}) (err
 	req := input.(*txtyp.TransactionView)
 	owner := req.Owner.Hex()
 	//log.Infof("received owner is %s ", owner)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyPendingTx]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				txQuery := &SingleOwner{}
-				err = json.Unmarshal([]byte(ctx), txQuery)
-				log.Info("single owner is: " + txQuery.Owner)
+	results := newSharedResults()
+	so.rangeSubscriptions(eventKeyPendingTx, func(v socketio.Conn, sub SocketIOSubscription) {
+		txQuery := &SingleOwner{}
+		err = json.Unmarshal([]byte(sub.Query), txQuery)
+		log.Info("single owner is: " + txQuery.Owner)
+		if err != nil {
+			log.Error("tx query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
+			log.Info("emit tx pending " + sub.Query)
+			respJson := results.get("", func() string {
+				txs, err := so.walletService.GetPendingTransactions(SingleOwner{owner})
+				resp := SocketIOJsonResp{}
+
 				if err != nil {
-					log.Error("tx query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(owner) == strings.ToUpper(txQuery.Owner) {
-					log.Info("emit tx pending " + ctx)
-					txs, err := so.walletService.GetPendingTransactions(SingleOwner{owner})
-					resp := SocketIOJsonResp{}
-
-					if err != nil {
-						resp = SocketIOJsonResp{Error: err.Error()}
-					} else {
-						resp.Data = txs
-					}
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyPendingTx+EventPostfixRes, string(respJson[:]))
+					resp = SocketIOJsonResp{Error: err.Error()}
+				} else {
+					resp.Data = txs
 				}
-			}
+				respJson, _ := json.Marshal(resp)
+				return string(respJson[:])
+			})
+			emitSubscription(v, eventKeyPendingTx, sub, respJson)
 		}
-		return true
 	})
 
 	return nil
@@ -1007,33 +957,27 @@ func (so *SocketIOServiceImpl) handleOrdersUpdate(input interface{}) (err error)
 	//log.Infof("received owner is %s ", owner)
 
 	orderQuery := LatestOrderQuery{Owner: owner, Market: req.RawOrder.Market, OrderType: req.RawOrder.OrderType}
-	orderList, err := so.walletService.GetLatestOrders(orderQuery)
-
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyOrders]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				query := &LatestOrderQuery{}
-				err = json.Unmarshal([]byte(ctx), query)
-				//log.Info("single owner is: " + query.Owner)
-				if err != nil {
-					//log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(owner) == strings.ToUpper(query.Owner) &&
-					strings.ToLower(req.RawOrder.Market) == strings.ToLower(query.Market) &&
-					strings.ToLower(req.RawOrder.OrderType) == strings.ToLower(query.OrderType) {
-					//log.Info("emit " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = orderList
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyOrders+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	results := newSharedResults()
+
+	so.rangeSubscriptions(eventKeyOrders, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &LatestOrderQuery{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		//log.Info("single owner is: " + query.Owner)
+		if err != nil {
+			//log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(owner) == strings.ToUpper(query.Owner) &&
+			strings.ToLower(req.RawOrder.Market) == strings.ToLower(query.Market) &&
+			strings.ToLower(req.RawOrder.OrderType) == strings.ToLower(query.OrderType) {
+			//log.Info("emit " + sub.Query)
+			respJson := results.get("", func() string {
+				orderList, _ := so.walletService.GetLatestOrders(orderQuery)
+				resp := SocketIOJsonResp{}
+				resp.Data = orderList
+				respJson, _ := json.Marshal(resp)
+				return string(respJson[:])
+			})
+			emitSubscription(v, eventKeyOrders, sub, respJson)
 		}
-		return true
 	})
 
 	return nil
@@ -1047,32 +991,27 @@ func (so *SocketIOServiceImpl) handleOrderAllocateChange(input interface{}) (err
 	//log.Infof("received order allocated owner  is %s ", owner)
 
 	allocatedQuery := EstimatedAllocatedAllowanceQuery{Owner: owner, DelegateAddress: delegateAddress}
-	allocateMap, err := so.walletService.GetAllEstimatedAllocatedAmount(allocatedQuery)
-
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyOrderAllocateChange]
-
-			if ok {
-				query := &EstimatedAllocatedAllowanceQuery{}
-				err = json.Unmarshal([]byte(ctx), query)
-				//log.Info("single owner is: " + query.Owner)
-				if err != nil {
-					log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(owner) == strings.ToUpper(query.Owner) &&
-					strings.ToLower(req.RawOrder.DelegateAddress.Hex()) == strings.ToLower(delegateAddress) {
-					log.Info("emit ctx " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = allocateMap
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyOrderAllocateChange+EventPostfixRes, string(respJson[:]))
-					log.Info("emit data " + string(respJson))
-				}
-			}
+	results := newSharedResults()
+
+	so.rangeSubscriptions(eventKeyOrderAllocateChange, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &EstimatedAllocatedAllowanceQuery{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		//log.Info("single owner is: " + query.Owner)
+		if err != nil {
+			log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(owner) == strings.ToUpper(query.Owner) &&
+			strings.ToLower(req.RawOrder.DelegateAddress.Hex()) == strings.ToLower(delegateAddress) {
+			log.Info("emit ctx " + sub.Query)
+			respJson := results.get("", func() string {
+				allocateMap, _ := so.walletService.GetAllEstimatedAllocatedAmount(allocatedQuery)
+				resp := SocketIOJsonResp{}
+				resp.Data = allocateMap
+				respJson, _ := json.Marshal(resp)
+				return string(respJson[:])
+			})
+			emitSubscription(v, eventKeyOrderAllocateChange, sub, respJson)
+			log.Info("emit data " + respJson)
 		}
-		return true
 	})
 
 	return nil
@@ -1085,29 +1024,19 @@ func (so *SocketIOServiceImpl) handleOrderTracing(input interface{}) (err error)
 	req := input.(*types.OrderState)
 	orderHash := req.RawOrder.Hash.Hex()
 	log.Infof("received orderHash is %s ", orderHash)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyOrderTracing]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				query := &OrderQuery{}
-				err = json.Unmarshal([]byte(ctx), query)
-				log.Info("single owner is: " + query.Owner)
-				if err != nil {
-					log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToUpper(orderHash) == strings.ToUpper(query.OrderHash) {
-					log.Info("emit " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = orderStateToJson(*req)
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyOrderTracing+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	so.rangeSubscriptions(eventKeyOrderTracing, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &OrderQuery{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		log.Info("single owner is: " + query.Owner)
+		if err != nil {
+			log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToUpper(orderHash) == strings.ToUpper(query.OrderHash) {
+			log.Info("emit " + sub.Query)
+			resp := SocketIOJsonResp{}
+			resp.Data = orderStateToJson(*req)
+			respJson, _ := json.Marshal(resp)
+			emitSubscription(v, eventKeyOrderTracing, sub, string(respJson[:]))
 		}
-		return true
 	})
 
 	return nil
@@ -1115,20 +1044,33 @@ func (so *SocketIOServiceImpl) handleOrderTracing(input interface{}) (err error)
 
 func (so *SocketIOServiceImpl) handleOrderUpdate(input interface{}) (err error) {
 	log.Infof("[SOCKETIO-RECEIVE-EVENT] order update.")
+	so.handleOrderOwnerUpdate(input)
+	return so.handleOrderMarketUpdate(input)
+}
+
+// handleOrderOwnerUpdate pushes the private events of the owner of the order.
+func (so *SocketIOServiceImpl) handleOrderOwnerUpdate(input interface{}) (err error) {
 	order := input.(*types.OrderState)
 	so.handleOrdersUpdate(order)
 	//so.handleOrderTracing(order)
 
 	log.Info("received order " + order.RawOrder.Hash.Hex())
 	so.handleOrderAllocateChange(input)
+	return nil
+}
 
+// handleOrderMarketUpdate pushes the public events of the market of the order.
+func (so *SocketIOServiceImpl) handleOrderMarketUpdate(input interface{}) (err error) {
+	order := input.(*types.OrderState)
 	if order.RawOrder.OrderType == types.ORDER_TYPE_P2P {
 		return nil
 	}
 
-	//TODO finish the depth cache.
+	// the order book in memory is updated before depth is pushed
+	so.walletService.orderViewer.UpdateOrderBook(order)
 	so.broadcastOrderBook(DepthQuery{DelegateAddress: order.RawOrder.DelegateAddress.Hex(), Market: order.RawOrder.Market})
 	so.broadcastDepth(DepthQuery{DelegateAddress: order.RawOrder.DelegateAddress.Hex(), Market: order.RawOrder.Market})
+	so.broadcastDepthDiff(DepthQuery{DelegateAddress: order.RawOrder.DelegateAddress.Hex(), Market: order.RawOrder.Market})
 	return nil
 }
 
@@ -1137,8 +1079,10 @@ func (so *SocketIOServiceImpl) handleCutOff(input interface{}) (err error) {
 	//req := input.(*socketioutil.KafkaMsg)
 	//owner := req.Data.(string)
 	//so.e
+	so.walletService.orderViewer.RemoveFromOrderBook(input.(*types.CutoffEvent).OrderHashList)
 	so.broadcastOrderBook(nil)
 	so.broadcastDepth(nil)
+	so.broadcastDepthDiff(nil)
 	return nil
 }
 
@@ -1146,12 +1090,14 @@ func (so *SocketIOServiceImpl) handleCutOffPair(input interface{}) (err error) {
 	//log.Infof("[SOCKETIO-RECEIVE-EVENT] order update.")
 	cutoffPair := input.(*types.CutoffPairEvent)
 	//so.handleOrdersUpdate(req.Data.(*types.CutoffPairEvent))
+	so.walletService.orderViewer.RemoveFromOrderBook(cutoffPair.OrderHashList)
 	market, err := util.WrapMarketByAddress(cutoffPair.Token1.Hex(), cutoffPair.Token2.Hex())
 	if err != nil {
 		return err
 	}
 	so.broadcastOrderBook(DepthQuery{DelegateAddress: cutoffPair.DelegateAddress.Hex(), Market: market})
 	so.broadcastDepth(DepthQuery{DelegateAddress: cutoffPair.DelegateAddress.Hex(), Market: market})
+	so.broadcastDepthDiff(DepthQuery{DelegateAddress: cutoffPair.DelegateAddress.Hex(), Market: market})
 	return nil
 }
 
@@ -1162,28 +1108,18 @@ func (so *SocketIOServiceImpl) handleOrderTransfer(input interface{}) (err error
 	ot := input.(*OrderTransfer)
 	ot.Origin = ""
 	log.Infof("received hash is %s ", ot.Hash)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyOrderTransfer]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				query := &OrderTransferQuery{}
-				err = json.Unmarshal([]byte(ctx), query)
-				if err != nil {
-					log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToLower(ot.Hash) == strings.ToLower(query.Hash) {
-					log.Info("emit " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = ot
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyOrderTransfer+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	so.rangeSubscriptions(eventKeyOrderTransfer, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &OrderTransferQuery{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		if err != nil {
+			log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToLower(ot.Hash) == strings.ToLower(query.Hash) {
+			log.Info("emit " + sub.Query)
+			resp := SocketIOJsonResp{}
+			resp.Data = ot
+			respJson, _ := json.Marshal(resp)
+			emitSubscription(v, eventKeyOrderTransfer, sub, string(respJson[:]))
 		}
-		return true
 	})
 
 	return nil
@@ -1195,28 +1131,18 @@ func (so *SocketIOServiceImpl) handleScanLogin(input interface{}) (err error) {
 
 	ot := input.(*LoginInfo)
 	log.Infof("received UUID is %s ", ot.UUID)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyScanLogin]
-			log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				query := &LoginInfo{}
-				err = json.Unmarshal([]byte(ctx), query)
-				if err != nil {
-					log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToLower(ot.UUID) == strings.ToLower(query.UUID) {
-					log.Info("emit " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = ot
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyScanLogin+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	so.rangeSubscriptions(eventKeyScanLogin, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &LoginInfo{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		if err != nil {
+			log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToLower(ot.UUID) == strings.ToLower(query.UUID) {
+			log.Info("emit " + sub.Query)
+			resp := SocketIOJsonResp{}
+			resp.Data = ot
+			respJson, _ := json.Marshal(resp)
+			emitSubscription(v, eventKeyScanLogin, sub, string(respJson[:]))
 		}
-		return true
 	})
 
 	return nil
@@ -1226,28 +1152,18 @@ func (so *SocketIOServiceImpl) handleCirculrNotify(input interface{}) (err error
 
 	ot := input.(*NotifyCirculrBody)
 	log.Infof("received owner is %s ", ot.Owner)
-	so.connIdMap.Range(func(key, value interface{}) bool {
-		v := value.(socketio.Conn)
-		if v.Context() != nil {
-			businesses := v.Context().(map[string]string)
-			ctx, ok := businesses[eventKeyCirculrNotify]
-			//log.Infof("cxt contains event key %b", ok)
-
-			if ok {
-				query := &SingleOwner{}
-				err = json.Unmarshal([]byte(ctx), query)
-				if err != nil {
-					log.Error("query unmarshal error, " + err.Error())
-				} else if strings.ToLower(ot.Owner) == strings.ToLower(query.Owner) {
-					log.Info("emit " + ctx)
-					resp := SocketIOJsonResp{}
-					resp.Data = ot
-					respJson, _ := json.Marshal(resp)
-					v.Emit(eventKeyCirculrNotify+EventPostfixRes, string(respJson[:]))
-				}
-			}
+	so.rangeSubscriptions(eventKeyCirculrNotify, func(v socketio.Conn, sub SocketIOSubscription) {
+		query := &SingleOwner{}
+		err = json.Unmarshal([]byte(sub.Query), query)
+		if err != nil {
+			log.Error("query unmarshal error, " + err.Error())
+		} else if strings.ToLower(ot.Owner) == strings.ToLower(query.Owner) {
+			log.Info("emit " + sub.Query)
+			resp := SocketIOJsonResp{}
+			resp.Data = ot
+			respJson, _ := json.Marshal(resp)
+			emitSubscription(v, eventKeyCirculrNotify, sub, string(respJson[:]))
 		}
-		return true
 	})
 
 	return nil
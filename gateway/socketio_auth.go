@@ -0,0 +1,283 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/Loopring/relay-lib/cache"
+	"github.com/Loopring/relay-lib/crypto"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/googollee/go-socket.io"
+	"reflect"
+	"strings"
+)
+
+const (
+	eventKeyAuth = "auth"
+
+	SocketIOSessionPrefix = "lpr_socketio_session_"
+	SocketIOSessionTtl    = 3600 * 6
+
+	AuthNoncePrefix = "lpr_auth_nonce_"
+	AuthNonceTtl    = 300
+
+	// an auth nonce is only accepted for the purpose it's issued for
+	AuthPurposeSession   = "session"
+	AuthPurposeScanLogin = "scan_login"
+	AuthPurposeGrpc      = "grpc"
+
+	socketIOCodeUnauthorized = "401"
+)
+
+var authPurposes = map[string]bool{
+	AuthPurposeSession:   true,
+	AuthPurposeScanLogin: true,
+	AuthPurposeGrpc:      true,
+}
+
+// events carrying data of a single owner, they are only served to connections authenticated as the owner
+var privateEventKeys = map[string]bool{
+	eventKeyBalance:             true,
+	eventKeyTransaction:         true,
+	eventKeyLatestTransaction:   true,
+	eventKeyPendingTx:           true,
+	eventKeyOrders:              true,
+	eventKeyOrderAllocateChange: true,
+}
+
+// SocketIOAuthRequest authenticates an owner on a connection either by a sign of
+// an auth nonce for AuthPurposeSession, or by a session token got from a former auth or scan login.
+type SocketIOAuthRequest struct {
+	Sign  *AuthSign `json:"sign"`
+	Token string    `json:"token"`
+}
+
+type AuthNonceQuery struct {
+	Owner   string `json:"owner"`
+	Purpose string `json:"purpose"`
+}
+
+// AuthSign is the owner's signature(v, r, s) of the hash of "nonce|owner|purpose", owner in lower case.
+// Nonce is got from loopring_getAuthNonce, it can be used only once.
+type AuthSign struct {
+	Owner string `json:"owner"`
+	Nonce string `json:"nonce"`
+	V     uint8  `json:"v"`
+	R     string `json:"r"`
+	S     string `json:"s"`
+}
+
+// authNonceStore keeps the nonces issued to owners, use must remove a nonce atomically,
+// so that a nonce authenticates a single request across all relay nodes.
+type authNonceStore interface {
+	save(nonce, member string) error
+	use(nonce, member string) bool
+}
+
+type redisAuthNonceStore struct{}
+
+func (redisAuthNonceStore) save(nonce, member string) error {
+	return cache.SAdd(AuthNoncePrefix+nonce, AuthNonceTtl, []byte(member))
+}
+
+func (redisAuthNonceStore) use(nonce, member string) bool {
+	removed, err := cache.SRem(AuthNoncePrefix+nonce, []byte(member))
+	return err == nil && removed > 0
+}
+
+var authNonces authNonceStore = redisAuthNonceStore{}
+
+func randomHex() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func authNonceMember(owner, purpose string) string {
+	return strings.ToLower(owner) + "|" + purpose
+}
+
+// newAuthNonce issues a nonce for owner to sign for purpose, it expires in AuthNonceTtl seconds.
+func newAuthNonce(owner, purpose string) (string, error) {
+	if !common.IsHexAddress(owner) {
+		return "", errors.New("owner is invalid")
+	}
+	if !authPurposes[purpose] {
+		return "", errors.New("auth purpose " + purpose + " is invalid")
+	}
+	nonce, err := randomHex()
+	if err != nil {
+		return "", err
+	}
+	if err := authNonces.save(nonce, authNonceMember(owner, purpose)); err != nil {
+		log.Errorf("failed to save auth nonce of %s, err:%s", owner, err.Error())
+		return "", errors.New("failed to create auth nonce")
+	}
+	return nonce, nil
+}
+
+// verifyAuthSign checks the signer of sign is its owner, and uses up the nonce of sign issued for purpose.
+func verifyAuthSign(sign AuthSign, purpose string) error {
+	owner := strings.ToLower(sign.Owner)
+	if !common.IsHexAddress(owner) || len(sign.Nonce) == 0 {
+		return errors.New("owner and nonce of sign can't be empty")
+	}
+
+	hash := crypto.GenerateHash([]byte(sign.Nonce + "|" + owner + "|" + purpose))
+	sig, err := crypto.VRSToSig(sign.V, types.HexToBytes32(sign.R).Bytes(), types.HexToBytes32(sign.S).Bytes())
+	if err != nil {
+		return errors.New("sign is incorrect")
+	}
+	addressBytes, err := crypto.SigToAddress(hash, sig)
+	if err != nil {
+		return errors.New("sign is incorrect")
+	}
+	if common.BytesToAddress(addressBytes) != common.HexToAddress(owner) {
+		return errors.New("sign address not matched")
+	}
+
+	if !authNonces.use(sign.Nonce, authNonceMember(owner, purpose)) {
+		return errors.New("auth nonce is invalid, expired or used")
+	}
+	return nil
+}
+
+type SocketIOAuthResult struct {
+	Owner string `json:"owner"`
+	Token string `json:"token"`
+}
+
+// newSocketIOSession returns a token that authenticates owner on any relay node until it expires.
+func newSocketIOSession(owner string) (string, error) {
+	token, err := randomHex()
+	if err != nil {
+		return "", err
+	}
+	if err := cache.Set(SocketIOSessionPrefix+token, []byte(strings.ToLower(owner)), SocketIOSessionTtl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func getSocketIOSessionOwner(token string) (string, error) {
+	if len(token) == 0 {
+		return "", errors.New("session token can't be empty")
+	}
+	owner, err := cache.Get(SocketIOSessionPrefix + token)
+	if err != nil || len(owner) == 0 {
+		return "", errors.New("session token is invalid or expired")
+	}
+	return string(owner), nil
+}
+
+func authenticateSocketIO(req SocketIOAuthRequest) (res SocketIOAuthResult, err error) {
+	if req.Sign != nil {
+		if err := verifyAuthSign(*req.Sign, AuthPurposeSession); err != nil {
+			return res, err
+		}
+		res.Owner = strings.ToLower(req.Sign.Owner)
+		if res.Token, err = newSocketIOSession(res.Owner); err != nil {
+			log.Errorf("failed to create socketio session of %s, err:%s", res.Owner, err.Error())
+			return res, errors.New("failed to create session")
+		}
+		return res, nil
+	}
+
+	if res.Owner, err = getSocketIOSessionOwner(req.Token); err != nil {
+		return res, err
+	}
+	res.Token = req.Token
+	return res, nil
+}
+
+func (so *SocketIOServiceImpl) handleAuth(s socketio.Conn, msg string) {
+	s = so.socketIOConn(s)
+	resp := SocketIOJsonResp{}
+	req := SocketIOAuthRequest{}
+	if err := json.Unmarshal([]byte(msg), &req); err != nil {
+		resp.Error = err.Error()
+	} else if res, err := authenticateSocketIO(req); err != nil {
+		resp.Error = err.Error()
+		resp.Code = socketIOCodeUnauthorized
+	} else {
+		ensureSubscriptions(s).authorize(res.Owner)
+		resp.Data = res
+	}
+	respJson, _ := json.Marshal(resp)
+	s.Emit(eventKeyAuth+EventPostfixRes, string(respJson[:]))
+}
+
+// handleAuthEnd logs out the owner in msg or all owners when msg is empty,
+// private subscriptions of the owners are ended too.
+func (so *SocketIOServiceImpl) handleAuthEnd(s socketio.Conn, msg string) {
+	subs := connSubscriptions(s)
+	if subs == nil {
+		return
+	}
+	query := SingleOwner{}
+	json.Unmarshal([]byte(msg), &query)
+	subs.unauthorize(query.Owner)
+
+	for eventKey := range privateEventKeys {
+		for _, sub := range subs.list(eventKey) {
+			if !subs.isAuthorized(so.subscriptionOwner(eventKey, sub.Query)) {
+				subs.remove(eventKey, sub.Id)
+			}
+		}
+	}
+}
+
+// checkSubscriptionAuth returns nil when eventKey is public or the owner of query is authenticated on the connection.
+func (so *SocketIOServiceImpl) checkSubscriptionAuth(subs *socketIOSubscriptions, eventKey, query string) error {
+	if !privateEventKeys[eventKey] || so.options.AnonymousPrivateChannels {
+		return nil
+	}
+	owner := so.subscriptionOwner(eventKey, query)
+	if len(owner) == 0 {
+		return errors.New("owner can't be empty")
+	}
+	if !subs.isAuthorized(owner) {
+		return errors.New("owner " + owner + " isn't authenticated, emit " + eventKeyAuth + EventPostfixReq + " first")
+	}
+	return nil
+}
+
+// subscriptionOwner decodes query as the request type of eventKey and returns its Owner.
+func (so *SocketIOServiceImpl) subscriptionOwner(eventKey, query string) string {
+	invokeInfo, ok := so.eventTypeRoute[eventKey]
+	if !ok || invokeInfo.Query == nil {
+		return ""
+	}
+	queryClone := reflect.New(reflect.TypeOf(invokeInfo.Query))
+	if err := json.Unmarshal([]byte(query), queryClone.Interface()); err != nil {
+		return ""
+	}
+	owner := queryClone.Elem().FieldByName("Owner")
+	if !owner.IsValid() || owner.Kind() != reflect.String {
+		return ""
+	}
+	return strings.ToLower(owner.String())
+}
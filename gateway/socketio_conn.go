@@ -0,0 +1,222 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"github.com/Loopring/relay-lib/cloudwatch"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/googollee/go-socket.io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	SlowConsumerPolicyDrop       = "drop"
+	SlowConsumerPolicyDisconnect = "disconnect"
+
+	defaultSocketIOQueueSize           = 256
+	defaultSocketIOMaxConnectionsPerIp = 50
+	defaultSocketIOPingInterval        = 25
+	defaultSocketIOPingTimeout         = 60
+
+	metricSocketIOMessageDropped      = "socketio_message_dropped"
+	metricSocketIOSlowConsumerClosed  = "socketio_slow_consumer_disconnected"
+	metricSocketIOConnectionsRejected = "socketio_connection_rejected"
+)
+
+type socketIOMessage struct {
+	event string
+	args  []interface{}
+}
+
+// socketIOConn is stored in connIdMap instead of the raw connection,
+// Emit only puts the message into a bounded queue which is written by a goroutine of the connection,
+// so that a slow client never blocks broadcasting to others.
+type socketIOConn struct {
+	socketio.Conn
+	ip        string
+	queue     chan socketIOMessage
+	quit      chan struct{}
+	closeOnce sync.Once
+	so        *SocketIOServiceImpl
+}
+
+func newSocketIOConn(so *SocketIOServiceImpl, conn socketio.Conn, ip string) *socketIOConn {
+	c := &socketIOConn{
+		Conn:  conn,
+		ip:    ip,
+		queue: make(chan socketIOMessage, so.options.QueueSize),
+		quit:  make(chan struct{}),
+		so:    so,
+	}
+	go c.serveWrite()
+	return c
+}
+
+func (c *socketIOConn) Emit(event string, v ...interface{}) {
+	c.tryEmit(event, v...)
+}
+
+// tryEmit queues the message as Emit, it returns false when the message isn't queued.
+func (c *socketIOConn) tryEmit(event string, v ...interface{}) bool {
+	select {
+	case <-c.quit:
+		return false
+	default:
+	}
+
+	select {
+	case c.queue <- socketIOMessage{event: event, args: v}:
+		return true
+	default:
+		c.so.onSlowConsumer(c, event)
+		return false
+	}
+}
+
+func (c *socketIOConn) serveWrite() {
+	for {
+		select {
+		case <-c.quit:
+			return
+		case msg := <-c.queue:
+			c.Conn.Emit(msg.event, msg.args...)
+		}
+	}
+}
+
+// stop ends the writer, it returns false if stopped already.
+func (c *socketIOConn) stop() bool {
+	stopped := false
+	c.closeOnce.Do(func() {
+		close(c.quit)
+		stopped = true
+	})
+	return stopped
+}
+
+// socketIOStats counts connections since the relay started, the others since last reportStats.
+type socketIOStats struct {
+	connections         int64
+	rejectedConnections int64
+	droppedMessages     int64
+	slowConsumersClosed int64
+}
+
+func (so *SocketIOServiceImpl) applyConnOptions(options *WebsocketOptions) {
+	so.options = *options
+	if so.options.QueueSize <= 0 {
+		so.options.QueueSize = defaultSocketIOQueueSize
+	}
+	if so.options.SlowConsumerPolicy != SlowConsumerPolicyDisconnect {
+		so.options.SlowConsumerPolicy = SlowConsumerPolicyDrop
+	}
+	if so.options.MaxConnectionsPerIp == 0 {
+		so.options.MaxConnectionsPerIp = defaultSocketIOMaxConnectionsPerIp
+	}
+	if so.options.PingInterval <= 0 {
+		so.options.PingInterval = defaultSocketIOPingInterval
+	}
+	if so.options.PingTimeout <= 0 {
+		so.options.PingTimeout = defaultSocketIOPingTimeout
+	}
+}
+
+// addConn wraps s and stores it in connIdMap, it returns nil when the ip of s has too many connections.
+func (so *SocketIOServiceImpl) addConn(s socketio.Conn) *socketIOConn {
+	ip := remoteIp(&http.Request{RemoteAddr: s.RemoteAddr().String(), Header: s.RemoteHeader()})
+
+	so.ipMtx.Lock()
+	if so.options.MaxConnectionsPerIp > 0 && so.ipConnections[ip] >= so.options.MaxConnectionsPerIp {
+		so.ipMtx.Unlock()
+		atomic.AddInt64(&so.stats.rejectedConnections, 1)
+		log.Infof("[SOCKETIO] too many connections from %s, connection %s rejected", ip, s.ID())
+		return nil
+	}
+	so.ipConnections[ip]++
+	so.ipMtx.Unlock()
+
+	c := newSocketIOConn(so, s, ip)
+	so.connIdMap.Store(s.ID(), c)
+	atomic.AddInt64(&so.stats.connections, 1)
+	return c
+}
+
+func (so *SocketIOServiceImpl) removeConn(connId string) {
+	value, ok := so.connIdMap.Load(connId)
+	if !ok {
+		return
+	}
+	so.connIdMap.Delete(connId)
+
+	c, ok := value.(*socketIOConn)
+	if !ok || !c.stop() {
+		return
+	}
+	so.ipMtx.Lock()
+	if so.ipConnections[c.ip]--; so.ipConnections[c.ip] <= 0 {
+		delete(so.ipConnections, c.ip)
+	}
+	so.ipMtx.Unlock()
+	atomic.AddInt64(&so.stats.connections, -1)
+}
+
+// socketIOConn returns the queued connection of s, s itself if it isn't stored yet.
+func (so *SocketIOServiceImpl) socketIOConn(s socketio.Conn) socketio.Conn {
+	if value, ok := so.connIdMap.Load(s.ID()); ok {
+		return value.(socketio.Conn)
+	}
+	return s
+}
+
+func (so *SocketIOServiceImpl) onSlowConsumer(c *socketIOConn, event string) {
+	if so.options.SlowConsumerPolicy == SlowConsumerPolicyDisconnect {
+		if _, ok := so.connIdMap.Load(c.ID()); !ok {
+			return
+		}
+		atomic.AddInt64(&so.stats.slowConsumersClosed, 1)
+		log.Infof("[SOCKETIO] queue of connection %s from %s is full, disconnect it", c.ID(), c.ip)
+		so.removeConn(c.ID())
+		c.Conn.Close()
+		return
+	}
+	atomic.AddInt64(&so.stats.droppedMessages, 1)
+	log.Debugf("[SOCKETIO] queue of connection %s from %s is full, %s dropped", c.ID(), c.ip, event)
+}
+
+// reportStats logs the counts since last report, the counters are reset after read.
+// cloudwatch only takes heartbeats, one is put for each kind of event happened in the interval.
+func (so *SocketIOServiceImpl) reportStats() {
+	rejected := atomic.SwapInt64(&so.stats.rejectedConnections, 0)
+	dropped := atomic.SwapInt64(&so.stats.droppedMessages, 0)
+	closed := atomic.SwapInt64(&so.stats.slowConsumersClosed, 0)
+	log.Infof("[SOCKETIO] connections:%d, since last report rejected connections:%d, dropped messages:%d, slow consumers disconnected:%d",
+		atomic.LoadInt64(&so.stats.connections), rejected, dropped, closed)
+
+	for metric, count := range map[string]int64{
+		metricSocketIOConnectionsRejected: rejected,
+		metricSocketIOMessageDropped:      dropped,
+		metricSocketIOSlowConsumerClosed:  closed,
+	} {
+		if count > 0 {
+			cloudwatch.PutHeartBeatMetric(metric)
+		}
+	}
+}
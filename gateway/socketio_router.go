@@ -0,0 +1,289 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	txtyp "github.com/Loopring/relay-cluster/txmanager/types"
+	"github.com/Loopring/relay-cluster/util"
+	"github.com/Loopring/relay-lib/cache"
+	"github.com/Loopring/relay-lib/kafka"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/Shopify/sarama"
+	"github.com/googollee/go-socket.io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// every node consumes the routed messages of its own topic, named by the prefix and the instance id of the node.
+// The topics must be created before nodes start, they're not left to the auto creation of brokers.
+const Kafka_Topic_SocketIO_Node_Prefix = "Kafka_Topic_SocketIO_Node_"
+
+const (
+	SocketIORegistryPrefix = "lpr_socketio_owner_nodes_"
+	// a node refreshes its owners every minute, owners of a node not refreshed in ttl are ignored
+	SocketIORegistryTtl = 180
+
+	socketIOConsumerGroupPrefix = "SocketIOService_"
+	socketIORouterGroupId       = "SocketIOService_Router"
+)
+
+// socketIORoutedMessage wraps a message of a private topic forwarded to the nodes holding its owner.
+type socketIORoutedMessage struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// socketIOPrivateTopic is a topic whose messages only concern a single owner.
+type socketIOPrivateTopic struct {
+	SocketMsgHandler
+	owner func(data interface{}) string
+}
+
+// socketIOInstanceId returns the configured instance id, or hostname_port which is stable across restarts.
+// TargetedPush requires an instance id configured, which names the pre-created topic of the node.
+func socketIOInstanceId(options *WebsocketOptions) string {
+	if len(options.InstanceId) > 0 {
+		return options.InstanceId
+	}
+	if options.TargetedPush {
+		log.Fatalf("Failed to init socketio, websocket.instance_id must be set with targeted_push")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Failed to get hostname for socketio instance id, set websocket.instance_id instead, %s", err.Error())
+	}
+	return hostname + "_" + options.Port
+}
+
+// InstanceId identifies the node in kafka consumer groups and topics, see socketIOInstanceId.
+func (so *SocketIOServiceImpl) InstanceId() string {
+	return so.instanceId
+}
+
+func socketIONodeTopic(instanceId string) string {
+	return Kafka_Topic_SocketIO_Node_Prefix + instanceId
+}
+
+// checkKafkaTopic returns an error if topic doesn't exist in the brokers.
+func checkKafkaTopic(brokers []string, topic string) error {
+	client, err := sarama.NewClient(brokers, sarama.NewConfig())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	topics, err := client.Topics()
+	if err != nil {
+		return err
+	}
+	for _, v := range topics {
+		if v == topic {
+			return nil
+		}
+	}
+	return errors.New("kafka topic " + topic + " not found, it must be created before the node starts")
+}
+
+// socketIORegistry stores in redis which nodes hold private subscriptions of an owner,
+// every owner is a hash of node instance id -> unix time of last refresh.
+type socketIORegistry struct {
+	instanceId string
+	mtx        sync.Mutex
+	owners     map[string]bool
+}
+
+func newSocketIORegistry(instanceId string) *socketIORegistry {
+	return &socketIORegistry{instanceId: instanceId, owners: make(map[string]bool)}
+}
+
+func (r *socketIORegistry) register(owner string) {
+	owner = strings.ToLower(owner)
+	if len(owner) == 0 {
+		return
+	}
+	r.mtx.Lock()
+	r.owners[owner] = true
+	r.mtx.Unlock()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := cache.HMSet(SocketIORegistryPrefix+owner, SocketIORegistryTtl, []byte(r.instanceId), []byte(now)); err != nil {
+		log.Errorf("[SOCKETIO] failed to register owner %s, err:%s", owner, err.Error())
+	}
+}
+
+// refresh registers owners again and unregisters the owners no longer subscribed on this node.
+func (r *socketIORegistry) refresh(owners map[string]bool) {
+	r.mtx.Lock()
+	removed := make([]string, 0)
+	for owner := range r.owners {
+		if !owners[owner] {
+			removed = append(removed, owner)
+		}
+	}
+	r.owners = make(map[string]bool)
+	r.mtx.Unlock()
+
+	for _, owner := range removed {
+		if _, err := cache.HDel(SocketIORegistryPrefix+owner, []byte(r.instanceId)); err != nil {
+			log.Errorf("[SOCKETIO] failed to unregister owner %s, err:%s", owner, err.Error())
+		}
+	}
+	for owner := range owners {
+		r.register(owner)
+	}
+}
+
+// nodes returns the instance ids of the nodes holding owner.
+func (r *socketIORegistry) nodes(owner string) ([]string, error) {
+	fields, err := cache.HGetAll(SocketIORegistryPrefix + strings.ToLower(owner))
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Unix() - SocketIORegistryTtl
+	nodes := make([]string, 0)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if refreshed, err := strconv.ParseInt(string(fields[i+1]), 10, 64); err == nil && refreshed > deadline {
+			nodes = append(nodes, string(fields[i]))
+		}
+	}
+	return nodes, nil
+}
+
+// privateTopics are routed by owner when TargetedPush is enabled,
+// the order topic is also consumed by every node for the public depth and order book.
+func (so *SocketIOServiceImpl) privateTopics() map[string]socketIOPrivateTopic {
+	return map[string]socketIOPrivateTopic{
+		kafka.Kafka_Topic_SocketIO_BalanceUpdated: {
+			SocketMsgHandler{types.BalanceUpdateEvent{}, so.handleBalanceUpdate},
+			func(data interface{}) string { return data.(*types.BalanceUpdateEvent).Owner },
+		},
+		kafka.Kafka_Topic_SocketIO_Transaction_Updated: {
+			SocketMsgHandler{txtyp.TransactionView{}, so.handleTransactionUpdate},
+			func(data interface{}) string { return data.(*txtyp.TransactionView).Owner.Hex() },
+		},
+		kafka.Kafka_Topic_SocketIO_Order_Updated: {
+			SocketMsgHandler{types.OrderState{}, so.handleOrderOwnerUpdate},
+			func(data interface{}) string { return data.(*types.OrderState).RawOrder.Owner.Hex() },
+		},
+	}
+}
+
+// registerConsumers subscribes the kafka topics, with TargetedPush the private topics are consumed once
+// by the router group shared by all nodes and forwarded to the topic of the nodes holding the owner.
+func (so *SocketIOServiceImpl) registerConsumers(topicList map[string]SocketMsgHandler) {
+	groupId := socketIOConsumerGroupPrefix + so.instanceId
+	log.Infof("[SOCKETIO] instance %s consumes with group %s, targeted push:%t", so.instanceId, groupId, so.options.TargetedPush)
+
+	privateTopics := so.privateTopics()
+	if so.options.TargetedPush {
+		for k, v := range privateTopics {
+			topic, private := k, v
+			so.registerConsumer(k, socketIORouterGroupId, v.Data, func(input interface{}) error {
+				return so.routePrivateMessage(topic, private, input)
+			})
+		}
+		so.registerConsumer(socketIONodeTopic(so.instanceId), groupId, socketIORoutedMessage{}, so.handleRoutedMessage)
+		topicList[kafka.Kafka_Topic_SocketIO_Order_Updated] = SocketMsgHandler{types.OrderState{}, so.handleOrderMarketUpdate}
+	} else {
+		for k, v := range privateTopics {
+			topicList[k] = v.SocketMsgHandler
+		}
+		topicList[kafka.Kafka_Topic_SocketIO_Order_Updated] = SocketMsgHandler{types.OrderState{}, so.handleOrderUpdate}
+	}
+
+	for k, v := range topicList {
+		so.registerConsumer(k, groupId, v.Data, v.Handler)
+	}
+}
+
+func (so *SocketIOServiceImpl) registerConsumer(topic, groupId string, data interface{}, handler func(data interface{}) error) {
+	if err := so.consumer.RegisterTopicAndHandler(topic, groupId, data, handler); err != nil {
+		log.Fatalf("Failed init socketio consumer, %s", err.Error())
+	}
+}
+
+func (so *SocketIOServiceImpl) routePrivateMessage(topic string, private socketIOPrivateTopic, input interface{}) error {
+	owner := private.owner(input)
+	if len(owner) == 0 {
+		return errors.New("owner can't be nil")
+	}
+	nodes, err := so.registry.nodes(owner)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	msg := socketIORoutedMessage{Topic: topic, Data: data}
+	for _, node := range nodes {
+		if err := util.ProducerNormalMessage(socketIONodeTopic(node), msg); err != nil {
+			log.Errorf("[SOCKETIO] failed to route %s of %s to %s, err:%s", topic, owner, node, err.Error())
+		}
+	}
+	return nil
+}
+
+func (so *SocketIOServiceImpl) handleRoutedMessage(input interface{}) error {
+	msg := input.(*socketIORoutedMessage)
+	private, ok := so.privateTopics()[msg.Topic]
+	if !ok {
+		return errors.New("unsupported routed topic " + msg.Topic)
+	}
+	data := reflect.New(reflect.TypeOf(private.Data)).Interface()
+	if err := json.Unmarshal(msg.Data, data); err != nil {
+		return err
+	}
+	return private.Handler(data)
+}
+
+// registerSubscriptionOwner registers the owner of a private subscription at once,
+// so that its events are routed to this node before the next refresh.
+func (so *SocketIOServiceImpl) registerSubscriptionOwner(eventKey, query string) {
+	if !so.options.TargetedPush || !privateEventKeys[eventKey] {
+		return
+	}
+	so.registry.register(so.subscriptionOwner(eventKey, query))
+}
+
+func (so *SocketIOServiceImpl) refreshRegistry() {
+	if !so.options.TargetedPush {
+		return
+	}
+	owners := make(map[string]bool)
+	for eventKey := range privateEventKeys {
+		so.rangeSubscriptions(eventKey, func(conn socketio.Conn, sub SocketIOSubscription) {
+			if owner := so.subscriptionOwner(eventKey, sub.Query); len(owner) > 0 {
+				owners[owner] = true
+			}
+		})
+	}
+	so.registry.refresh(owners)
+}
@@ -0,0 +1,283 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"github.com/googollee/go-socket.io"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	SubscriptionIdField      = "subscriptionId"
+	MaxSubscriptionsPerEvent = 50
+)
+
+// SocketIOSubscription is a single `_req` of a connection, Query is the raw json sent by client.
+type SocketIOSubscription struct {
+	Id    string
+	Query string
+}
+
+// socketIOSubscriptions is the context of a socket.io connection,
+// it holds every subscription of the connection as eventKey -> subscription id -> query,
+// and the owners authenticated on the connection.
+type socketIOSubscriptions struct {
+	mtx    sync.RWMutex
+	subs   map[string]map[string]string
+	hashes map[string]map[string]uint64
+	owners map[string]bool
+}
+
+func newSocketIOSubscriptions() *socketIOSubscriptions {
+	return &socketIOSubscriptions{
+		subs:   make(map[string]map[string]string),
+		hashes: make(map[string]map[string]uint64),
+		owners: make(map[string]bool),
+	}
+}
+
+func (s *socketIOSubscriptions) authorize(owner string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.owners[strings.ToLower(owner)] = true
+}
+
+// unauthorize removes owner, or all owners when owner is empty.
+func (s *socketIOSubscriptions) unauthorize(owner string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if owner == "" {
+		s.owners = make(map[string]bool)
+	} else {
+		delete(s.owners, strings.ToLower(owner))
+	}
+}
+
+func (s *socketIOSubscriptions) isAuthorized(owner string) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return len(owner) > 0 && s.owners[strings.ToLower(owner)]
+}
+
+func (s *socketIOSubscriptions) add(eventKey, id, query string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.subs[eventKey]; !ok {
+		s.subs[eventKey] = make(map[string]string)
+		s.hashes[eventKey] = make(map[string]uint64)
+	}
+	s.subs[eventKey][id] = query
+	// the first push of a new or replaced subscription is always sent
+	delete(s.hashes[eventKey], id)
+}
+
+// remove deletes the subscription of id, or all subscriptions of eventKey when id is empty.
+func (s *socketIOSubscriptions) remove(eventKey, id string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if id == "" {
+		delete(s.subs, eventKey)
+		delete(s.hashes, eventKey)
+		return
+	}
+	if subs, ok := s.subs[eventKey]; ok {
+		delete(subs, id)
+		delete(s.hashes[eventKey], id)
+		if len(subs) == 0 {
+			delete(s.subs, eventKey)
+			delete(s.hashes, eventKey)
+		}
+	}
+}
+
+// changed reports whether hash differs from the last payload queued for the subscription,
+// payloads not belonging to a subscription(eg. errors of rejected requests) are always changed.
+func (s *socketIOSubscriptions) changed(eventKey, id string, hash uint64) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, ok := s.subs[eventKey][id]; !ok {
+		return true
+	}
+	last, ok := s.hashes[eventKey][id]
+	return !ok || last != hash
+}
+
+// queued records hash as the last payload queued for the subscription, it's only called after the payload is
+// queued, so that a payload dropped for a slow consumer is sent again by the next push.
+func (s *socketIOSubscriptions) queued(eventKey, id string, hash uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.subs[eventKey][id]; ok {
+		s.hashes[eventKey][id] = hash
+	}
+}
+
+// list returns a copy of the subscriptions of eventKey sorted by id.
+func (s *socketIOSubscriptions) list(eventKey string) []SocketIOSubscription {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	list := make([]SocketIOSubscription, 0, len(s.subs[eventKey]))
+	for id, query := range s.subs[eventKey] {
+		list = append(list, SocketIOSubscription{Id: id, Query: query})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
+
+func (s *socketIOSubscriptions) has(eventKey, id string) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	_, ok := s.subs[eventKey][id]
+	return ok
+}
+
+// connSubscriptions returns the subscriptions of conn, nil if conn never subscribed.
+func connSubscriptions(conn socketio.Conn) *socketIOSubscriptions {
+	if conn == nil || conn.Context() == nil {
+		return nil
+	}
+	subs, _ := conn.Context().(*socketIOSubscriptions)
+	return subs
+}
+
+// ensureSubscriptions returns the subscriptions of conn, it's created when missing.
+func ensureSubscriptions(conn socketio.Conn) *socketIOSubscriptions {
+	subs := connSubscriptions(conn)
+	if subs == nil {
+		subs = newSocketIOSubscriptions()
+		conn.SetContext(subs)
+	}
+	return subs
+}
+
+// parseSubscriptionId reads the subscription id from the json sent with `_req` or `_end`.
+// Requests without an id share the default id eventKey, so that a client unaware of
+// subscription ids still replaces its previous subscription as before.
+func parseSubscriptionId(eventKey, msg string) string {
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &req); err != nil {
+		return eventKey
+	}
+	if id, ok := req[SubscriptionIdField].(string); ok && len(strings.TrimSpace(id)) > 0 {
+		return strings.TrimSpace(id)
+	}
+	return eventKey
+}
+
+// withSubscriptionId adds the subscription id into the marshaled SocketIOJsonResp,
+// so that a response computed once can be pushed to many subscriptions.
+func withSubscriptionId(respJson string, id string) string {
+	if !strings.HasPrefix(respJson, "{") {
+		return respJson
+	}
+	idJson, _ := json.Marshal(id)
+	return "{\"" + SubscriptionIdField + "\":" + string(idJson) + "," + respJson[1:]
+}
+
+// rangeSubscriptions calls fn with every subscription of eventKey on every connection.
+func (so *SocketIOServiceImpl) rangeSubscriptions(eventKey string, fn func(conn socketio.Conn, sub SocketIOSubscription)) {
+	so.connIdMap.Range(func(key, value interface{}) bool {
+		v := value.(socketio.Conn)
+		if subs := connSubscriptions(v); subs != nil {
+			for _, sub := range subs.list(eventKey) {
+				fn(v, sub)
+			}
+		}
+		return true
+	})
+}
+
+// emitSubscription pushes respJson to the subscription unless it's the same as the last push.
+func emitSubscription(conn socketio.Conn, eventKey string, sub SocketIOSubscription, respJson string) {
+	emitSubscriptionAs(conn, eventKey, eventKey+EventPostfixRes, sub, respJson)
+}
+
+// emitSubscriptionAs is emitSubscription for subscriptions of eventKey pushed as another event.
+func emitSubscriptionAs(conn socketio.Conn, eventKey, event string, sub SocketIOSubscription, respJson string) {
+	subs := connSubscriptions(conn)
+	hash := payloadHash(respJson)
+	if subs != nil && !subs.changed(eventKey, sub.Id, hash) {
+		return
+	}
+
+	payload := withSubscriptionId(respJson, sub.Id)
+	if c, ok := conn.(*socketIOConn); ok {
+		if !c.tryEmit(event, payload) {
+			return
+		}
+	} else {
+		conn.Emit(event, payload)
+	}
+	if subs != nil {
+		subs.queued(eventKey, sub.Id, hash)
+	}
+}
+
+func payloadHash(payload string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(payload))
+	return h.Sum64()
+}
+
+// sharedResults computes the response of a query once for all subscriptions of it during a single push.
+type sharedResults struct {
+	results map[string]string
+}
+
+func newSharedResults() *sharedResults {
+	return &sharedResults{results: make(map[string]string)}
+}
+
+func (r *sharedResults) get(key string, compute func() string) string {
+	if result, ok := r.results[key]; ok {
+		return result
+	}
+	result := compute()
+	r.results[key] = result
+	return result
+}
+
+// queryKey normalizes query by the request type of eventKey, so that queries only different in
+// format, field order or subscription id share one result.
+func (so *SocketIOServiceImpl) queryKey(eventKey, query string) string {
+	invokeInfo, ok := so.eventTypeRoute[eventKey]
+	if !ok || invokeInfo.Query == nil {
+		return ""
+	}
+	queryClone := reflect.New(reflect.TypeOf(invokeInfo.Query))
+	if err := json.Unmarshal([]byte(query), queryClone.Interface()); err != nil {
+		return query
+	}
+	key, _ := json.Marshal(queryClone.Interface())
+	return string(key)
+}
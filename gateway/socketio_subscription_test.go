@@ -0,0 +1,100 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package gateway
+
+import (
+	"github.com/googollee/go-socket.io"
+	"sync"
+	"testing"
+)
+
+type fakeSocketIOConn struct {
+	socketio.Conn
+	ctx interface{}
+}
+
+func (c *fakeSocketIOConn) ID() string               { return "fake" }
+func (c *fakeSocketIOConn) Context() interface{}     { return c.ctx }
+func (c *fakeSocketIOConn) SetContext(v interface{}) { c.ctx = v }
+
+// newTestSocketIOConn returns a connection whose queue is drained by the test, it's never in connIdMap,
+// so that a full queue only drops the message.
+func newTestSocketIOConn(queueSize int) *socketIOConn {
+	so := &SocketIOServiceImpl{connIdMap: &sync.Map{}}
+	so.options.QueueSize = queueSize
+	so.options.SlowConsumerPolicy = SlowConsumerPolicyDisconnect
+	return &socketIOConn{
+		Conn:  &fakeSocketIOConn{},
+		queue: make(chan socketIOMessage, queueSize),
+		quit:  make(chan struct{}),
+		so:    so,
+	}
+}
+
+func TestEmitSubscriptionSkipsUnchanged(t *testing.T) {
+	c := newTestSocketIOConn(10)
+	ensureSubscriptions(c).add(eventKeyTickers, "a", "{}")
+	sub := SocketIOSubscription{Id: "a", Query: "{}"}
+
+	emitSubscription(c, eventKeyTickers, sub, `{"data":1}`)
+	emitSubscription(c, eventKeyTickers, sub, `{"data":1}`)
+	emitSubscription(c, eventKeyTickers, sub, `{"data":2}`)
+	if len(c.queue) != 2 {
+		t.Fatalf("expected 2 queued messages, got %d", len(c.queue))
+	}
+
+	msg := <-c.queue
+	if msg.event != eventKeyTickers+EventPostfixRes || msg.args[0] != `{"subscriptionId":"a","data":1}` {
+		t.Errorf("unexpected message %s %v", msg.event, msg.args)
+	}
+}
+
+func TestEmitSubscriptionResendsDropped(t *testing.T) {
+	c := newTestSocketIOConn(1)
+	ensureSubscriptions(c).add(eventKeyTickers, "a", "{}")
+	ensureSubscriptions(c).add(eventKeyTickers, "b", "{}")
+
+	emitSubscription(c, eventKeyTickers, SocketIOSubscription{Id: "a"}, `{"data":1}`)
+	// queue is full, the push of b is dropped
+	emitSubscription(c, eventKeyTickers, SocketIOSubscription{Id: "b"}, `{"data":1}`)
+	<-c.queue
+
+	emitSubscription(c, eventKeyTickers, SocketIOSubscription{Id: "b"}, `{"data":1}`)
+	if len(c.queue) != 1 {
+		t.Fatalf("expected the dropped payload to be queued again")
+	}
+	if msg := <-c.queue; msg.args[0] != `{"subscriptionId":"b","data":1}` {
+		t.Errorf("unexpected message %v", msg.args)
+	}
+}
+
+func TestEmitSubscriptionAs(t *testing.T) {
+	c := newTestSocketIOConn(10)
+	ensureSubscriptions(c).add(eventKeyLatestTransaction, "a", "{}")
+	sub := SocketIOSubscription{Id: "a"}
+
+	emitSubscriptionAs(c, eventKeyLatestTransaction, eventKeyTransaction+EventPostfixRes, sub, `{"data":1}`)
+	emitSubscriptionAs(c, eventKeyLatestTransaction, eventKeyTransaction+EventPostfixRes, sub, `{"data":1}`)
+	if len(c.queue) != 1 {
+		t.Fatalf("expected 1 queued message, got %d", len(c.queue))
+	}
+	if msg := <-c.queue; msg.event != eventKeyTransaction+EventPostfixRes {
+		t.Errorf("unexpected event %s", msg.event)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Loopring/relay-cluster/dao"
 	"github.com/Loopring/relay-cluster/market"
 	"github.com/Loopring/relay-cluster/ordermanager/manager"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
 	"github.com/Loopring/relay-cluster/ordermanager/viewer"
 	txtyp "github.com/Loopring/relay-cluster/txmanager/types"
 	txmanager "github.com/Loopring/relay-cluster/txmanager/viewer"
@@ -53,6 +54,9 @@ import (
 const DefaultCapCurrency = "CNY"
 const PendingTxPreKey = "PENDING_TX_"
 
+// ORDER_FINISH_REASON_DUST is the finishReason of orders retired by the dust sweeper.
+const ORDER_FINISH_REASON_DUST = "dust"
+
 const SYS_10001 = "10001"
 const P2P_50001 = "50001"
 const P2P_50002 = "50002"
@@ -81,6 +85,8 @@ type PageResult struct {
 	PageIndex int           `json:"pageIndex"`
 	PageSize  int           `json:"pageSize"`
 	Total     int           `json:"total"`
+	// NextCursor is only returned in cursor mode, empty means there is no more data
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type Depth struct {
@@ -219,6 +225,7 @@ type TransactionQuery struct {
 	TrxHashes []string `json:"trxHashes"`
 	PageIndex int      `json:"pageIndex"`
 	PageSize  int      `json:"pageSize"`
+	Cursor    *string  `json:"cursor,omitempty"`
 }
 
 type OrderQuery struct {
@@ -232,6 +239,7 @@ type OrderQuery struct {
 	OrderHashes     []string `json:"orderHashes"`
 	Side            string   `json:"side"`
 	OrderType       string   `json:"orderType"`
+	Cursor          *string  `json:"cursor,omitempty"`
 }
 
 type DepthQuery struct {
@@ -240,23 +248,25 @@ type DepthQuery struct {
 }
 
 type FillQuery struct {
-	DelegateAddress string `json:"delegateAddress"`
-	Market          string `json:"market"`
-	Owner           string `json:"owner"`
-	OrderHash       string `json:"orderHash"`
-	RingHash        string `json:"ringHash"`
-	PageIndex       int    `json:"pageIndex"`
-	PageSize        int    `json:"pageSize"`
-	Side            string `json:"side"`
-	OrderType       string `json:"orderType"`
+	DelegateAddress string  `json:"delegateAddress"`
+	Market          string  `json:"market"`
+	Owner           string  `json:"owner"`
+	OrderHash       string  `json:"orderHash"`
+	RingHash        string  `json:"ringHash"`
+	PageIndex       int     `json:"pageIndex"`
+	PageSize        int     `json:"pageSize"`
+	Side            string  `json:"side"`
+	OrderType       string  `json:"orderType"`
+	Cursor          *string `json:"cursor,omitempty"`
 }
 
 type RingMinedQuery struct {
-	DelegateAddress string `json:"delegateAddress"`
-	ProtocolAddress string `json:"protocolAddress"`
-	RingIndex       string `json:"ringIndex"`
-	PageIndex       int    `json:"pageIndex"`
-	PageSize        int    `json:"pageSize"`
+	DelegateAddress string  `json:"delegateAddress"`
+	ProtocolAddress string  `json:"protocolAddress"`
+	RingIndex       string  `json:"ringIndex"`
+	PageIndex       int     `json:"pageIndex"`
+	PageSize        int     `json:"pageSize"`
+	Cursor          *string `json:"cursor,omitempty"`
 }
 
 type RawOrderJsonResult struct {
@@ -278,8 +288,7 @@ type RawOrderJsonResult struct {
 	R                     string `json:"r"`
 	S                     string `json:"s"`
 	WalletAddress         string `json:"walletAddress" gencodec:"required"`
-	AuthAddr              string `json:"authAddr" gencodec:"required"`       //
-	AuthPrivateKey        string `json:"authPrivateKey" gencodec:"required"` //
+	AuthAddr              string `json:"authAddr" gencodec:"required"` // authPrivateKey is never returned
 	Market                string `json:"market"`
 	Side                  string `json:"side"`
 	CreateTime            int64  `json:"createTime"`
@@ -293,6 +302,61 @@ type OrderJsonResult struct {
 	CancelledAmountS string             `json:"cancelledAmountS"`
 	CancelledAmountB string             `json:"cancelledAmountB"`
 	Status           string             `json:"status"`
+	FinishReason     string             `json:"finishReason,omitempty"`
+}
+
+type ScheduledOrderQuery struct {
+	Owner  string `json:"owner"`
+	Status string `json:"status"`
+}
+
+type ScheduledOrderJsonResult struct {
+	OrderHash  string          `json:"orderHash"`
+	ValidSince int64           `json:"validSince"`
+	ValidUntil int64           `json:"validUntil"`
+	Status     string          `json:"status"`
+	Reason     string          `json:"reason"`
+	CreateTime int64           `json:"createTime"`
+	UpdateTime int64           `json:"updateTime"`
+	Order      OrderJsonResult `json:"order"`
+}
+
+type StopOrderRequest struct {
+	Order        types.OrderJsonRequest `json:"order"`
+	Condition    string                 `json:"condition"`
+	TriggerPrice float64                `json:"triggerPrice"`
+}
+
+type StopOrderQuery struct {
+	Owner  string `json:"owner"`
+	Status string `json:"status"`
+}
+
+type StopOrderJsonResult struct {
+	OrderHash    string          `json:"orderHash"`
+	Market       string          `json:"market"`
+	Condition    string          `json:"condition"`
+	TriggerPrice float64         `json:"triggerPrice"`
+	LastPrice    float64         `json:"lastPrice"`
+	Status       string          `json:"status"`
+	Reason       string          `json:"reason"`
+	CreateTime   int64           `json:"createTime"`
+	UpdateTime   int64           `json:"updateTime"`
+	Order        OrderJsonResult `json:"order"`
+}
+
+type OrderHistoryJsonResult struct {
+	Status           string `json:"status"`
+	DealtAmountS     string `json:"dealtAmountS"`
+	DealtAmountB     string `json:"dealtAmountB"`
+	SplitAmountS     string `json:"splitAmountS"`
+	SplitAmountB     string `json:"splitAmountB"`
+	CancelledAmountS string `json:"cancelledAmountS"`
+	CancelledAmountB string `json:"cancelledAmountB"`
+	BlockNumber      int64  `json:"blockNumber"`
+	EventType        string `json:"eventType"`
+	TxHash           string `json:"txHash"`
+	CreateTime       int64  `json:"createTime"`
 }
 
 type PriceQuote struct {
@@ -353,12 +417,27 @@ type LatestFill struct {
 }
 
 type CancelOrderQuery struct {
-	Sign       SignInfo `json:"sign"`
-	OrderHash  string   `json:"orderHash"`
-	CutoffTime int64    `json:"cutoff"`
-	TokenS     string   `json:"tokenS"`
-	TokenB     string   `json:"tokenB"`
-	Type       uint8    `json:"type"`
+	Sign        SignInfo `json:"sign"`
+	OrderHash   string   `json:"orderHash"`
+	OrderHashes []string `json:"orderHashes"`
+	CutoffTime  int64    `json:"cutoff"`
+	TokenS      string   `json:"tokenS"`
+	TokenB      string   `json:"tokenB"`
+	Type        uint8    `json:"type"`
+}
+
+type FlexCancelOrderRes struct {
+	OrderHashList []string `json:"orderHashList"`
+}
+
+type OrderGroupQuery struct {
+	Sign        SignInfo `json:"sign"`
+	OrderHashes []string `json:"orderHashes"`
+}
+
+type OrderGroupRes struct {
+	GroupId       string   `json:"groupId"`
+	OrderHashList []string `json:"orderHashList"`
 }
 
 type SignInfo struct {
@@ -382,11 +461,13 @@ type TicketQuery struct {
 type LoginInfo struct {
 	Owner string `json:"owner"`
 	UUID  string `json:"uuid"`
+	Token string `json:"token,omitempty"`
 }
 
 type SignedLoginInfo struct {
-	Sign SignInfo `json:"sign"`
-	UUID string   `json:"uuid"`
+	Sign     SignInfo  `json:"sign"`
+	UUID     string    `json:"uuid"`
+	AuthSign *AuthSign `json:"authSign,omitempty"`
 }
 
 type P2PRingRequest struct {
@@ -412,6 +493,7 @@ type WalletServiceImpl struct {
 	globalMarket    market.GlobalMarket
 	rds             *dao.RdsService
 	oldWethAddress  string
+	depthTracker    *depthDiffTracker
 }
 
 func NewWalletService(trendManager market.TrendManager, orderViewer viewer.OrderViewer, accountManager accountmanager.AccountManager,
@@ -425,6 +507,7 @@ func NewWalletService(trendManager market.TrendManager, orderViewer viewer.Order
 	w.rds = rds
 	w.oldWethAddress = oldWethAddress
 	w.globalMarket = globalMarket
+	w.depthTracker = newDepthDiffTracker()
 	return w
 }
 func (w *WalletServiceImpl) TestPing(input int) (resp []byte, err error) {
@@ -625,14 +708,53 @@ func (w *WalletServiceImpl) SubmitOrder(order *types.OrderJsonRequest) (res stri
 	return HandleInputOrder(types.ToOrder(order))
 }
 
+// SubmitStopOrder submits a market order kept by the relay until the last trade price of its market is greater
+// than or equal to(gte), or less than or equal to(lte) the trigger price.
+func (w *WalletServiceImpl) SubmitStopOrder(req *StopOrderRequest) (res string, err error) {
+	req.Order.OrderType = types.ORDER_TYPE_MARKET
+	return HandleInputStopOrder(types.ToOrder(&req.Order), omtyp.StopCondition(req.Condition), req.TriggerPrice)
+}
+
+// GetStopOrders returns stop orders of the owner, the latest first.
+func (w *WalletServiceImpl) GetStopOrders(query StopOrderQuery) (orders []StopOrderJsonResult, err error) {
+	if !common.IsHexAddress(query.Owner) {
+		return orders, errors.New("owner can't be null")
+	}
+	var statusList []omtyp.StopOrderStatus
+	if len(query.Status) > 0 {
+		status, ok := stopOrderStrToStatus(query.Status)
+		if !ok {
+			return orders, errors.New("status invalid")
+		}
+		statusList = append(statusList, status)
+	}
+
+	list, err := w.orderViewer.GetStopOrders(common.HexToAddress(query.Owner), statusList)
+	if err != nil {
+		return orders, err
+	}
+	orders = make([]StopOrderJsonResult, 0)
+	for _, v := range list {
+		orders = append(orders, stopOrderToJson(v))
+	}
+	return orders, nil
+}
+
+// GetOrders works in page index mode by default, once query.Cursor is supplied(an empty string for the first page)
+// it switches to cursor mode, which doesn't count total and returns nextCursor for the following page.
 func (w *WalletServiceImpl) GetOrders(query *OrderQuery) (res PageResult, err error) {
+	var src dao.PageResult
 	orderQuery, statusList, pi, ps := convertFromQuery(query)
-	src, err := w.orderViewer.GetOrders(orderQuery, statusList, pi, ps)
+	if query.Cursor != nil {
+		src, err = w.orderViewer.GetOrdersByCursor(orderQuery, statusList, *query.Cursor, ps)
+	} else {
+		src, err = w.orderViewer.GetOrders(orderQuery, statusList, pi, ps)
+	}
 	if err != nil {
 		log.Info("query order error : " + err.Error())
 	}
 
-	rst := PageResult{Total: src.Total, PageIndex: src.PageIndex, PageSize: src.PageSize, Data: make([]interface{}, 0)}
+	rst := PageResult{Total: src.Total, PageIndex: src.PageIndex, PageSize: src.PageSize, NextCursor: src.NextCursor, Data: make([]interface{}, 0)}
 
 	for _, d := range src.Data {
 		o := d.(types.OrderState)
@@ -676,6 +798,46 @@ func (w *WalletServiceImpl) GetOrderByHash(query OrderQuery) (order OrderJsonRes
 	}
 }
 
+func (w *WalletServiceImpl) GetOrderHistory(query OrderQuery) (history []OrderHistoryJsonResult, err error) {
+	if len(query.OrderHash) == 0 {
+		return history, errors.New("order hash can't be null")
+	}
+	list, err := w.orderViewer.GetOrderHistory(common.HexToHash(query.OrderHash))
+	if err != nil {
+		return history, err
+	}
+	history = make([]OrderHistoryJsonResult, 0)
+	for _, v := range list {
+		history = append(history, orderHistoryToJson(v))
+	}
+	return history, nil
+}
+
+// GetScheduledOrders returns orders of the owner valid since far in future, which are released when validSince arrives.
+func (w *WalletServiceImpl) GetScheduledOrders(query ScheduledOrderQuery) (orders []ScheduledOrderJsonResult, err error) {
+	if !common.IsHexAddress(query.Owner) {
+		return orders, errors.New("owner can't be null")
+	}
+	var statusList []omtyp.ScheduledOrderStatus
+	if len(query.Status) > 0 {
+		status, ok := scheduledOrderStrToStatus(query.Status)
+		if !ok {
+			return orders, errors.New("status invalid")
+		}
+		statusList = append(statusList, status)
+	}
+
+	list, err := w.orderViewer.GetScheduledOrders(common.HexToAddress(query.Owner), statusList)
+	if err != nil {
+		return orders, err
+	}
+	orders = make([]ScheduledOrderJsonResult, 0)
+	for _, v := range list {
+		orders = append(orders, scheduledOrderToJson(v))
+	}
+	return orders, nil
+}
+
 func (w *WalletServiceImpl) GetOrdersByHashes(query OrderQuery) (order []OrderJsonResult, err error) {
 	if query.OrderHashes == nil || len(query.OrderHashes) == 0 {
 		return order, errors.New("param orderHashes can't be empty")
@@ -783,7 +945,8 @@ func (w *WalletServiceImpl) GetLatestP2POrders(query LatestOrderQuery) (res []Or
 func (w *WalletServiceImpl) GetDepth(query DepthQuery) (res Depth, err error) {
 
 	defaultDepthLength := 100
-	asks, bids, err := w.getInnerOrderBook(query, defaultDepthLength)
+	// orders at the same price are merged, so all orders in the order book are used
+	asks, bids, err := w.getInnerOrderBook(query, 0)
 	if err != nil {
 		return
 	}
@@ -804,6 +967,30 @@ func (w *WalletServiceImpl) GetDepth(query DepthQuery) (res Depth, err error) {
 	return depth, err
 }
 
+// GetDepthSnapshot returns the depth pushed last time together with the sequence of the depth diffs pushed by socketio,
+// it's used to resync when a client finds a gap between sequences.
+func (w *WalletServiceImpl) GetDepthSnapshot(query DepthQuery) (res DepthSnapshot, err error) {
+	if res, ok := w.depthTracker.current(query.DelegateAddress, query.Market); ok {
+		return res, nil
+	}
+
+	depth, err := w.GetDepth(query)
+	if err != nil {
+		return
+	}
+	return w.depthTracker.track(depth), nil
+}
+
+// updateDepthDiff computes the depth of market and returns its diff against the depth tracked last time.
+func (w *WalletServiceImpl) updateDepthDiff(query DepthQuery) (*DepthDiff, error) {
+	depth, err := w.GetDepth(query)
+	if err != nil {
+		return nil, err
+	}
+	diff, _ := w.depthTracker.update(depth)
+	return diff, nil
+}
+
 func (w *WalletServiceImpl) GetUnmergedOrderBook(query DepthQuery) (res OrderBook, err error) {
 
 	defaultDepthLength := 40
@@ -821,7 +1008,8 @@ func (w *WalletServiceImpl) GetUnmergedOrderBook(query DepthQuery) (res OrderBoo
 	return orderBook, err
 }
 
-func (w *WalletServiceImpl) getInnerOrderBook(query DepthQuery, defaultDepthLength int) (asks, bids []types.OrderState, err error) {
+// getInnerOrderBook returns length orders of both sides from the order book in memory, length <= 0 returns all.
+func (w *WalletServiceImpl) getInnerOrderBook(query DepthQuery, length int) (asks, bids []types.OrderState, err error) {
 
 	mkt := strings.ToUpper(query.Market)
 	delegateAddress := query.DelegateAddress
@@ -845,11 +1033,10 @@ func (w *WalletServiceImpl) getInnerOrderBook(query DepthQuery, defaultDepthLeng
 		empty[i] = make([]string, 0)
 	}
 
-	//(TODO) 考虑到需要聚合的情况，所以每次取2倍的数据，先聚合完了再cut, 不是完美方案，后续再优化
 	asks, askErr := w.orderViewer.GetOrderBook(
 		common.HexToAddress(delegateAddress),
 		util.AllTokens[a].Protocol,
-		util.AllTokens[b].Protocol, defaultDepthLength)
+		util.AllTokens[b].Protocol, length)
 
 	if askErr != nil {
 		err = errors.New("get ask order error , please refresh again")
@@ -859,7 +1046,7 @@ func (w *WalletServiceImpl) getInnerOrderBook(query DepthQuery, defaultDepthLeng
 	bids, bidErr := w.orderViewer.GetOrderBook(
 		common.HexToAddress(delegateAddress),
 		util.AllTokens[b].Protocol,
-		util.AllTokens[a].Protocol, defaultDepthLength)
+		util.AllTokens[a].Protocol, length)
 
 	if bidErr != nil {
 		err = errors.New("get bid order error , please refresh again")
@@ -870,13 +1057,24 @@ func (w *WalletServiceImpl) getInnerOrderBook(query DepthQuery, defaultDepthLeng
 }
 
 func (w *WalletServiceImpl) GetFills(query FillQuery) (dao.PageResult, error) {
-	res, err := w.orderViewer.FillsPageQuery(fillQueryToMap(query))
+	var (
+		res dao.PageResult
+		err error
+	)
+	if query.Cursor != nil {
+		fillQuery, _, ps := fillQueryToMap(query)
+		if res, err = w.orderViewer.FillsCursorQuery(fillQuery, *query.Cursor, ps); err == dao.ErrInvalidCursor {
+			return dao.PageResult{}, err
+		}
+	} else {
+		res, err = w.orderViewer.FillsPageQuery(fillQueryToMap(query))
+	}
 
 	if err != nil {
 		return dao.PageResult{}, nil
 	}
 
-	result := dao.PageResult{PageIndex: res.PageIndex, PageSize: res.PageSize, Total: res.Total, Data: make([]interface{}, 0)}
+	result := dao.PageResult{PageIndex: res.PageIndex, PageSize: res.PageSize, Total: res.Total, NextCursor: res.NextCursor, Data: make([]interface{}, 0)}
 
 	for _, f := range res.Data {
 		fill := f.(dao.FillEvent)
@@ -925,6 +1123,10 @@ func (w *WalletServiceImpl) GetTrend(query TrendQuery) (res []market.Trend, err
 }
 
 func (w *WalletServiceImpl) GetRingMined(query RingMinedQuery) (res dao.PageResult, err error) {
+	if query.Cursor != nil {
+		ringMinedQuery, _, ps := ringMinedQueryToMap(query)
+		return w.orderViewer.RingMinedCursorQuery(ringMinedQuery, *query.Cursor, ps)
+	}
 	return w.orderViewer.RingMinedPageQuery(ringMinedQueryToMap(query))
 }
 
@@ -1159,6 +1361,14 @@ func (w *WalletServiceImpl) GetTransactions(query TransactionQuery) (PageResult,
 
 	rst.Data = make([]interface{}, 0)
 	rst.PageIndex, rst.PageSize, limit, offset = pagination(query.PageIndex, query.PageSize)
+	if query.Cursor != nil {
+		rst.PageIndex = 0
+		txs, rst.NextCursor, err = txmanager.GetAllTransactionsByCursor(query.Owner, query.Symbol, query.Status, query.TxType, *query.Cursor, limit)
+		for _, v := range txs {
+			rst.Data = append(rst.Data, v)
+		}
+		return rst, err
+	}
 	rst.Total, err = txmanager.GetAllTransactionCount(query.Owner, query.Symbol, query.Status, query.TxType)
 	if err != nil {
 		return rst, err
@@ -1311,7 +1521,7 @@ func convertStatus(s string) []types.OrderStatus {
 	case "ORDER_PARTIAL":
 		return []types.OrderStatus{types.ORDER_PARTIAL}
 	case "ORDER_FINISHED":
-		return []types.OrderStatus{types.ORDER_FINISHED}
+		return []types.OrderStatus{types.ORDER_FINISHED, omtyp.ORDER_DUST}
 	case "ORDER_CANCELLED":
 		return []types.OrderStatus{types.ORDER_CANCEL, types.ORDER_FLEX_CANCEL, types.ORDER_CUTOFF}
 	case "ORDER_CUTOFF":
@@ -1333,16 +1543,20 @@ func getStringStatus(order types.OrderState) string {
 		return "ORDER_EXPIRE"
 	}
 
-	if order.RawOrder.OrderType == types.ORDER_TYPE_P2P && manager.IsP2PTakerLocked(order.RawOrder.Hash.Hex()) && s != types.ORDER_FINISHED {
+	if order.RawOrder.OrderType == types.ORDER_TYPE_P2P && manager.IsP2PTakerLocked(order.RawOrder.Hash.Hex()) && s != types.ORDER_FINISHED && s != omtyp.ORDER_DUST {
 		return "ORDER_P2P_LOCKED"
 	}
 
+	return orderStatusToStr(s)
+}
+
+func orderStatusToStr(s types.OrderStatus) string {
 	switch s {
 	case types.ORDER_NEW:
 		return "ORDER_OPENED"
 	case types.ORDER_PARTIAL:
 		return "ORDER_OPENED"
-	case types.ORDER_FINISHED:
+	case types.ORDER_FINISHED, omtyp.ORDER_DUST:
 		return "ORDER_FINISHED"
 	case types.ORDER_CANCEL:
 		return "ORDER_CANCELLED"
@@ -1626,40 +1840,57 @@ func (w *WalletServiceImpl) NotifyCirculr(req NotifyCirculrBody) (owner string,
 	return req.Owner, err
 }
 
-func (w *WalletServiceImpl) FlexCancelOrder(req CancelOrderQuery) (rst string, err error) {
+func (w *WalletServiceImpl) FlexCancelOrder(req CancelOrderQuery) (rst FlexCancelOrderRes, err error) {
 
 	isCorrect, err := verifySign(req.Sign)
 	if !isCorrect {
 		return rst, err
 	}
 
-	cancelOrderEvent := types.FlexCancelOrderEvent{}
+	cancelOrderEvent := omtyp.FlexCancelOrderEvent{}
 	cancelOrderEvent.OrderHash = common.HexToHash(req.OrderHash)
+	for _, v := range req.OrderHashes {
+		cancelOrderEvent.OrderHashList = append(cancelOrderEvent.OrderHashList, common.HexToHash(v))
+	}
 	cancelOrderEvent.Owner = common.HexToAddress(req.Sign.Owner)
 	cancelOrderEvent.TokenS = common.HexToAddress(req.TokenS)
 	cancelOrderEvent.TokenB = common.HexToAddress(req.TokenB)
 	cancelOrderEvent.CutoffTime = req.CutoffTime
-	cancelOrderEvent.Type = types.FlexCancelType(req.Type)
-	err = manager.FlexCancelOrder(&cancelOrderEvent)
-	if err == nil {
-		go func() {
+	cancelOrderEvent.Type = omtyp.FlexCancelType(req.Type)
 
-			orderQuery := OrderQuery{Owner: req.Sign.Owner, OrderType: types.ORDER_TYPE_MARKET}
-			if cancelOrderEvent.Type == types.FLEX_CANCEL_BY_HASH {
-				orderQuery.OrderHash = cancelOrderEvent.OrderHash.Hex()
-			} else if cancelOrderEvent.Type == types.FLEX_CANCEL_BY_MARKET {
-				orderQuery.Market, _ = util.WrapMarketByAddress(req.TokenS, req.TokenB)
-			} else {
-				// other conditions will notify all market, not implement now
-			}
-			orderQueryMap, _, _, _ := convertFromQuery(&orderQuery)
-			ot, err := w.orderViewer.GetLatestOrders(orderQueryMap, 1)
-			if err == nil && len(ot) > 0 {
-				kafkaUtil.ProducerSocketIOMessage(kafka.Kafka_Topic_SocketIO_Order_Updated, ot[0])
-			}
-		}()
+	// every cancelled order is pushed to socketio by order manager
+	orderhashList, err := manager.FlexCancelOrder(&cancelOrderEvent)
+	if err != nil {
+		return rst, err
 	}
-	return rst, err
+	rst.OrderHashList = make([]string, 0, len(orderhashList))
+	for _, v := range orderhashList {
+		rst.OrderHashList = append(rst.OrderHashList, v.Hex())
+	}
+	return rst, nil
+}
+
+// LinkOrderGroup links orders into an one-cancels-other group, the first fill of any of them cancels the others.
+func (w *WalletServiceImpl) LinkOrderGroup(req OrderGroupQuery) (rst OrderGroupRes, err error) {
+
+	isCorrect, err := verifySign(req.Sign)
+	if !isCorrect {
+		return rst, err
+	}
+
+	var orderhashList []common.Hash
+	for _, v := range req.OrderHashes {
+		orderhashList = append(orderhashList, common.HexToHash(v))
+	}
+	groupId, err := manager.LinkOrderGroup(common.HexToAddress(req.Sign.Owner), orderhashList)
+	if err != nil {
+		return rst, err
+	}
+	rst.GroupId = groupId.Hex()
+	for _, v := range orderhashList {
+		rst.OrderHashList = append(rst.OrderHashList, v.Hex())
+	}
+	return rst, nil
 }
 
 func (w *WalletServiceImpl) SetOrderTransfer(req OrderTransfer) (hash string, err error) {
@@ -1708,10 +1939,27 @@ func (w *WalletServiceImpl) NotifyScanLogin(req SignedLoginInfo) (rst string, er
 		return req.UUID, err
 	}
 
-	kafkaUtil.ProducerSocketIOMessage(Kafka_Topic_SocketIO_Scan_Login, &LoginInfo{UUID: req.UUID, Owner: req.Sign.Owner})
+	// only a single-use nonce sign of the owner gets the scanned page a session token of private socketio events
+	info := &LoginInfo{UUID: req.UUID, Owner: req.Sign.Owner}
+	if req.AuthSign != nil {
+		if !strings.EqualFold(req.AuthSign.Owner, req.Sign.Owner) {
+			return req.UUID, errors.New("owner of authSign not matched")
+		}
+		if err := verifyAuthSign(*req.AuthSign, AuthPurposeScanLogin); err != nil {
+			return req.UUID, err
+		}
+		if info.Token, err = newSocketIOSession(req.Sign.Owner); err != nil {
+			return req.UUID, err
+		}
+	}
+	kafkaUtil.ProducerSocketIOMessage(Kafka_Topic_SocketIO_Scan_Login, info)
 	return req.UUID, err
 }
 
+func (w *WalletServiceImpl) GetAuthNonce(query AuthNonceQuery) (nonce string, err error) {
+	return newAuthNonce(query.Owner, query.Purpose)
+}
+
 func (w *WalletServiceImpl) GetNonce(owner SingleOwner) (n int64, err error) {
 	nonce, err := txmanager.GetNonce(owner.Owner)
 	if err != nil {
@@ -1789,6 +2037,114 @@ func ringMinedQueryToMap(q RingMinedQuery) (map[string]interface{}, int, int) {
 	return rst, pi, ps
 }
 
+func orderHistoryToJson(src dao.OrderHistory) OrderHistoryJsonResult {
+	rst := OrderHistoryJsonResult{}
+	rst.Status = orderStatusToStr(types.OrderStatus(src.Status))
+	rst.DealtAmountS = amountStrToHex(src.DealtAmountS)
+	rst.DealtAmountB = amountStrToHex(src.DealtAmountB)
+	rst.SplitAmountS = amountStrToHex(src.SplitAmountS)
+	rst.SplitAmountB = amountStrToHex(src.SplitAmountB)
+	rst.CancelledAmountS = amountStrToHex(src.CancelledAmountS)
+	rst.CancelledAmountB = amountStrToHex(src.CancelledAmountB)
+	rst.BlockNumber = src.BlockNumber
+	rst.EventType = src.EventType
+	rst.TxHash = src.TxHash
+	rst.CreateTime = src.CreateTime
+	return rst
+}
+
+func amountStrToHex(amount string) string {
+	v, ok := new(big.Int).SetString(amount, 0)
+	if !ok {
+		v = big.NewInt(0)
+	}
+	return types.BigintToHex(v)
+}
+
+func scheduledOrderToJson(src dao.ScheduledOrder) ScheduledOrderJsonResult {
+	rst := ScheduledOrderJsonResult{}
+	rst.OrderHash = src.OrderHash
+	rst.ValidSince = src.ValidSince
+	rst.ValidUntil = src.ValidUntil
+	rst.Status = scheduledOrderStatusToStr(omtyp.ScheduledOrderStatus(src.Status))
+	rst.Reason = src.Reason
+	rst.CreateTime = src.CreateTime
+	rst.UpdateTime = src.UpdateTime
+
+	state := types.OrderState{}
+	if err := src.ConvertUp(&state.RawOrder); err == nil {
+		rst.Order = orderStateToJson(state)
+	}
+	return rst
+}
+
+var scheduledOrderStatusStr = map[omtyp.ScheduledOrderStatus]string{
+	omtyp.SCHEDULED_ORDER_PENDING:   "SCHEDULED_PENDING",
+	omtyp.SCHEDULED_ORDER_RELEASED:  "SCHEDULED_RELEASED",
+	omtyp.SCHEDULED_ORDER_REJECTED:  "SCHEDULED_REJECTED",
+	omtyp.SCHEDULED_ORDER_EXPIRED:   "SCHEDULED_EXPIRED",
+	omtyp.SCHEDULED_ORDER_CANCELLED: "SCHEDULED_CANCELLED",
+}
+
+func scheduledOrderStatusToStr(s omtyp.ScheduledOrderStatus) string {
+	if str, ok := scheduledOrderStatusStr[s]; ok {
+		return str
+	}
+	return "SCHEDULED_UNKNOWN"
+}
+
+func scheduledOrderStrToStatus(str string) (omtyp.ScheduledOrderStatus, bool) {
+	for k, v := range scheduledOrderStatusStr {
+		if v == str {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func stopOrderToJson(src dao.StopOrder) StopOrderJsonResult {
+	rst := StopOrderJsonResult{}
+	rst.OrderHash = src.OrderHash
+	rst.Market = src.Market
+	rst.Condition = src.Condition
+	rst.TriggerPrice = src.TriggerPrice
+	rst.LastPrice = src.LastPrice
+	rst.Status = stopOrderStatusToStr(omtyp.StopOrderStatus(src.Status))
+	rst.Reason = src.Reason
+	rst.CreateTime = src.CreateTime
+	rst.UpdateTime = src.UpdateTime
+
+	state := types.OrderState{}
+	if err := src.ConvertUp(&state.RawOrder); err == nil {
+		rst.Order = orderStateToJson(state)
+	}
+	return rst
+}
+
+var stopOrderStatusStr = map[omtyp.StopOrderStatus]string{
+	omtyp.STOP_ORDER_PENDING:   "STOP_PENDING",
+	omtyp.STOP_ORDER_TRIGGERED: "STOP_TRIGGERED",
+	omtyp.STOP_ORDER_REJECTED:  "STOP_REJECTED",
+	omtyp.STOP_ORDER_EXPIRED:   "STOP_EXPIRED",
+	omtyp.STOP_ORDER_CANCELLED: "STOP_CANCELLED",
+}
+
+func stopOrderStatusToStr(s omtyp.StopOrderStatus) string {
+	if str, ok := stopOrderStatusStr[s]; ok {
+		return str
+	}
+	return "STOP_UNKNOWN"
+}
+
+func stopOrderStrToStatus(str string) (omtyp.StopOrderStatus, bool) {
+	for k, v := range stopOrderStatusStr {
+		if v == str {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
 func orderStateToJson(src types.OrderState) OrderJsonResult {
 
 	rst := OrderJsonResult{}
@@ -1797,6 +2153,9 @@ func orderStateToJson(src types.OrderState) OrderJsonResult {
 	rst.CancelledAmountB = types.BigintToHex(src.CancelledAmountB)
 	rst.CancelledAmountS = types.BigintToHex(src.CancelledAmountS)
 	rst.Status = getStringStatus(src)
+	if src.Status == omtyp.ORDER_DUST {
+		rst.FinishReason = ORDER_FINISH_REASON_DUST
+	}
 	rawOrder := RawOrderJsonResult{}
 	rawOrder.Protocol = src.RawOrder.Protocol.Hex()
 	rawOrder.DelegateAddress = src.RawOrder.DelegateAddress.Hex()
@@ -1817,8 +2176,6 @@ func orderStateToJson(src types.OrderState) OrderJsonResult {
 	rawOrder.WalletAddress = src.RawOrder.WalletAddress.Hex()
 	rawOrder.AuthAddr = src.RawOrder.AuthAddr.Hex()
 	rawOrder.Market = src.RawOrder.Market
-	auth, _ := src.RawOrder.AuthPrivateKey.MarshalText()
-	rawOrder.AuthPrivateKey = string(auth)
 	rawOrder.CreateTime = src.RawOrder.CreateTime
 	rawOrder.Side = src.RawOrder.Side
 	rawOrder.OrderType = src.RawOrder.OrderType
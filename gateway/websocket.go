@@ -16,39 +16,61 @@
 
 */
 
-// deprecate
 package gateway
 
 import (
-	"fmt"
-	"github.com/Loopring/relay-cluster/accountmanager"
-	"github.com/Loopring/relay-cluster/market"
 	"github.com/Loopring/relay-lib/log"
-	"github.com/Loopring/relay-lib/marketcap"
 	"github.com/gorilla/websocket"
 	"net/http"
+	"sync"
 )
 
+const defaultWebsocketNativePath = "/ws"
+
 type WebsocketService interface {
-	Start(port string)
+	Start()
 	Stop()
 }
 
+// WebsocketOptions is also used by socketio,
+// AnonymousPrivateChannels serves private events without authentication and should only be used while clients migrate.
+// QueueSize is the max messages waiting to be sent to a connection, when it's full the message is dropped
+// or the connection is closed according to SlowConsumerPolicy(drop or disconnect).
+// MaxConnectionsPerIp less than 0 means unlimited, PingInterval and PingTimeout are in seconds.
+// NativePath is the path of the native websocket endpoint served on Port together with socketio.
+// InstanceId identifies the node in the cluster and names its kafka consumer group, it's hostname_port by default.
+// TargetedPush routes balance, transaction and order events only to the nodes holding their owners,
+// it must be enabled on all nodes of the cluster at the same time, and requires InstanceId set and the topic of the node
+// created, see Kafka_Topic_SocketIO_Node_Prefix.
 type WebsocketOptions struct {
-	Port string
+	Port                     string
+	AnonymousPrivateChannels bool
+	QueueSize                int
+	SlowConsumerPolicy       string
+	MaxConnectionsPerIp      int
+	PingInterval             int64
+	PingTimeout              int64
+	NativePath               string
+	InstanceId               string
+	TargetedPush             bool
 }
 
+// WebsocketServiceImpl serves the events of socketio to clients speaking plain json over websocket,
+// the connections are registered in SocketIOServiceImpl and share its subscriptions, auth and limits.
 type WebsocketServiceImpl struct {
-	port     string
-	upgrader websocket.Upgrader
+	path            string
+	upgrader        websocket.Upgrader
+	socketIOService *SocketIOServiceImpl
+	clients         sync.Map
 }
 
-type WebsocketRequest map[string]string
-
-// this code is not stable
-func NewWebsocketService(port string, trendManager market.TrendManager, accountManager accountmanager.AccountManager, capProvider marketcap.MarketCapProvider) *WebsocketServiceImpl {
+func NewWebsocketService(options *WebsocketOptions, socketIOService *SocketIOServiceImpl) *WebsocketServiceImpl {
 	l := &WebsocketServiceImpl{}
-	l.port = port
+	l.path = options.NativePath
+	if len(l.path) == 0 {
+		l.path = defaultWebsocketNativePath
+	}
+	l.socketIOService = socketIOService
 	l.upgrader = websocket.Upgrader{
 		CheckOrigin:     func(r *http.Request) bool { return true },
 		ReadBufferSize:  1024,
@@ -57,31 +79,35 @@ func NewWebsocketService(port string, trendManager market.TrendManager, accountM
 	return l
 }
 
+// Start registers the endpoint, it's served by the http server of socketio.
 func (ws *WebsocketServiceImpl) Start() {
+	http.Handle(ws.path, ws)
+	log.Info("Serving native websocket at path: " + ws.path)
+}
 
-	node := newSocketNode()
-	go node.run()
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		ws.serve(node, w, r)
+// Stop closes all native websocket connections.
+func (ws *WebsocketServiceImpl) Stop() {
+	ws.clients.Range(func(key, value interface{}) bool {
+		value.(*SocketClient).Close()
+		return true
 	})
-
-	err := http.ListenAndServe(":"+ws.port, nil)
-	if err != nil {
-		log.Fatal("ListenAndServe Websocket Error : " + err.Error())
-	}
-
-	return
 }
 
-func (ws *WebsocketServiceImpl) serve(node *SocketNode, w http.ResponseWriter, r *http.Request) {
+func (ws *WebsocketServiceImpl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	conn, err := ws.upgrader.Upgrade(w, r, nil)
-	fmt.Print(conn.LocalAddr())
 	if err != nil {
 		log.Error("get ws connection error , " + err.Error())
 		return
 	}
-	client := &SocketClient{node: node, conn: conn}
-	client.node.register <- client
-	go client.write()
-	go client.read()
+	client := newSocketClient(ws.socketIOService, conn, r)
+	if ws.socketIOService.addConn(client) == nil {
+		client.Close()
+		return
+	}
+	ws.clients.Store(client.ID(), client)
+	go client.ping()
+	go func() {
+		client.read()
+		ws.clients.Delete(client.ID())
+	}()
 }
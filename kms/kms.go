@@ -0,0 +1,164 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package kms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/Loopring/relay-lib/log"
+	"io"
+	"strings"
+)
+
+const (
+	KMS_TYPE_LOCAL = "local"
+
+	sealedPrefix  = "kms:v1:"
+	dataKeyLength = 32
+)
+
+// KeyManager wraps data keys with a master key which never leaves it, a kms of cloud providers can be
+// plugged in by implementing it and adding its type to Initialize.
+type KeyManager interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type KmsOptions struct {
+	Enabled bool
+	Type    string
+	KeyFile string
+}
+
+var keyManager KeyManager
+
+func Initialize(options KmsOptions) error {
+	if !options.Enabled {
+		log.Infof("kms is not enabled, secrets are saved in plaintext")
+		keyManager = nil
+		return nil
+	}
+
+	switch options.Type {
+	case KMS_TYPE_LOCAL:
+		km, err := NewLocalKeyManager(options.KeyFile)
+		if err != nil {
+			return err
+		}
+		keyManager = km
+	default:
+		return fmt.Errorf("kms type:%s not supported", options.Type)
+	}
+	return nil
+}
+
+func IsEnabled() bool {
+	return keyManager != nil
+}
+
+func IsSealed(data string) bool {
+	return strings.HasPrefix(data, sealedPrefix)
+}
+
+// Seal encrypts plaintext with a new data key by envelope encryption, the data key wrapped by the key manager
+// is kept with the ciphertext as "kms:v1:<wrapped data key>:<ciphertext>".
+func Seal(plaintext []byte) (string, error) {
+	if keyManager == nil {
+		return "", fmt.Errorf("kms is not enabled")
+	}
+
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	wrapped, err := keyManager.Encrypt(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := aesGcmEncrypt(dataKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts data sealed by Seal.
+func Open(sealed string) ([]byte, error) {
+	if keyManager == nil {
+		return nil, fmt.Errorf("kms is not enabled")
+	}
+	if !IsSealed(sealed) {
+		return nil, fmt.Errorf("data isn't sealed")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("sealed data invalid")
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := keyManager.Decrypt(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return aesGcmDecrypt(dataKey, ciphertext)
+}
+
+// aesGcmEncrypt returns nonce and ciphertext with tag.
+func aesGcmEncrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func aesGcmDecrypt(key, data []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+func newAesGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
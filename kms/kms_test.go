@@ -0,0 +1,120 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeKeyFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSealOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { keyManager = nil }()
+
+	masterKey := bytes.Repeat([]byte{0x11}, dataKeyLength)
+	keyFile := writeKeyFile(t, dir, "master", "0x"+hex.EncodeToString(masterKey)+"\n")
+	if err := Initialize(KmsOptions{Enabled: true, Type: KMS_TYPE_LOCAL, KeyFile: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsEnabled() {
+		t.Fatal("kms should be enabled")
+	}
+
+	for _, plaintext := range [][]byte{[]byte("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"), {}} {
+		sealed, err := Seal(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsSealed(sealed) {
+			t.Fatalf("sealed:%s should have prefix %s", sealed, sealedPrefix)
+		}
+		opened, err := Open(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("opened:%x, want %x", opened, plaintext)
+		}
+
+		// a new data key and nonce are used every time
+		if again, _ := Seal(plaintext); again == sealed {
+			t.Errorf("sealed twice to the same data:%s", sealed)
+		}
+	}
+
+	sealed, _ := Seal([]byte("secret"))
+	parts := strings.Split(strings.TrimPrefix(sealed, sealedPrefix), ":")
+	ciphertext, _ := base64.RawStdEncoding.DecodeString(parts[1])
+	ciphertext[len(ciphertext)-1] ^= 0x01
+	tampered := sealedPrefix + parts[0] + ":" + base64.RawStdEncoding.EncodeToString(ciphertext)
+	for _, data := range []string{tampered, "secret", sealedPrefix + parts[0], sealedPrefix + "!:" + parts[1]} {
+		if _, err := Open(data); err == nil {
+			t.Errorf("data:%s should fail to open", data)
+		}
+	}
+
+	// data sealed by another master key
+	otherKeyFile := writeKeyFile(t, dir, "other", hex.EncodeToString(bytes.Repeat([]byte{0x22}, dataKeyLength)))
+	if err := Initialize(KmsOptions{Enabled: true, Type: KMS_TYPE_LOCAL, KeyFile: otherKeyFile}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(sealed); err == nil {
+		t.Error("data sealed by another master key should fail to open")
+	}
+}
+
+func TestNewLocalKeyManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, content := range []string{"not hex", hex.EncodeToString(make([]byte, 16))} {
+		if _, err := NewLocalKeyManager(writeKeyFile(t, dir, "key", content)); err == nil {
+			t.Errorf("key file of %q should be invalid", content)
+		}
+	}
+	for _, keyFile := range []string{"", filepath.Join(dir, "missing")} {
+		if _, err := NewLocalKeyManager(keyFile); err == nil {
+			t.Errorf("key file:%q should be invalid", keyFile)
+		}
+	}
+	if err := Initialize(KmsOptions{Enabled: true, Type: "cloud"}); err == nil {
+		t.Error("kms type cloud should be unsupported")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package kms
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// LocalKeyManager wraps data keys with a 256 bits master key read from a local file in hex, the file should
+// be readable by the relay only.
+type LocalKeyManager struct {
+	masterKey []byte
+}
+
+func NewLocalKeyManager(keyFile string) (*LocalKeyManager, error) {
+	if len(keyFile) == 0 {
+		return nil, fmt.Errorf("kms key file is empty")
+	}
+
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("kms key file:%s invalid, %s", keyFile, err.Error())
+	}
+	if len(key) != dataKeyLength {
+		return nil, fmt.Errorf("kms key file:%s invalid, master key should be %d bytes", keyFile, dataKeyLength)
+	}
+
+	return &LocalKeyManager{masterKey: key}, nil
+}
+
+func (km *LocalKeyManager) Encrypt(plaintext []byte) ([]byte, error) {
+	return aesGcmEncrypt(km.masterKey, plaintext)
+}
+
+func (km *LocalKeyManager) Decrypt(ciphertext []byte) ([]byte, error) {
+	return aesGcmDecrypt(km.masterKey, ciphertext)
+}
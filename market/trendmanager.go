@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Loopring/relay-cluster/dao"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
 	socketioUtil "github.com/Loopring/relay-cluster/util"
 	redisCache "github.com/Loopring/relay-lib/cache"
 	"github.com/Loopring/relay-lib/eventemitter"
@@ -140,6 +141,8 @@ func NewTrendManager(dao *dao.RdsService) TrendManager {
 		fillOrderWatcher := &eventemitter.Watcher{Concurrent: false, Handle: trendManager.HandleOrderFilled}
 		eventemitter.On(eventemitter.OrderFilled, fillOrderWatcher)
 
+		forkWatcher := &eventemitter.Watcher{Concurrent: false, Handle: trendManager.HandleForkRolledBack}
+		eventemitter.On(omtyp.ForkRolledBack, forkWatcher)
 	})
 
 	return trendManager
@@ -1088,6 +1091,69 @@ func (t *TrendManager) HandleOrderFilled(input eventemitter.EventData) (err erro
 	return
 }
 
+// HandleForkRolledBack recalculates the finished trends containing fills rolled back by a fork,
+// then reloads trends and tickers of cache which are calculated from fills not forked.
+func (t *TrendManager) HandleForkRolledBack(input eventemitter.EventData) error {
+	event := input.(*omtyp.ForkRolledBackEvent)
+	if len(event.Fills) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	firstSecondThisHour := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 1, 0, now.Location())
+	intervals := []string{OneHour, TwoHour, FourHour, OneDay, OneWeek}
+
+	markets := make(map[string]bool)
+	for _, fill := range event.Fills {
+		markets[fill.Market] = true
+	}
+	for mkt := range markets {
+		// trends of longer intervals are aggregated from 1Hr trends, so they are recalculated in order
+		for _, interval := range intervals {
+			tsInterval := getTsInterval(interval)
+			starts := make(map[int64]bool)
+			for _, fill := range event.Fills {
+				start := (fill.BlockTime/tsInterval)*tsInterval + 1
+				// trend of the current period isn't saved yet
+				if fill.Market != mkt || start+tsInterval-1 >= firstSecondThisHour.Unix() {
+					continue
+				}
+				starts[start] = true
+			}
+
+			for start := range starts {
+				var err error
+				if interval == OneHour {
+					err = t.insertMinIntervalTrend(OneHour, start, mkt)
+				} else {
+					err = t.insertByTrendV2(interval, start, mkt)
+				}
+				if err != nil {
+					log.Errorf("recalculate trend of fork failed, %s, %s, %d, %s", mkt, interval, start, err.Error())
+				}
+			}
+		}
+	}
+
+	t.refreshMinIntervalCache()
+	for _, interval := range intervals[1:] {
+		t.refreshCacheByInterval(interval)
+	}
+
+	for mkt := range markets {
+		if err := socketioUtil.ProducerSocketIOMessage(kafka.Kafka_Topic_SocketIO_Loopring_Ticker_Updated, &TrendUpdateMsg{Market: mkt}); err != nil {
+			log.Error("send ticker update message failed")
+		}
+		for _, interval := range intervals {
+			if err := socketioUtil.ProducerSocketIOMessage(kafka.Kafka_Topic_SocketIO_Trends_Updated, &TrendUpdateMsg{Market: mkt, Interval: interval}); err != nil {
+				log.Error("send trends update message failed")
+			}
+		}
+	}
+
+	return nil
+}
+
 func (t *TrendManager) reCalTicker(market string) {
 	//trendInCache, _ := t.c.Get(trendKeyPre + strings.ToLower(OneHour))
 	trendInCache, _ := redisCache.Get(buildTrendKey(OneHour, market))
@@ -25,6 +25,7 @@ import (
 
 	"github.com/Loopring/relay-cluster/accountmanager"
 	"github.com/Loopring/relay-cluster/gateway"
+	"github.com/Loopring/relay-cluster/kms"
 	"github.com/Loopring/relay-cluster/market"
 	ordermanager "github.com/Loopring/relay-cluster/ordermanager/common"
 	"github.com/Loopring/relay-cluster/usermanager"
@@ -81,9 +82,11 @@ type GlobalConfig struct {
 	MotanServer      motan.MotanServerOptions
 	Jsonrpc          gateway.JsonrpcOptions
 	Websocket        gateway.WebsocketOptions
+	Grpc             gateway.GrpcOptions
 	AccountManager   accountmanager.AccountManagerOptions
 	MyToken          market.MyTokenConfig
 	CloudWatch       cloudwatch.CloudWatchConfig
+	Kms              kms.KmsOptions
 }
 
 func Validator(cv reflect.Value) (bool, error) {
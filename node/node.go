@@ -25,6 +25,7 @@ import (
 	"github.com/Loopring/relay-cluster/accountmanager"
 	"github.com/Loopring/relay-cluster/dao"
 	"github.com/Loopring/relay-cluster/gateway"
+	"github.com/Loopring/relay-cluster/kms"
 	"github.com/Loopring/relay-cluster/market"
 	ordermanager "github.com/Loopring/relay-cluster/ordermanager/manager"
 	orderviewer "github.com/Loopring/relay-cluster/ordermanager/viewer"
@@ -61,11 +62,12 @@ type Node struct {
 	tickerCollector   market.CollectorImpl
 	globalMarket      market.GlobalMarket
 	jsonRpcService    gateway.JsonrpcServiceImpl
-	websocketService  gateway.WebsocketServiceImpl
-	socketIOService   gateway.SocketIOServiceImpl
+	websocketService  *gateway.WebsocketServiceImpl
+	socketIOService   *gateway.SocketIOServiceImpl
 	walletService     gateway.WalletServiceImpl
 	txManager         txmanager.TransactionManager
 	motanService      *gateway.MotanService
+	grpcService       *gateway.GrpcServiceImpl
 
 	wg     *sync.WaitGroup
 	logger *zap.Logger
@@ -81,6 +83,8 @@ func NewNode(logger *zap.Logger, globalConfig *GlobalConfig) *Node {
 	n.registerSocketIOProducer()
 	n.registerSnsNotifier()
 
+	// auth private keys of orders are sealed by kms while saving
+	n.registerKms()
 	n.registerMysql()
 	n.registerCache()
 
@@ -89,6 +93,8 @@ func NewNode(logger *zap.Logger, globalConfig *GlobalConfig) *Node {
 	n.registerAccessor()
 	n.registerUserManager()
 
+	// stop orders are triggered by tickers of trend manager
+	n.registerTrendManager()
 	n.registerOrderManager()
 	n.registerOrderViewer()
 
@@ -99,13 +105,13 @@ func NewNode(logger *zap.Logger, globalConfig *GlobalConfig) *Node {
 	n.registerTransactionManager()
 	n.registerTransactionViewer()
 
-	n.registerTrendManager()
 	n.registerTickerCollector()
 	n.registerGlobalMarket()
 	n.registerWalletService()
 	n.registerJsonRpcService()
-	n.registerWebsocketService()
 	n.registerSocketIOService()
+	n.registerWebsocketService()
+	n.registerGrpcService()
 
 	n.registerExtractor()
 	n.registerCloudWatch()
@@ -123,8 +129,11 @@ func (n *Node) Start() {
 	n.tickerCollector.Start()
 	n.globalMarket.Start()
 	go n.jsonRpcService.Start()
-	//n.websocketService.Start()
+	n.websocketService.Start()
 	go n.socketIOService.Start()
+	if n.grpcService != nil {
+		go n.grpcService.Start()
+	}
 	gateway.StartMotanService(n.globalConfig.MotanServer, n.accountManager, n.orderViewer)
 
 	n.wg.Add(1)
@@ -138,6 +147,10 @@ func (n *Node) Wait() {
 func (n *Node) Stop() {
 	n.orderManager.Stop()
 	n.txManager.Stop()
+	n.websocketService.Stop()
+	if n.grpcService != nil {
+		n.grpcService.Stop()
+	}
 	n.wg.Done()
 }
 
@@ -167,7 +180,7 @@ func (n *Node) registerAccessor() {
 //}
 
 func (n *Node) registerOrderManager() {
-	n.orderManager = ordermanager.NewOrderManager(&n.globalConfig.OrderManager, n.rdsService, n.marketCapProvider, n.globalConfig.Kafka.Brokers)
+	n.orderManager = ordermanager.NewOrderManager(&n.globalConfig.OrderManager, n.rdsService, n.marketCapProvider, &n.trendManager, n.globalConfig.Kafka.Brokers)
 }
 
 func (n *Node) registerOrderViewer() {
@@ -204,15 +217,24 @@ func (n *Node) registerWalletService() {
 }
 
 func (n *Node) registerJsonRpcService() {
-	n.jsonRpcService = *gateway.NewJsonrpcService(n.globalConfig.Jsonrpc.Port, &n.walletService)
+	if len(n.globalConfig.Jsonrpc.EthProxy.Nodes) == 0 {
+		n.globalConfig.Jsonrpc.EthProxy.Nodes = n.globalConfig.Accessor.RawUrls
+	}
+	n.jsonRpcService = *gateway.NewJsonrpcService(&n.globalConfig.Jsonrpc, &n.walletService)
 }
 
 func (n *Node) registerWebsocketService() {
-	n.websocketService = *gateway.NewWebsocketService(n.globalConfig.Websocket.Port, n.trendManager, n.accountManager, n.marketCapProvider)
+	n.websocketService = gateway.NewWebsocketService(&n.globalConfig.Websocket, n.socketIOService)
 }
 
 func (n *Node) registerSocketIOService() {
-	n.socketIOService = *gateway.NewSocketIOService(n.globalConfig.Websocket.Port, n.walletService, n.globalConfig.Kafka.Brokers)
+	n.socketIOService = gateway.NewSocketIOService(&n.globalConfig.Websocket, n.walletService, n.globalConfig.Kafka.Brokers)
+}
+
+func (n *Node) registerGrpcService() {
+	if n.globalConfig.Grpc.Enable {
+		n.grpcService = gateway.NewGrpcService(&n.globalConfig.Grpc, &n.walletService, n.globalConfig.Kafka.Brokers, n.socketIOService.InstanceId())
+	}
 }
 
 func (n *Node) registerGateway() {
@@ -254,3 +276,9 @@ func (n *Node) registerExtractor() {
 func (n *Node) registerCloudWatch() {
 	cloudwatch.Initialize(n.globalConfig.CloudWatch)
 }
+
+func (n *Node) registerKms() {
+	if err := kms.Initialize(n.globalConfig.Kms); err != nil {
+		log.Fatalf("node start, register kms error:%s", err.Error())
+	}
+}
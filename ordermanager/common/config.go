@@ -1,6 +1,47 @@
 package common
 
+// ExpirySweepInterval, ReconcileInterval, ScheduleInterval, StopOrderTriggerInterval and DustSweepInterval are
+// in seconds, the job is disabled when it's less than 0.
+// AdminListen is the address of the admin api, it's disabled when empty.
+// OrderBookSize is the max orders of a side kept in memory, OrderBookResyncInterval is in seconds.
+// ScheduleMaxRetries is the max failed releases of a scheduled order before it's rejected.
+// EventShards is the number of workers processing order events in parallel, EventQueueSize is the capacity of each.
 type OrderManagerOptions struct {
-	CutoffCacheExpireTime int64
-	CutoffCacheCleanTime  int64
+	CutoffCacheExpireTime    int64
+	CutoffCacheCleanTime     int64
+	ExpirySweepInterval      int64
+	ExpirySweepBatchSize     int
+	ReconcileInterval        int64
+	ReconcileBatchSize       int
+	ReconcileConfirmations   int64
+	PauseBufferSize          int
+	AdminListen              string
+	EventShards              int
+	EventQueueSize           int
+	OrderBookSize            int
+	OrderBookResyncInterval  int64
+	MinerPolicies            []MinerPolicyOptions
+	ScheduleInterval         int64
+	ScheduleBatchSize        int
+	ScheduleMaxRetries       int
+	StopOrderTriggerInterval int64
+	DustSweepInterval        int64
+	DustSweepBatchSize       int
+}
+
+// MinerPolicyOptions ranks the orders provided to miners of Market, the policy without Market is the default.
+// Score of an order is the sum of PriceWeight*price, LrcFeeWeight*(lrcFee value/order value) and AgeWeight*age,
+// each is normalized to [0,1] among the candidates, orders of the same score are in price-time priority.
+// CandidateFactor times of the orders requested are ranked, MaxOrdersPerOwner limits the orders of an owner
+// in a batch and 0 means unlimited. With CheckBalance, orders whose available balance/allowance of tokenS
+// is zero or less than MinAvailableRatio of the remained amount are excluded.
+type MinerPolicyOptions struct {
+	Market            string
+	PriceWeight       float64
+	LrcFeeWeight      float64
+	AgeWeight         float64
+	CandidateFactor   int
+	MaxOrdersPerOwner int
+	CheckBalance      bool
+	MinAvailableRatio float64
 }
@@ -18,7 +18,10 @@
 
 package common
 
-import "github.com/Loopring/relay-lib/types"
+import (
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/types"
+)
 
 var PendingStatus = []types.OrderStatus{
 	types.ORDER_PENDING,
@@ -43,6 +46,39 @@ var ValidFlexCancelStatus = []types.OrderStatus{
 	types.ORDER_PENDING,
 }
 
+// 过期订单由sweeper置为ORDER_EXPIRE, pending的订单等待环路结果
+var ValidExpireStatus = []types.OrderStatus{
+	types.ORDER_NEW,
+	types.ORDER_PARTIAL,
+}
+
+// 对账只处理有效订单, pending的订单等待环路结果
+var ValidReconcileStatus = []types.OrderStatus{
+	types.ORDER_NEW,
+	types.ORDER_PARTIAL,
+}
+
+// 只有部分成交的订单会因价格变化成为dust, 由sweeper置为ORDER_DUST
+var ValidDustStatus = []types.OrderStatus{
+	types.ORDER_PARTIAL,
+}
+
+// 订单簿只展示可撮合的订单, 与dao.GetOrderBook的查询条件一致
+var ValidOrderBookStatus = []types.OrderStatus{
+	types.ORDER_NEW,
+	types.ORDER_PARTIAL,
+	types.ORDER_PENDING,
+}
+
+func IsValidOrderBookStatus(status types.OrderStatus) bool {
+	for _, v := range ValidOrderBookStatus {
+		if v == status {
+			return true
+		}
+	}
+	return false
+}
+
 var ValidMinerStatus = []types.OrderStatus{
 	types.ORDER_NEW,
 	types.ORDER_PARTIAL,
@@ -63,6 +99,7 @@ var InvalidFillStatus = []types.OrderStatus{
 	types.ORDER_UNKNOWN,
 	types.ORDER_CUTOFF,
 	types.ORDER_FINISHED,
+	omtyp.ORDER_DUST,
 }
 
 func IsInvalidFillStatus(status types.OrderStatus) bool {
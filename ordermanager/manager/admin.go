@@ -0,0 +1,135 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"github.com/Loopring/relay-lib/log"
+	"net/http"
+	"sync"
+)
+
+const (
+	adminPathStatus = "/ordermanager/status"
+	adminPathPause  = "/ordermanager/pause"
+	adminPathResume = "/ordermanager/resume"
+
+	adminReasonDefault = "admin"
+)
+
+// adminResult is the response of every admin api, Error is empty on success.
+type adminResult struct {
+	Status LifecycleStatus `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// adminServer serves the lifecycle of the order manager over http, it should only listen on a private address:
+//
+//	GET  /ordermanager/status
+//	POST /ordermanager/pause?reason=xxx
+//	POST /ordermanager/resume?resynced=true
+//
+// resynced is required to resume after events were dropped while paused, it confirms orders are resynced.
+type adminServer struct {
+	listen string
+	om     OrderManager
+	mtx    sync.Mutex
+	server *http.Server
+}
+
+func newAdminServer(listen string, om OrderManager) *adminServer {
+	return &adminServer{listen: listen, om: om}
+}
+
+func (a *adminServer) start() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if len(a.listen) == 0 || a.server != nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(adminPathStatus, a.handleStatus)
+	mux.HandleFunc(adminPathPause, a.handlePause)
+	mux.HandleFunc(adminPathResume, a.handleResume)
+	a.server = &http.Server{Addr: a.listen, Handler: mux}
+
+	go func(server *http.Server) {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("order manager admin, listen on %s error:%s", a.listen, err.Error())
+		}
+	}(a.server)
+	log.Infof("order manager admin opened on %s", a.listen)
+}
+
+func (a *adminServer) stop() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.server != nil {
+		a.server.Close()
+		a.server = nil
+	}
+}
+
+func (a *adminServer) handleStatus(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		a.write(writer, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	a.write(writer, http.StatusOK, "")
+}
+
+func (a *adminServer) handlePause(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		a.write(writer, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	reason := req.URL.Query().Get("reason")
+	if len(reason) == 0 {
+		reason = adminReasonDefault
+	}
+	if !a.om.Pause(reason) {
+		a.write(writer, http.StatusConflict, "order manager isn't running")
+		return
+	}
+	a.write(writer, http.StatusOK, "")
+}
+
+func (a *adminServer) handleResume(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		a.write(writer, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := a.om.Resume(req.URL.Query().Get("resynced") == "true"); err != nil {
+		a.write(writer, http.StatusConflict, err.Error())
+		return
+	}
+	a.write(writer, http.StatusOK, "")
+}
+
+func (a *adminServer) write(writer http.ResponseWriter, status int, message string) {
+	res := adminResult{Status: a.om.Status(), Error: message}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if data, err := json.Marshal(res); nil == err {
+		writer.Write(data)
+	}
+}
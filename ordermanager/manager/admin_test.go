@@ -0,0 +1,102 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// lifecycleOrderManager is an order manager whose events are never processed, only its lifecycle is served.
+type lifecycleOrderManager struct {
+	l *lifecycle
+}
+
+func (om *lifecycleOrderManager) Start()                  { om.l.start() }
+func (om *lifecycleOrderManager) Stop()                   { om.l.stop() }
+func (om *lifecycleOrderManager) Status() LifecycleStatus { return om.l.current() }
+func (om *lifecycleOrderManager) Pause(reason string) bool {
+	return om.l.pause(reason)
+}
+func (om *lifecycleOrderManager) Resume(resynced bool) error {
+	return om.l.resume(resynced)
+}
+
+func TestAdminServer(t *testing.T) {
+	om := &lifecycleOrderManager{l: newLifecycle(1, func() {}, func() {})}
+	a := newAdminServer("", om)
+	mux := http.NewServeMux()
+	mux.HandleFunc(adminPathStatus, a.handleStatus)
+	mux.HandleFunc(adminPathPause, a.handlePause)
+	mux.HandleFunc(adminPathResume, a.handleResume)
+	handle := om.l.dispatch(func(input eventemitter.EventData) error { return nil })
+
+	cases := []struct {
+		name   string
+		before func()
+		method string
+		url    string
+		code   int
+		state  LifecycleState
+		reason string
+	}{
+		{"status", nil, http.MethodGet, adminPathStatus, http.StatusOK, LIFECYCLE_STOPPED, LIFECYCLE_REASON_STOP},
+		{"pause stopped", nil, http.MethodPost, adminPathPause, http.StatusConflict, LIFECYCLE_STOPPED, LIFECYCLE_REASON_STOP},
+		{"pause by get", om.Start, http.MethodGet, adminPathPause, http.StatusMethodNotAllowed, LIFECYCLE_RUNNING, LIFECYCLE_REASON_START},
+		{"pause", nil, http.MethodPost, adminPathPause + "?reason=maintain", http.StatusOK, LIFECYCLE_PAUSED, "maintain"},
+		{"pause paused", nil, http.MethodPost, adminPathPause, http.StatusConflict, LIFECYCLE_PAUSED, "maintain"},
+		{"status by post", nil, http.MethodPost, adminPathStatus, http.StatusMethodNotAllowed, LIFECYCLE_PAUSED, "maintain"},
+		{"resume by get", nil, http.MethodGet, adminPathResume, http.StatusMethodNotAllowed, LIFECYCLE_PAUSED, "maintain"},
+		{"resume after dropped", func() {
+			handle(filledAt(1))
+			handle(filledAt(2))
+		}, http.MethodPost, adminPathResume, http.StatusConflict, LIFECYCLE_PAUSED, "maintain"},
+		{"resume resynced", nil, http.MethodPost, adminPathResume + "?resynced=true", http.StatusOK, LIFECYCLE_DRAINING, LIFECYCLE_REASON_RESUME},
+		{"pause default reason", func() { waitLifecycleState(t, om.l, LIFECYCLE_RUNNING) }, http.MethodPost, adminPathPause, http.StatusOK, LIFECYCLE_PAUSED, adminReasonDefault},
+	}
+
+	for _, c := range cases {
+		if c.before != nil {
+			c.before()
+		}
+		writer := httptest.NewRecorder()
+		mux.ServeHTTP(writer, httptest.NewRequest(c.method, c.url, nil))
+		if writer.Code != c.code {
+			t.Fatalf("%s: expected code %d, got %d, %s", c.name, c.code, writer.Code, writer.Body.String())
+		}
+
+		res := adminResult{}
+		if err := json.Unmarshal(writer.Body.Bytes(), &res); err != nil {
+			t.Fatalf("%s: %s", c.name, err.Error())
+		}
+		if (c.code == http.StatusOK) != (res.Error == "") {
+			t.Fatalf("%s: unexpected error:%s", c.name, res.Error)
+		}
+		// the replay of resume may finish before the response
+		if res.Status.State != c.state && !(c.state == LIFECYCLE_DRAINING && res.Status.State == LIFECYCLE_RUNNING) {
+			t.Fatalf("%s: expected state %s, got %s", c.name, c.state, res.Status.State)
+		}
+		if res.Status.Reason != c.reason {
+			t.Fatalf("%s: expected reason %s, got %s", c.name, c.reason, res.Status.Reason)
+		}
+	}
+}
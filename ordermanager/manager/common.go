@@ -54,6 +54,11 @@ func NewOrderEntity(state *types.OrderState, blockNumber *big.Int) (*dao.Order,
 }
 
 func SettleOrderAmountOnChain(state *types.OrderState) error {
+	return settleOrderAmountAtBlock(state, "latest")
+}
+
+// settleOrderAmountAtBlock sets dealt and cancelled amounts of state by the contract at blockNumStr.
+func settleOrderAmountAtBlock(state *types.OrderState, blockNumStr string) error {
 	var (
 		cancelled, cancelOrFilled, dealt *big.Int
 		err                              error
@@ -63,12 +68,12 @@ func SettleOrderAmountOnChain(state *types.OrderState) error {
 	orderhash := state.RawOrder.Hash
 
 	// get order cancelled amount from chain
-	if cancelled, err = loopringaccessor.GetCancelled(protocol, orderhash, "latest"); err != nil {
+	if cancelled, err = loopringaccessor.GetCancelled(protocol, orderhash, blockNumStr); err != nil {
 		return fmt.Errorf("order manager,handle gateway order,order %s getCancelled error:%s", orderhash.Hex(), err.Error())
 	}
 
 	// get order cancelledOrFilled amount from chain
-	if cancelOrFilled, err = loopringaccessor.GetCancelledOrFilled(protocol, orderhash, "latest"); err != nil {
+	if cancelOrFilled, err = loopringaccessor.GetCancelledOrFilled(protocol, orderhash, blockNumStr); err != nil {
 		return fmt.Errorf("order manager,handle gateway order,order %s getCancelledOrFilled error:%s", orderhash.Hex(), err.Error())
 	}
 
@@ -0,0 +1,76 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/sns"
+	"github.com/Loopring/relay-lib/zklock"
+	"github.com/robfig/cron"
+	"sync"
+	"sync/atomic"
+)
+
+// zkCronJob calls run every interval seconds on the node holding the zklock named lockName, a negative interval
+// disables the job. It's paused while the order manager is stopped, eg. processing a chain fork, and run should
+// check isPaused between batches.
+type zkCronJob struct {
+	name     string
+	lockName string
+	interval int64
+	run      func()
+
+	cron   *cron.Cron
+	once   sync.Once
+	paused int32
+}
+
+// start runs the job after the zklock is held, it only resumes the job when called again.
+func (j *zkCronJob) start() {
+	atomic.StoreInt32(&j.paused, 0)
+	if j.interval < 0 {
+		return
+	}
+
+	j.once.Do(func() {
+		go func() {
+			if err := zklock.TryLock(j.lockName); err != nil {
+				log.Errorf("order manager, %s try lock error:%s", j.name, err.Error())
+				tryLockError := fmt.Sprintf("order manager %s try lock failed", j.name)
+				if err := sns.PublishSns(tryLockError, tryLockError); err != nil {
+					log.Error(err.Error())
+				}
+				return
+			}
+			j.cron = cron.New()
+			j.cron.AddFunc(fmt.Sprintf("@every %ds", j.interval), j.run)
+			j.cron.Start()
+			log.Infof("order manager, %s started, interval:%ds", j.name, j.interval)
+		}()
+	})
+}
+
+func (j *zkCronJob) stop() {
+	atomic.StoreInt32(&j.paused, 1)
+}
+
+func (j *zkCronJob) isPaused() bool {
+	return atomic.LoadInt32(&j.paused) == 1
+}
@@ -0,0 +1,193 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"github.com/Loopring/relay-cluster/dao"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	notify "github.com/Loopring/relay-cluster/util"
+	"github.com/Loopring/relay-lib/cloudwatch"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/sns"
+	"github.com/Loopring/relay-lib/types"
+	"math/big"
+	"time"
+)
+
+const (
+	dustSweeperZkLock      = "orderManagerDustSweeperZkLock"
+	dustSweeperReportTitle = "order manager dust sweeper retired orders"
+
+	defaultDustSweepInterval  = 300
+	defaultDustSweepBatchSize = 500
+
+	metricOrderDustRetired = "order_dust_retired"
+)
+
+// dustMarketReport is the remained value of orders retired in a market, in the legal currency of marketcap.
+type dustMarketReport struct {
+	Orders int    `json:"orders"`
+	Value  string `json:"value"`
+
+	value *big.Rat
+}
+
+// dustReport is the result of a single sweep, it's logged after every run and published by sns when any order is retired,
+// markets with orders retired put a heartbeat of metricOrderDustRetired suffixed by the market once a sweep.
+type dustReport struct {
+	StartTime int64                        `json:"startTime"`
+	EndTime   int64                        `json:"endTime"`
+	Scanned   int                          `json:"scanned"`
+	Failed    int                          `json:"failed"`
+	Retired   int                          `json:"retired"`
+	Markets   map[string]*dustMarketReport `json:"markets"`
+}
+
+// dustSweeper re-evaluates partially filled orders with current prices and sets those whose remained value became
+// dust to ORDER_DUST, which is finished as ORDER_FINISHED set by settlement after fills, but keeps the reason on the
+// order. Orders retired are recorded in history as "dust" as well. Only the node holding the zklock sweeps.
+type dustSweeper struct {
+	*zkCronJob
+	batchSize int
+}
+
+func newDustSweeper(options *omcm.OrderManagerOptions) *dustSweeper {
+	s := &dustSweeper{batchSize: options.DustSweepBatchSize}
+	s.zkCronJob = &zkCronJob{name: "dust sweeper", lockName: dustSweeperZkLock, interval: options.DustSweepInterval, run: s.sweep}
+	if s.interval == 0 {
+		s.interval = defaultDustSweepInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultDustSweepBatchSize
+	}
+	return s
+}
+
+func (s *dustSweeper) sweep() {
+	if s.isPaused() {
+		return
+	}
+
+	report := &dustReport{StartTime: time.Now().Unix(), Markets: make(map[string]*dustMarketReport)}
+	owners := make(map[types.BalanceUpdateEvent]bool)
+	cursor := 0
+	for !s.isPaused() {
+		orders, err := rds.GetOrdersAfterId(cursor, omcm.ValidDustStatus, s.batchSize)
+		if err != nil {
+			log.Errorf("order manager, dust sweeper get orders error:%s", err.Error())
+			break
+		}
+
+		for i := range orders {
+			report.Scanned++
+			state, value, err := retireDustOrder(&orders[i])
+			if err != nil {
+				report.Failed++
+				log.Errorf("order manager, dust sweeper retire order:%s error:%s", orders[i].OrderHash, err.Error())
+				continue
+			}
+			if state == nil {
+				continue
+			}
+
+			report.add(state.RawOrder.Market, value)
+			owners[types.BalanceUpdateEvent{DelegateAddress: orders[i].DelegateAddress, Owner: orders[i].Owner}] = true
+		}
+		if len(orders) < s.batchSize {
+			break
+		}
+		cursor = orders[len(orders)-1].ID
+	}
+
+	// frozen amounts of owners changed
+	for event := range owners {
+		evt := event
+		notify.NotifyAccountBalanceUpdate(&evt)
+	}
+
+	report.EndTime = time.Now().Unix()
+	report.emit()
+}
+
+// retireDustOrder finishes the order if its remained value is dust, it returns nil state if the order isn't dust,
+// its value is unknown or it's updated since read.
+func retireDustOrder(model *dao.Order) (*types.OrderState, *big.Rat, error) {
+	state := &types.OrderState{}
+	if err := model.ConvertUp(state); err != nil {
+		return nil, nil, err
+	}
+
+	value, err := remainedOrderValue(state)
+	if err != nil || !marketCapProvider.IsValueDusted(value) {
+		return nil, nil, nil
+	}
+
+	nums, err := rds.UpdateOrderWhileDust(model, omtyp.ORDER_DUST)
+	if err != nil || nums == 0 {
+		return nil, nil, err
+	}
+	state.Status = omtyp.ORDER_DUST
+
+	saveOrderHistory(state, HISTORY_EVT_TYPE_DUST, types.NilHash)
+	notify.NotifyOrderUpdate(state)
+
+	return state, value, nil
+}
+
+// remainedOrderValue is the value of the remained amount in the legal currency, tokenS is preferred as IsOrderValueDust.
+func remainedOrderValue(state *types.OrderState) (*big.Rat, error) {
+	remainedAmountS, remainedAmountB := state.RemainedAmount()
+	if marketCapProvider.IsSupport(state.RawOrder.TokenS) {
+		return marketCapProvider.LegalCurrencyValue(state.RawOrder.TokenS, remainedAmountS)
+	}
+	return marketCapProvider.LegalCurrencyValue(state.RawOrder.TokenB, remainedAmountB)
+}
+
+func (report *dustReport) add(market string, value *big.Rat) {
+	mr, ok := report.Markets[market]
+	if !ok {
+		mr = &dustMarketReport{value: new(big.Rat)}
+		report.Markets[market] = mr
+	}
+	mr.Orders++
+	mr.value.Add(mr.value, value)
+	mr.Value = mr.value.FloatString(6)
+	report.Retired++
+}
+
+func (report *dustReport) emit() {
+	log.Infof("order manager, dust sweeper checked %d orders, failed:%d retired:%d", report.Scanned, report.Failed, report.Retired)
+	for market, mr := range report.Markets {
+		log.Infof("order manager, dust sweeper retired %d orders of market:%s, value:%s", mr.Orders, market, mr.Value)
+		cloudwatch.PutHeartBeatMetric(metricOrderDustRetired + "_" + market)
+	}
+	if report.Retired == 0 {
+		return
+	}
+	bs, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("order manager, dust sweeper marshal report error:%s", err.Error())
+		return
+	}
+	if err := sns.PublishSns(dustSweeperReportTitle, string(bs)); err != nil {
+		log.Error(err.Error())
+	}
+}
@@ -0,0 +1,62 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/types"
+	"math/big"
+	"testing"
+)
+
+func TestDustReportAdd(t *testing.T) {
+	report := &dustReport{Markets: make(map[string]*dustMarketReport)}
+	report.add("LRC-WETH", big.NewRat(1, 2))
+	report.add("LRC-WETH", big.NewRat(1, 3))
+	report.add("RDN-WETH", big.NewRat(2, 1))
+
+	if report.Retired != 3 || len(report.Markets) != 2 {
+		t.Fatalf("retired:%d markets:%d, want 3 and 2", report.Retired, len(report.Markets))
+	}
+	if mr := report.Markets["LRC-WETH"]; mr.Orders != 2 || mr.Value != "0.833333" {
+		t.Errorf("LRC-WETH orders:%d value:%s, want 2 and 0.833333", mr.Orders, mr.Value)
+	}
+	if mr := report.Markets["RDN-WETH"]; mr.Orders != 1 || mr.Value != "2.000000" {
+		t.Errorf("RDN-WETH orders:%d value:%s, want 1 and 2.000000", mr.Orders, mr.Value)
+	}
+
+	// the exact value is kept out of the report published
+	bs, err := json.Marshal(report.Markets["RDN-WETH"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != `{"orders":1,"value":"2.000000"}` {
+		t.Errorf("market report json:%s", string(bs))
+	}
+}
+
+func TestSettleForkedOrderStatusKeepsSweptOrders(t *testing.T) {
+	for _, status := range []types.OrderStatus{omtyp.ORDER_DUST, types.ORDER_EXPIRE} {
+		state := &types.OrderState{Status: status, DealtAmountS: big.NewInt(0)}
+		if settleForkedOrderStatus(state) || state.Status != status {
+			t.Errorf("status %d should be kept on rollback, got %d", status, state.Status)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-cluster/dao"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	notify "github.com/Loopring/relay-cluster/util"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"time"
+)
+
+const (
+	expirySweeperZkLock = "orderManagerExpirySweeperZkLock"
+
+	defaultExpirySweepInterval  = 60
+	defaultExpirySweepBatchSize = 500
+)
+
+// expirySweeper sets the orders passed their validUntil to ORDER_EXPIRE, only the node holding the zklock sweeps.
+// Frozen amounts are calculated from orders in valid status, so they are released once the status is updated.
+type expirySweeper struct {
+	*zkCronJob
+	batchSize int
+}
+
+func newExpirySweeper(options *omcm.OrderManagerOptions) *expirySweeper {
+	s := &expirySweeper{batchSize: options.ExpirySweepBatchSize}
+	s.zkCronJob = &zkCronJob{name: "expiry sweeper", lockName: expirySweeperZkLock, interval: options.ExpirySweepInterval, run: s.sweep}
+	if s.interval == 0 {
+		s.interval = defaultExpirySweepInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultExpirySweepBatchSize
+	}
+	return s
+}
+
+func (s *expirySweeper) sweep() {
+	for !s.isPaused() {
+		now := time.Now().Unix()
+		orders, err := rds.GetExpiredOrders(now, omcm.ValidExpireStatus, s.batchSize)
+		if err != nil {
+			log.Errorf("order manager, expiry sweeper get expired orders error:%s", err.Error())
+			return
+		}
+		if len(orders) == 0 {
+			return
+		}
+
+		if err := expireOrders(orders, now); err != nil {
+			log.Errorf("order manager, expiry sweeper expire orders error:%s", err.Error())
+			return
+		}
+		if len(orders) < s.batchSize {
+			return
+		}
+	}
+}
+
+func expireOrders(orders []dao.Order, now int64) error {
+	var hashes []string
+	for _, v := range orders {
+		hashes = append(hashes, v.OrderHash)
+	}
+
+	nums, err := rds.SetOrdersExpired(hashes, now, omcm.ValidExpireStatus, types.ORDER_EXPIRE)
+	if err != nil {
+		return err
+	}
+	log.Debugf("order manager, expiry sweeper expired %d of %d orders", nums, len(orders))
+
+	// orders filled or cancelled after queried are not expired, notify the orders really updated only
+	var orderhashList []common.Hash
+	for _, v := range orders {
+		orderhashList = append(orderhashList, common.HexToHash(v.OrderHash))
+	}
+	updated, err := rds.GetOrdersByHashes(orderhashList)
+	if err != nil {
+		return err
+	}
+
+	owners := make(map[types.BalanceUpdateEvent]bool)
+	for _, v := range updated {
+		if types.OrderStatus(v.Status) != types.ORDER_EXPIRE {
+			continue
+		}
+		state := &types.OrderState{}
+		if err := v.ConvertUp(state); err != nil {
+			continue
+		}
+		saveOrderHistory(state, HISTORY_EVT_TYPE_EXPIRE, types.NilHash)
+		notify.NotifyOrderUpdate(state)
+		owners[types.BalanceUpdateEvent{DelegateAddress: v.DelegateAddress, Owner: v.Owner}] = true
+	}
+
+	// frozen amounts of owners changed
+	for event := range owners {
+		evt := event
+		notify.NotifyAccountBalanceUpdate(&evt)
+	}
+
+	return nil
+}
@@ -20,10 +20,16 @@ package manager
 
 import (
 	"fmt"
+	"github.com/Loopring/relay-cluster/dao"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	notify "github.com/Loopring/relay-cluster/util"
+	"github.com/Loopring/relay-lib/eventemitter"
 	"github.com/Loopring/relay-lib/log"
 	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
 	"math/big"
 	"sort"
+	"strings"
 )
 
 type ForkProcessor struct{}
@@ -42,42 +48,165 @@ func NewForkProcess() *ForkProcessor {
 //   c.处理cutoff,合约里cutoff可以重复提交,而在ordermanager中,所有cutoff事件都会被存储,但是更新订单时,同一个订单不会被多次cutoff
 //     那么,在回滚时,我们需要知道某一个订单以前是否也cutoff过,在dao/cutoff中我们存储了orderhashList,可以将这些订单取出并按照订单量重置状态
 //   d.处理cutoffPair,同cutoff
+// 3.订单回滚,订单历史以及标记分叉事件(ringmined,fill,cancel,cutoff,cutoffPair)在同一个数据库事务中完成,任何一步失败或进程退出都不会留下回滚了一半的订单,
+//   事件未被标记,重新处理分叉时可以完整回滚
+// 4.事务提交前恢复p2p订单的pendingAmount,该操作可重复执行,失败时回滚事务,整个分叉处理被重试
+// 5.事务提交后推送订单及余额的socketio消息,并通知trend重新计算
 func (p *ForkProcessor) Fork(event *types.ForkedEvent) error {
 	from := event.ForkBlock.Int64()
 	to := event.DetectedBlock.Int64()
 
-	list, _ := p.GetForkEvents(from, to)
-	if list.Len() == 0 {
+	list, err := p.GetForkEvents(from, to)
+	if err != nil {
+		return err
+	}
+	ringminedList, err := rds.GetRingMinedForkEvents(from, to)
+	if err != nil {
+		return fmt.Errorf("fork get ringmined events error:%s", err.Error())
+	}
+	if list.Len() == 0 && len(ringminedList) == 0 {
 		log.Debugf("order manager fork:non fork events")
 		return nil
 	}
 
 	sort.Sort(list)
 
+	rollback := newForkRollback(rds.Begin())
+	if err := rollback.process(list, from, to); err != nil {
+		rollback.abort()
+		return err
+	}
+	if err := rollback.restoreP2POrders(ringminedList); err != nil {
+		rollback.abort()
+		return err
+	}
+	if err := rollback.tx.Commit(); err != nil {
+		return fmt.Errorf("fork commit db transaction error:%s", err.Error())
+	}
+
+	rollback.publish(event)
+	log.Infof("order manager fork, from:%d to:%d, rolled back %d events of %d orders", from, to, list.Len(), len(rollback.states))
+
+	return nil
+}
+
+// forkRollback is a single fork processing, every db write is in tx, and side effects out of db
+// are applied by publish only after tx committed.
+type forkRollback struct {
+	tx        *dao.RdsService
+	states    map[common.Hash]*types.OrderState
+	histories []*dao.OrderHistory
+	fills     []omtyp.ForkedFill
+}
+
+func newForkRollback(tx *dao.RdsService) *forkRollback {
+	return &forkRollback{tx: tx, states: make(map[common.Hash]*types.OrderState)}
+}
+
+func (r *forkRollback) process(list InnerForkEventList, from, to int64) error {
 	var err error
 	for _, v := range list {
 		switch v.Type {
 		case FORK_EVT_TYPE_FILL:
-			err = p.RollBackSingleFill(v.Event.(*types.OrderFilledEvent))
+			err = r.RollBackSingleFill(v.Event.(*types.OrderFilledEvent))
 		case FORK_EVT_TYPE_CANCEL:
-			err = p.RollBackSingleCancel(v.Event.(*types.OrderCancelledEvent))
+			err = r.RollBackSingleCancel(v.Event.(*types.OrderCancelledEvent))
 		case FORK_EVT_TYPE_CUTOFF:
-			err = p.RollBackSingleCutoff(v.Event.(*types.CutoffEvent))
+			err = r.RollBackSingleCutoff(v.Event.(*types.CutoffEvent))
 		case FORK_EVT_TYPE_CUTOFF_PAIR:
-			err = p.RollBackSingleCutoffPair(v.Event.(*types.CutoffPairEvent))
+			err = r.RollBackSingleCutoffPair(v.Event.(*types.CutoffPairEvent))
 		}
 		if err != nil {
 			return err
 		}
 	}
 
-	return p.MarkForkEvents(from, to)
+	for _, v := range r.histories {
+		if err := r.tx.Add(v); err != nil {
+			return fmt.Errorf("fork save order history error:%s", err.Error())
+		}
+	}
+
+	return r.MarkForkEvents(from, to)
+}
+
+// record keeps the latest state of the order to notify and its history to save in tx.
+func (r *forkRollback) record(state *types.OrderState, eventType string, txhash common.Hash) {
+	r.recordHistory(state, eventType, txhash)
+	r.states[state.RawOrder.Hash] = state
+}
+
+// recordHistory records the rollback of an order without publishing its update.
+func (r *forkRollback) recordHistory(state *types.OrderState, eventType string, txhash common.Hash) {
+	history := &dao.OrderHistory{}
+	history.ConvertDown(state, eventType, txhash)
+	r.histories = append(r.histories, history)
+}
+
+// settleForkedOrderStatus settles the status of an order whose amounts are rolled back, it returns false for orders
+// retired by the dust or expiry sweeper, which keep ORDER_DUST or ORDER_EXPIRE as rolled back events don't revive them.
+func settleForkedOrderStatus(state *types.OrderState) bool {
+	if state.Status == omtyp.ORDER_DUST || state.Status == types.ORDER_EXPIRE {
+		return false
+	}
+	SettleOrderStatus(state, false)
+	return true
+}
+
+func (r *forkRollback) abort() {
+	if err := r.tx.Rollback(); err != nil {
+		log.Errorf("order manager fork, rollback db transaction error:%s", err.Error())
+	}
+}
+
+// restoreP2POrders locks the pending amount of a p2p maker released by a forked ring again until the ring is
+// mined again. It's done before tx is committed, so that a failure aborts the fork processing which is retried
+// as a whole, restoring the same ring again only sets the same cache entries.
+func (r *forkRollback) restoreP2POrders(ringminedList []dao.RingMinedEvent) error {
+	for _, v := range ringminedList {
+		if err := RollBackP2POrderFilled(v.TxHash); err != nil {
+			return fmt.Errorf("fork rollback p2p ring:%s error:%s", v.TxHash, err.Error())
+		}
+	}
+	return nil
+}
+
+// publish applies side effects of the committed rollback, failures are only logged as db is already consistent.
+func (r *forkRollback) publish(event *types.ForkedEvent) {
+	owners := make(map[types.BalanceUpdateEvent]bool)
+	for _, state := range r.states {
+		notify.NotifyOrderUpdate(state)
+		owners[types.BalanceUpdateEvent{DelegateAddress: state.RawOrder.DelegateAddress.Hex(), Owner: state.RawOrder.Owner.Hex()}] = true
+	}
+	for evt := range owners {
+		balanceEvent := evt
+		notify.NotifyAccountBalanceUpdate(&balanceEvent)
+	}
+
+	// trades are queried from db by delegate and market, a single fill of each market refreshes them
+	markets := make(map[string]bool)
+	for _, v := range r.fills {
+		key := strings.ToLower(v.DelegateAddress.Hex()) + "_" + strings.ToLower(v.Market)
+		if markets[key] {
+			continue
+		}
+		markets[key] = true
+		notify.NotifyOrderFilled(&dao.FillEvent{DelegateAddress: v.DelegateAddress.Hex(), Market: v.Market})
+	}
+
+	eventemitter.Emit(omtyp.ForkRolledBack, &omtyp.ForkRolledBackEvent{
+		ForkBlock:     event.ForkBlock.Int64(),
+		DetectedBlock: event.DetectedBlock.Int64(),
+		Fills:         r.fills,
+	})
 }
 
 // calculate order's related values and status, update order
-func (p *ForkProcessor) RollBackSingleFill(evt *types.OrderFilledEvent) error {
+func (r *forkRollback) RollBackSingleFill(evt *types.OrderFilledEvent) error {
+	r.fills = append(r.fills, omtyp.ForkedFill{DelegateAddress: evt.DelegateAddress, Market: evt.Market, BlockTime: evt.BlockTime})
+
 	state := &types.OrderState{}
-	model, err := rds.GetOrderByHash(evt.OrderHash)
+	model, err := r.tx.GetOrderByHash(evt.OrderHash)
 	if err != nil {
 		log.Debugf("fork fill event,order:%s not exist in dao/fill", evt.OrderHash.Hex())
 		return nil
@@ -94,21 +223,67 @@ func (p *ForkProcessor) RollBackSingleFill(evt *types.OrderFilledEvent) error {
 	log.Debugf("fork fill event, orderhash:%s,dealAmountS:%s,dealtAmountB:%s", state.RawOrder.Hash.Hex(), state.DealtAmountS.String(), state.DealtAmountB.String())
 
 	// update order status
-	SettleOrderStatus(state, false)
+	settled := settleForkedOrderStatus(state)
 
 	// update rds.Order
 	model.ConvertDown(state)
-	if err := rds.UpdateOrderWhileFill(state.RawOrder.Hash, state.Status, state.DealtAmountS, state.DealtAmountB, state.SplitAmountS, state.SplitAmountB, state.UpdatedBlock); err != nil {
-		return err
+	if err := r.tx.UpdateOrderWhileFill(state.RawOrder.Hash, state.Status, state.DealtAmountS, state.DealtAmountB, state.SplitAmountS, state.SplitAmountB, state.UpdatedBlock); err != nil {
+		return fmt.Errorf("fork fill event,error:%s", err.Error())
+	}
+	if settled {
+		r.record(state, HISTORY_EVT_TYPE_FORK_FILL, evt.TxHash)
+	} else {
+		r.recordHistory(state, HISTORY_EVT_TYPE_FORK_FILL, evt.TxHash)
+	}
+
+	return r.RollBackOrderGroup(evt)
+}
+
+// RollBackOrderGroup resets the order group triggered by the forked fill, and restores the members cancelled by it
+// unless they're cancelled otherwise since then.
+func (r *forkRollback) RollBackOrderGroup(evt *types.OrderFilledEvent) error {
+	group, err := r.tx.GetOrderGroupByOrderHash(evt.OrderHash)
+	if err != nil {
+		return nil
+	}
+	members, err := r.tx.GetOrderGroupMembers(group.GroupId)
+	if err != nil {
+		return fmt.Errorf("fork fill event, get members of order group:%s error:%s", group.GroupId, err.Error())
+	}
+	nums, err := r.tx.ResetOrderGroupTrigger(group.GroupId, evt.OrderHash, evt.TxHash)
+	if err != nil {
+		return fmt.Errorf("fork fill event, reset order group:%s error:%s", group.GroupId, err.Error())
+	}
+	if nums == 0 {
+		return nil
 	}
 
+	for _, v := range members {
+		if !v.TriggerCancelled {
+			continue
+		}
+		model, err := r.tx.GetOrderByHash(common.HexToHash(v.OrderHash))
+		if err != nil || types.OrderStatus(model.Status) != types.ORDER_FLEX_CANCEL {
+			continue
+		}
+		state := &types.OrderState{}
+		model.ConvertUp(state)
+		state.UpdatedBlock = evt.BlockNumber
+		SettleOrderStatus(state, false)
+		if _, err := r.tx.RestoreFlexCancelledOrder(state.RawOrder.Hash, state.Status, state.UpdatedBlock); err != nil {
+			return fmt.Errorf("fork fill event, restore order:%s of group:%s error:%s", v.OrderHash, group.GroupId, err.Error())
+		}
+		r.record(state, HISTORY_EVT_TYPE_FORK_OCO_CANCEL, evt.TxHash)
+	}
+
+	log.Debugf("fork fill event, order group:%s triggered by order:%s tx:%s rolled back", group.GroupId, evt.OrderHash.Hex(), evt.TxHash.Hex())
 	return nil
 }
 
-func (p *ForkProcessor) RollBackSingleCancel(evt *types.OrderCancelledEvent) error {
+func (r *forkRollback) RollBackSingleCancel(evt *types.OrderCancelledEvent) error {
 	// get rds.Order and types.OrderState
 	state := &types.OrderState{}
-	model, err := rds.GetOrderByHash(evt.OrderHash)
+	model, err := r.tx.GetOrderByHash(evt.OrderHash)
 	if err != nil {
 		log.Debugf("fork order cancelled event,order:%s not exist in dao/order", evt.OrderHash.Hex())
 		return nil
@@ -125,19 +300,24 @@ func (p *ForkProcessor) RollBackSingleCancel(evt *types.OrderCancelledEvent) err
 	}
 
 	// update order status
-	SettleOrderStatus(state, false)
+	settled := settleForkedOrderStatus(state)
 	state.UpdatedBlock = evt.BlockNumber
 
 	// update rds.Order
 	model.ConvertDown(state)
-	if err := rds.UpdateOrderWhileCancel(state.RawOrder.Hash, state.Status, state.CancelledAmountS, state.CancelledAmountB, state.UpdatedBlock); err != nil {
+	if err := r.tx.UpdateOrderWhileCancel(state.RawOrder.Hash, state.Status, state.CancelledAmountS, state.CancelledAmountB, state.UpdatedBlock); err != nil {
 		return fmt.Errorf("fork cancel event,error:%s", err.Error())
 	}
+	if settled {
+		r.record(state, HISTORY_EVT_TYPE_FORK_CANCEL, evt.TxHash)
+	} else {
+		r.recordHistory(state, HISTORY_EVT_TYPE_FORK_CANCEL, evt.TxHash)
+	}
 
 	return nil
 }
 
-func (p *ForkProcessor) RollBackSingleCutoff(evt *types.CutoffEvent) error {
+func (r *forkRollback) RollBackSingleCutoff(evt *types.CutoffEvent) error {
 	if len(evt.OrderHashList) == 0 {
 		log.Debugf("fork cutoff event,tx:%s,no order cutoff", evt.TxHash.Hex())
 		return nil
@@ -145,7 +325,7 @@ func (p *ForkProcessor) RollBackSingleCutoff(evt *types.CutoffEvent) error {
 
 	for _, orderhash := range evt.OrderHashList {
 		state := &types.OrderState{}
-		model, err := rds.GetOrderByHash(orderhash)
+		model, err := r.tx.GetOrderByHash(orderhash)
 		if err != nil {
 			log.Debugf("fork cutoff event,order:%s not exist in dao/order", orderhash.Hex())
 			continue
@@ -153,11 +333,15 @@ func (p *ForkProcessor) RollBackSingleCutoff(evt *types.CutoffEvent) error {
 		model.ConvertUp(state)
 
 		// update order status
-		SettleOrderStatus(state, false)
+		if !settleForkedOrderStatus(state) {
+			continue
+		}
 
-		if err := rds.UpdateOrderWhileRollbackCutoff(orderhash, state.Status, evt.BlockNumber); err != nil {
+		if err := r.tx.UpdateOrderWhileRollbackCutoff(orderhash, state.Status, evt.BlockNumber); err != nil {
 			return fmt.Errorf("fork cutoff event,error:%s", err.Error())
 		}
+		state.UpdatedBlock = evt.BlockNumber
+		r.record(state, HISTORY_EVT_TYPE_FORK_CUTOFF, evt.TxHash)
 
 		log.Debugf("fork cutoff event,order:%s", orderhash.Hex())
 	}
@@ -165,7 +349,7 @@ func (p *ForkProcessor) RollBackSingleCutoff(evt *types.CutoffEvent) error {
 	return nil
 }
 
-func (p *ForkProcessor) RollBackSingleCutoffPair(evt *types.CutoffPairEvent) error {
+func (r *forkRollback) RollBackSingleCutoffPair(evt *types.CutoffPairEvent) error {
 	if len(evt.OrderHashList) == 0 {
 		log.Debugf("fork cutoffPair event,tx:%s,no order cutoff", evt.TxHash.Hex())
 		return nil
@@ -173,7 +357,7 @@ func (p *ForkProcessor) RollBackSingleCutoffPair(evt *types.CutoffPairEvent) err
 
 	for _, orderhash := range evt.OrderHashList {
 		state := &types.OrderState{}
-		model, err := rds.GetOrderByHash(orderhash)
+		model, err := r.tx.GetOrderByHash(orderhash)
 		if err != nil {
 			log.Debugf("fork cutoffPair event,order:%s not exist in dao/order", orderhash.Hex())
 			continue
@@ -182,11 +366,15 @@ func (p *ForkProcessor) RollBackSingleCutoffPair(evt *types.CutoffPairEvent) err
 
 		// update order status
 		// 在ordermanager 已完成的订单不会再更新,因此,cutoff事件发生之前,从钱包的角度来看只会有fillEvent,默认cancel取消所有的量
-		SettleOrderStatus(state, false)
+		if !settleForkedOrderStatus(state) {
+			continue
+		}
 
-		if err := rds.UpdateOrderWhileRollbackCutoff(orderhash, state.Status, evt.BlockNumber); err != nil {
+		if err := r.tx.UpdateOrderWhileRollbackCutoff(orderhash, state.Status, evt.BlockNumber); err != nil {
 			return fmt.Errorf("fork cutoffPair event,error:%s", err.Error())
 		}
+		state.UpdatedBlock = evt.BlockNumber
+		r.record(state, HISTORY_EVT_TYPE_FORK_CUTOFF_PAIR, evt.TxHash)
 
 		log.Debugf("fork cutoff pair event,order:%s", orderhash.Hex())
 	}
@@ -194,20 +382,20 @@ func (p *ForkProcessor) RollBackSingleCutoffPair(evt *types.CutoffPairEvent) err
 	return nil
 }
 
-func (p *ForkProcessor) MarkForkEvents(from, to int64) error {
-	if err := rds.RollBackRingMined(from, to); err != nil {
+func (r *forkRollback) MarkForkEvents(from, to int64) error {
+	if err := r.tx.RollBackRingMined(from, to); err != nil {
 		return fmt.Errorf("fork rollback ringmined events error:%s", err.Error())
 	}
-	if err := rds.RollBackFill(from, to); err != nil {
+	if err := r.tx.RollBackFill(from, to); err != nil {
 		return fmt.Errorf("fork rollback fill events error:%s", err.Error())
 	}
-	if err := rds.RollBackCancel(from, to); err != nil {
+	if err := r.tx.RollBackCancel(from, to); err != nil {
 		return fmt.Errorf("fork rollback cancel events error:%s", err.Error())
 	}
-	if err := rds.RollBackCutoff(from, to); err != nil {
+	if err := r.tx.RollBackCutoff(from, to); err != nil {
 		return fmt.Errorf("fork rollback cutoff events error:%s", err.Error())
 	}
-	if err := rds.RollBackCutoffPair(from, to); err != nil {
+	if err := r.tx.RollBackCutoffPair(from, to); err != nil {
 		return fmt.Errorf("fork rollback cutoffPair events error:%s", err.Error())
 	}
 
@@ -224,64 +412,72 @@ const (
 func (p *ForkProcessor) GetForkEvents(from, to int64) (InnerForkEventList, error) {
 	var list InnerForkEventList
 
-	if fillList, _ := rds.GetFillForkEvents(from, to); len(fillList) > 0 {
-		for _, v := range fillList {
-			var (
-				fill     types.OrderFilledEvent
-				innerEvt InnerForkEvent
-			)
-			v.ConvertUp(&fill)
-			innerEvt.LogIndex = fill.TxLogIndex
-			innerEvt.BlockNumber = fill.BlockNumber.Int64()
-			innerEvt.Type = FORK_EVT_TYPE_FILL
-			innerEvt.Event = &fill
-			list = append(list, innerEvt)
-		}
+	fillList, err := rds.GetFillForkEvents(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("fork get fill events error:%s", err.Error())
+	}
+	for _, v := range fillList {
+		var (
+			fill     types.OrderFilledEvent
+			innerEvt InnerForkEvent
+		)
+		v.ConvertUp(&fill)
+		innerEvt.LogIndex = fill.TxLogIndex
+		innerEvt.BlockNumber = fill.BlockNumber.Int64()
+		innerEvt.Type = FORK_EVT_TYPE_FILL
+		innerEvt.Event = &fill
+		list = append(list, innerEvt)
 	}
 
-	if cancelList, _ := rds.GetCancelForkEvents(from, to); len(cancelList) > 0 {
-		for _, v := range cancelList {
-			var (
-				cancel   types.OrderCancelledEvent
-				innerEvt InnerForkEvent
-			)
-			v.ConvertUp(&cancel)
-			innerEvt.LogIndex = cancel.TxLogIndex
-			innerEvt.BlockNumber = cancel.BlockNumber.Int64()
-			innerEvt.Type = FORK_EVT_TYPE_CANCEL
-			innerEvt.Event = &cancel
-			list = append(list, innerEvt)
-		}
+	cancelList, err := rds.GetCancelForkEvents(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("fork get cancel events error:%s", err.Error())
+	}
+	for _, v := range cancelList {
+		var (
+			cancel   types.OrderCancelledEvent
+			innerEvt InnerForkEvent
+		)
+		v.ConvertUp(&cancel)
+		innerEvt.LogIndex = cancel.TxLogIndex
+		innerEvt.BlockNumber = cancel.BlockNumber.Int64()
+		innerEvt.Type = FORK_EVT_TYPE_CANCEL
+		innerEvt.Event = &cancel
+		list = append(list, innerEvt)
 	}
 
-	if cutoffList, _ := rds.GetCutoffForkEvents(from, to); len(cutoffList) > 0 {
-		for _, v := range cutoffList {
-			var (
-				cutoff   types.CutoffEvent
-				innerEvt InnerForkEvent
-			)
-			v.ConvertUp(&cutoff)
-			innerEvt.LogIndex = cutoff.TxLogIndex
-			innerEvt.BlockNumber = cutoff.BlockNumber.Int64()
-			innerEvt.Type = FORK_EVT_TYPE_CUTOFF
-			innerEvt.Event = &cutoff
-			list = append(list, innerEvt)
-		}
+	cutoffList, err := rds.GetCutoffForkEvents(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("fork get cutoff events error:%s", err.Error())
+	}
+	for _, v := range cutoffList {
+		var (
+			cutoff   types.CutoffEvent
+			innerEvt InnerForkEvent
+		)
+		v.ConvertUp(&cutoff)
+		innerEvt.LogIndex = cutoff.TxLogIndex
+		innerEvt.BlockNumber = cutoff.BlockNumber.Int64()
+		innerEvt.Type = FORK_EVT_TYPE_CUTOFF
+		innerEvt.Event = &cutoff
+		list = append(list, innerEvt)
 	}
 
-	if cutoffPairList, _ := rds.GetCutoffPairForkEvents(from, to); len(cutoffPairList) > 0 {
-		for _, v := range cutoffPairList {
-			var (
-				cutoffPair types.CutoffPairEvent
-				innerEvt   InnerForkEvent
-			)
-			v.ConvertUp(&cutoffPair)
-			innerEvt.LogIndex = cutoffPair.TxLogIndex
-			innerEvt.BlockNumber = cutoffPair.BlockNumber.Int64()
-			innerEvt.Type = FORK_EVT_TYPE_CUTOFF_PAIR
-			innerEvt.Event = &cutoffPair
-			list = append(list, innerEvt)
-		}
+	cutoffPairList, err := rds.GetCutoffPairForkEvents(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("fork get cutoffPair events error:%s", err.Error())
+	}
+	for _, v := range cutoffPairList {
+		var (
+			cutoffPair types.CutoffPairEvent
+			innerEvt   InnerForkEvent
+		)
+		v.ConvertUp(&cutoffPair)
+		innerEvt.LogIndex = cutoffPair.TxLogIndex
+		innerEvt.BlockNumber = cutoffPair.BlockNumber.Int64()
+		innerEvt.Type = FORK_EVT_TYPE_CUTOFF_PAIR
+		innerEvt.Event = &cutoffPair
+		list = append(list, innerEvt)
 	}
 
 	return list, nil
@@ -0,0 +1,74 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-cluster/dao"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	HISTORY_EVT_TYPE_NEW              = "new"
+	HISTORY_EVT_TYPE_FILL             = "fill"
+	HISTORY_EVT_TYPE_CANCEL           = "cancel"
+	HISTORY_EVT_TYPE_CUTOFF           = "cutoff"
+	HISTORY_EVT_TYPE_CUTOFF_PAIR      = "cutoff_pair"
+	HISTORY_EVT_TYPE_FLEX_CANCEL      = "flex_cancel"
+	HISTORY_EVT_TYPE_OCO_CANCEL       = "oco_cancel"
+	HISTORY_EVT_TYPE_EXPIRE           = "expire"
+	HISTORY_EVT_TYPE_DUST             = "dust"
+	HISTORY_EVT_TYPE_RECONCILE        = "reconcile"
+	HISTORY_EVT_TYPE_PENDING_TX       = "pending_tx"
+	HISTORY_EVT_TYPE_FORK_FILL        = "fork_fill"
+	HISTORY_EVT_TYPE_FORK_CANCEL      = "fork_cancel"
+	HISTORY_EVT_TYPE_FORK_CUTOFF      = "fork_cutoff"
+	HISTORY_EVT_TYPE_FORK_CUTOFF_PAIR = "fork_cutoff_pair"
+	HISTORY_EVT_TYPE_FORK_OCO_CANCEL  = "fork_oco_cancel"
+)
+
+// saveOrderHistory records the state of an order after a transition,
+// failures are only logged as the history should never block order processing.
+func saveOrderHistory(state *types.OrderState, eventType string, txhash common.Hash) {
+	model := &dao.OrderHistory{}
+	model.ConvertDown(state, eventType, txhash)
+	if err := rds.Add(model); err != nil {
+		log.Errorf("order manager, save order:%s history of %s error:%s", state.RawOrder.Hash.Hex(), eventType, err.Error())
+	}
+}
+
+// saveOrdersHistory records the current states of orders updated in batch.
+func saveOrdersHistory(orderhashList []common.Hash, eventType string, txhash common.Hash) {
+	if len(orderhashList) == 0 {
+		return
+	}
+	models, err := rds.GetOrdersByHashes(orderhashList)
+	if err != nil {
+		log.Errorf("order manager, get orders for history of %s error:%s", eventType, err.Error())
+		return
+	}
+	for _, v := range models {
+		state := &types.OrderState{}
+		if err := v.ConvertUp(state); err != nil {
+			continue
+		}
+		saveOrderHistory(state, eventType, txhash)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/Loopring/relay-lib/cloudwatch"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/sns"
+	"github.com/Loopring/relay-lib/types"
+	"sync"
+	"time"
+)
+
+type LifecycleState string
+
+// running: 处理所有事件
+// paused: 事件按到达顺序缓存, 等待resume
+// draining: resume后按顺序重放缓存的事件, 新到达的事件继续追加到缓存, 缓存清空后进入running
+// stopped: 节点退出, 事件被丢弃
+const (
+	LIFECYCLE_RUNNING  LifecycleState = "running"
+	LIFECYCLE_PAUSED   LifecycleState = "paused"
+	LIFECYCLE_DRAINING LifecycleState = "draining"
+	LIFECYCLE_STOPPED  LifecycleState = "stopped"
+)
+
+const (
+	LIFECYCLE_REASON_START   = "start"
+	LIFECYCLE_REASON_STOP    = "stop"
+	LIFECYCLE_REASON_RESUME  = "resume"
+	LIFECYCLE_REASON_WARNING = "extractor warning"
+	LIFECYCLE_REASON_FORK    = "chain fork"
+
+	lifecyclePausedTitle = "order manager paused"
+
+	defaultPauseBufferSize = 100000
+
+	metricOrderManagerPaused       = "order_manager_paused"
+	metricOrderManagerEventDropped = "order_manager_event_dropped"
+)
+
+// LifecycleStatus is the current state of the order manager, Buffered events are replayed on resume,
+// and Dropped events arrived while the buffer was full. Orders may miss dropped events, so resume is refused
+// until an operator resyncs the orders and resumes with resynced.
+type LifecycleStatus struct {
+	State    LifecycleState `json:"state"`
+	Reason   string         `json:"reason"`
+	Since    int64          `json:"since"`
+	Buffered int            `json:"buffered"`
+	Dropped  int            `json:"dropped"`
+}
+
+type bufferedEvent struct {
+	handle func(input eventemitter.EventData) error
+	input  eventemitter.EventData
+}
+
+// lifecycle dispatches events to handlers by the state of the order manager.
+// Handlers hold inflight while processing, so that pause returns only after events in process are finished.
+type lifecycle struct {
+	mtx        sync.Mutex
+	inflight   sync.RWMutex
+	status     LifecycleStatus
+	buffer     []bufferedEvent
+	bufferSize int
+	onRunning  func()
+	onPaused   func()
+}
+
+func newLifecycle(bufferSize int, onRunning, onPaused func()) *lifecycle {
+	if bufferSize <= 0 {
+		bufferSize = defaultPauseBufferSize
+	}
+	l := &lifecycle{bufferSize: bufferSize, onRunning: onRunning, onPaused: onPaused}
+	l.status = LifecycleStatus{State: LIFECYCLE_STOPPED, Reason: LIFECYCLE_REASON_STOP, Since: time.Now().Unix()}
+	return l
+}
+
+// dispatch wraps handle, the event is handled at once while running, buffered while paused or draining,
+// and dropped after stopped.
+func (l *lifecycle) dispatch(handle func(input eventemitter.EventData) error) func(input eventemitter.EventData) error {
+	return func(input eventemitter.EventData) error {
+		l.inflight.RLock()
+		defer l.inflight.RUnlock()
+
+		l.mtx.Lock()
+		switch l.status.State {
+		case LIFECYCLE_RUNNING:
+			l.mtx.Unlock()
+			return handle(input)
+		case LIFECYCLE_PAUSED, LIFECYCLE_DRAINING:
+			if len(l.buffer) >= l.bufferSize {
+				l.status.Dropped++
+				l.mtx.Unlock()
+				cloudwatch.PutHeartBeatMetric(metricOrderManagerEventDropped)
+				log.Errorf("order manager lifecycle, buffer is full(%d), event dropped", l.bufferSize)
+				return nil
+			}
+			l.buffer = append(l.buffer, bufferedEvent{handle: handle, input: input})
+			l.mtx.Unlock()
+			return nil
+		default:
+			l.mtx.Unlock()
+			return nil
+		}
+	}
+}
+
+func (l *lifecycle) current() LifecycleStatus {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	status := l.status
+	status.Buffered = len(l.buffer)
+	return status
+}
+
+// setState is called with mtx held.
+func (l *lifecycle) setState(state LifecycleState, reason string) {
+	log.Infof("order manager lifecycle, %s -> %s, reason:%s", l.status.State, state, reason)
+	l.status.State = state
+	l.status.Reason = reason
+	l.status.Since = time.Now().Unix()
+}
+
+func (l *lifecycle) start() {
+	l.mtx.Lock()
+	l.buffer = nil
+	l.status.Dropped = 0
+	l.setState(LIFECYCLE_RUNNING, LIFECYCLE_REASON_START)
+	l.mtx.Unlock()
+
+	l.onRunning()
+}
+
+func (l *lifecycle) stop() {
+	l.mtx.Lock()
+	l.setState(LIFECYCLE_STOPPED, LIFECYCLE_REASON_STOP)
+	l.buffer = nil
+	l.mtx.Unlock()
+
+	l.onPaused()
+}
+
+// pause buffers the following events and waits for events in process, it returns false if not running or draining.
+func (l *lifecycle) pause(reason string) bool {
+	l.mtx.Lock()
+	if l.status.State != LIFECYCLE_RUNNING && l.status.State != LIFECYCLE_DRAINING {
+		l.mtx.Unlock()
+		return false
+	}
+	l.setState(LIFECYCLE_PAUSED, reason)
+	l.mtx.Unlock()
+
+	l.inflight.Lock()
+	l.inflight.Unlock()
+	l.onPaused()
+
+	cloudwatch.PutHeartBeatMetric(metricOrderManagerPaused)
+	if err := sns.PublishSns(lifecyclePausedTitle, fmt.Sprintf("order manager paused, reason:%s", reason)); err != nil {
+		log.Error(err.Error())
+	}
+	return true
+}
+
+// resume replays the buffered events in order and then runs, the replay is aborted if paused again meanwhile.
+// It's refused after events are dropped unless resynced is set by an operator who resynced the orders.
+func (l *lifecycle) resume(resynced bool) error {
+	l.mtx.Lock()
+	if l.status.State != LIFECYCLE_PAUSED {
+		state := l.status.State
+		l.mtx.Unlock()
+		return fmt.Errorf("order manager lifecycle, can't resume in state %s", state)
+	}
+	if l.status.Dropped > 0 && !resynced {
+		dropped := l.status.Dropped
+		l.mtx.Unlock()
+		return fmt.Errorf("order manager lifecycle, %d events dropped while paused, resync orders before resume", dropped)
+	}
+	l.status.Dropped = 0
+	l.setState(LIFECYCLE_DRAINING, LIFECYCLE_REASON_RESUME)
+	l.mtx.Unlock()
+
+	go l.drain()
+	return nil
+}
+
+// dropForkedEvents removes buffered events of blocks after forkBlock, which are rolled back by the fork
+// processing and must not be replayed, it returns the number of events removed.
+func (l *lifecycle) dropForkedEvents(forkBlock int64) int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	buffer := l.buffer[:0]
+	for _, evt := range l.buffer {
+		if blockNumber, ok := eventBlockNumber(evt.input); ok && blockNumber > forkBlock {
+			continue
+		}
+		buffer = append(buffer, evt)
+	}
+	removed := len(l.buffer) - len(buffer)
+	for i := len(buffer); i < len(l.buffer); i++ {
+		l.buffer[i] = bufferedEvent{}
+	}
+	l.buffer = buffer
+	return removed
+}
+
+// eventBlockNumber returns the block of an event mined, it returns false for events not mined, eg. orders submitted.
+func eventBlockNumber(input eventemitter.EventData) (int64, bool) {
+	var txinfo types.TxInfo
+	switch event := input.(type) {
+	case *types.SubmitRingMethodEvent:
+		txinfo = event.TxInfo
+	case *types.RingMinedEvent:
+		txinfo = event.TxInfo
+	case *types.OrderFilledEvent:
+		txinfo = event.TxInfo
+	case *types.OrderCancelledEvent:
+		txinfo = event.TxInfo
+	case *types.CutoffEvent:
+		txinfo = event.TxInfo
+	case *types.CutoffPairEvent:
+		txinfo = event.TxInfo
+	case *types.ApprovalEvent:
+		txinfo = event.TxInfo
+	case *types.WethDepositEvent:
+		txinfo = event.TxInfo
+	case *types.WethWithdrawalEvent:
+		txinfo = event.TxInfo
+	case *types.TransferEvent:
+		txinfo = event.TxInfo
+	case *types.EthTransferEvent:
+		txinfo = event.TxInfo
+	case *types.UnsupportedContractEvent:
+		txinfo = event.TxInfo
+	default:
+		return 0, false
+	}
+	if txinfo.BlockNumber == nil {
+		return 0, false
+	}
+	return txinfo.BlockNumber.Int64(), true
+}
+
+func (l *lifecycle) drain() {
+	replayed := 0
+	for {
+		// replayed events are in process as well, pause waits for them
+		l.inflight.RLock()
+		l.mtx.Lock()
+		if l.status.State != LIFECYCLE_DRAINING {
+			l.mtx.Unlock()
+			l.inflight.RUnlock()
+			log.Infof("order manager lifecycle, draining aborted after %d events replayed", replayed)
+			return
+		}
+		if len(l.buffer) == 0 {
+			l.setState(LIFECYCLE_RUNNING, LIFECYCLE_REASON_RESUME)
+			l.mtx.Unlock()
+			l.inflight.RUnlock()
+			break
+		}
+		evt := l.buffer[0]
+		l.buffer = l.buffer[1:]
+		l.mtx.Unlock()
+
+		if err := evt.handle(evt.input); err != nil {
+			log.Errorf("order manager lifecycle, replay event error:%s", err.Error())
+		}
+		l.inflight.RUnlock()
+		replayed++
+	}
+
+	log.Infof("order manager lifecycle, %d events replayed", replayed)
+	l.onRunning()
+}
@@ -0,0 +1,273 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"go.uber.org/zap"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tests run without sns and cloudwatch, whose errors are logged
+func init() {
+	if !log.IsInit() {
+		cfg := zap.NewDevelopmentConfig()
+		cfg.Level = zap.NewAtomicLevelAt(zap.FatalLevel)
+		log.Initialize(cfg)
+	}
+}
+
+// recorder is a handler recording the blocks of the events it handled in order.
+type recorder struct {
+	mtx    sync.Mutex
+	blocks []int64
+}
+
+func (r *recorder) handle(input eventemitter.EventData) error {
+	blockNumber, _ := eventBlockNumber(input)
+	r.mtx.Lock()
+	r.blocks = append(r.blocks, blockNumber)
+	r.mtx.Unlock()
+	return nil
+}
+
+func (r *recorder) handled() []int64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]int64{}, r.blocks...)
+}
+
+func filledAt(blockNumber int64) *types.OrderFilledEvent {
+	event := &types.OrderFilledEvent{}
+	event.BlockNumber = big.NewInt(blockNumber)
+	return event
+}
+
+func waitLifecycleState(t *testing.T, l *lifecycle, state LifecycleState) {
+	deadline := time.Now().Add(2 * time.Second)
+	for l.current().State != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected state %s, got %s", state, l.current().State)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLifecycleTransitions(t *testing.T) {
+	type step struct {
+		op       string
+		ok       bool
+		expected LifecycleState
+	}
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{"stopped ignores pause and resume", []step{
+			{"pause", false, LIFECYCLE_STOPPED},
+			{"resume", false, LIFECYCLE_STOPPED},
+		}},
+		{"pause and resume", []step{
+			{"start", true, LIFECYCLE_RUNNING},
+			{"pause", true, LIFECYCLE_PAUSED},
+			{"resume", true, LIFECYCLE_RUNNING},
+		}},
+		{"pause twice", []step{
+			{"start", true, LIFECYCLE_RUNNING},
+			{"pause", true, LIFECYCLE_PAUSED},
+			{"pause", false, LIFECYCLE_PAUSED},
+		}},
+		{"resume while running", []step{
+			{"start", true, LIFECYCLE_RUNNING},
+			{"resume", false, LIFECYCLE_RUNNING},
+		}},
+		{"stop while paused", []step{
+			{"start", true, LIFECYCLE_RUNNING},
+			{"pause", true, LIFECYCLE_PAUSED},
+			{"stop", true, LIFECYCLE_STOPPED},
+			{"resume", false, LIFECYCLE_STOPPED},
+		}},
+	}
+
+	for _, c := range cases {
+		running, paused := 0, 0
+		l := newLifecycle(10, func() { running++ }, func() { paused++ })
+		for i, s := range c.steps {
+			ok := true
+			switch s.op {
+			case "start":
+				l.start()
+			case "stop":
+				l.stop()
+			case "pause":
+				ok = l.pause("test")
+			case "resume":
+				ok = l.resume(false) == nil
+			}
+			if ok != s.ok {
+				t.Fatalf("%s: step %d %s expected ok:%t, got %t", c.name, i, s.op, s.ok, ok)
+			}
+			waitLifecycleState(t, l, s.expected)
+		}
+	}
+}
+
+func TestLifecycleDispatch(t *testing.T) {
+	r := &recorder{}
+	l := newLifecycle(10, func() {}, func() {})
+	handle := l.dispatch(r.handle)
+
+	handle(filledAt(1))
+	if blocks := r.handled(); len(blocks) != 0 {
+		t.Fatalf("events should be dropped while stopped, got %v", blocks)
+	}
+
+	l.start()
+	handle(filledAt(2))
+	l.pause("test")
+	handle(filledAt(3))
+	handle(filledAt(4))
+	if status := l.current(); status.Buffered != 2 {
+		t.Fatalf("expected 2 buffered events, got %d", status.Buffered)
+	}
+	if err := l.resume(false); err != nil {
+		t.Fatal(err)
+	}
+	waitLifecycleState(t, l, LIFECYCLE_RUNNING)
+	handle(filledAt(5))
+
+	if blocks := r.handled(); !reflect.DeepEqual(blocks, []int64{2, 3, 4, 5}) {
+		t.Fatalf("events handled out of order: %v", blocks)
+	}
+}
+
+func TestLifecycleBufferFull(t *testing.T) {
+	r := &recorder{}
+	l := newLifecycle(2, func() {}, func() {})
+	handle := l.dispatch(r.handle)
+
+	l.start()
+	l.pause("test")
+	for i := int64(1); i <= 3; i++ {
+		handle(filledAt(i))
+	}
+	if status := l.current(); status.Buffered != 2 || status.Dropped != 1 {
+		t.Fatalf("expected 2 buffered and 1 dropped, got %+v", status)
+	}
+
+	if err := l.resume(false); err == nil {
+		t.Fatal("resume should be refused after events dropped")
+	}
+	if state := l.current().State; state != LIFECYCLE_PAUSED {
+		t.Fatalf("expected paused after refused resume, got %s", state)
+	}
+
+	if err := l.resume(true); err != nil {
+		t.Fatal(err)
+	}
+	waitLifecycleState(t, l, LIFECYCLE_RUNNING)
+	if status := l.current(); status.Dropped != 0 {
+		t.Fatalf("dropped should be reset after resynced, got %d", status.Dropped)
+	}
+	if blocks := r.handled(); !reflect.DeepEqual(blocks, []int64{1, 2}) {
+		t.Fatalf("unexpected replayed events: %v", blocks)
+	}
+}
+
+func TestLifecyclePauseWhileDraining(t *testing.T) {
+	r := &recorder{}
+	release := make(chan struct{})
+	replaying := make(chan struct{}, 1)
+	blocking := func(input eventemitter.EventData) error {
+		if blockNumber, _ := eventBlockNumber(input); blockNumber == 1 {
+			replaying <- struct{}{}
+			<-release
+		}
+		return r.handle(input)
+	}
+
+	l := newLifecycle(10, func() {}, func() {})
+	handle := l.dispatch(blocking)
+	l.start()
+	l.pause("test")
+	for i := int64(1); i <= 3; i++ {
+		handle(filledAt(i))
+	}
+	if err := l.resume(false); err != nil {
+		t.Fatal(err)
+	}
+	<-replaying
+
+	// pause waits for the event in replay
+	paused := make(chan bool)
+	go func() { paused <- l.pause("again") }()
+	select {
+	case <-paused:
+		t.Fatal("pause returned before the replayed event finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if !<-paused {
+		t.Fatal("pause while draining should succeed")
+	}
+
+	status := l.current()
+	if status.State != LIFECYCLE_PAUSED || status.Buffered != 2 {
+		t.Fatalf("expected paused with 2 buffered events, got %+v", status)
+	}
+	if err := l.resume(false); err != nil {
+		t.Fatal(err)
+	}
+	waitLifecycleState(t, l, LIFECYCLE_RUNNING)
+	if blocks := r.handled(); !reflect.DeepEqual(blocks, []int64{1, 2, 3}) {
+		t.Fatalf("events handled out of order: %v", blocks)
+	}
+}
+
+func TestLifecycleDropForkedEvents(t *testing.T) {
+	r := &recorder{}
+	l := newLifecycle(10, func() {}, func() {})
+	handle := l.dispatch(r.handle)
+	l.start()
+	l.pause("test")
+
+	handle(filledAt(9))
+	handle(filledAt(11))
+	handle(&types.OrderState{})
+	handle(filledAt(10))
+	handle(filledAt(12))
+
+	if dropped := l.dropForkedEvents(10); dropped != 2 {
+		t.Fatalf("expected 2 events dropped, got %d", dropped)
+	}
+	if err := l.resume(false); err != nil {
+		t.Fatal(err)
+	}
+	waitLifecycleState(t, l, LIFECYCLE_RUNNING)
+	// the order submitted isn't mined and is kept
+	if blocks := r.handled(); !reflect.DeepEqual(blocks, []int64{9, 0, 10}) {
+		t.Fatalf("unexpected replayed events: %v", blocks)
+	}
+}
@@ -19,18 +19,25 @@
 package manager
 
 import (
+	"fmt"
 	"github.com/Loopring/relay-cluster/dao"
+	"github.com/Loopring/relay-cluster/market"
 	"github.com/Loopring/relay-cluster/ordermanager/cache"
 	"github.com/Loopring/relay-cluster/ordermanager/common"
 	"github.com/Loopring/relay-lib/eventemitter"
 	"github.com/Loopring/relay-lib/log"
 	"github.com/Loopring/relay-lib/marketcap"
+	"github.com/Loopring/relay-lib/sns"
 	"github.com/Loopring/relay-lib/types"
+	"time"
 )
 
 type OrderManager interface {
 	Start()
 	Stop()
+	Status() LifecycleStatus
+	Pause(reason string) bool
+	Resume(resynced bool) error
 }
 
 type OrderManagerImpl struct {
@@ -52,8 +59,22 @@ type OrderManagerImpl struct {
 	forkWatcher                *eventemitter.Watcher
 	warningWatcher             *eventemitter.Watcher
 	submitRingMethodWatcher    *eventemitter.Watcher
+	expirySweeper              *expirySweeper
+	reconciler                 *reconciler
+	orderScheduler             *orderScheduler
+	stopOrderTrigger           *stopOrderTrigger
+	dustSweeper                *dustSweeper
+	lifecycle                  *lifecycle
+	shards                     *shardPool
+	admin                      *adminServer
 }
 
+const (
+	forkRetryTimes    = 3
+	forkRetryInterval = 3 * time.Second
+	forkFailedTitle   = "order manager handle fork failed"
+)
+
 var (
 	rds               *dao.RdsService
 	marketCapProvider marketcap.MarketCapProvider
@@ -64,13 +85,23 @@ func NewOrderManager(
 	options *common.OrderManagerOptions,
 	db *dao.RdsService,
 	market marketcap.MarketCapProvider,
+	trend *market.TrendManager,
 	brokers []string) *OrderManagerImpl {
 
 	om := &OrderManagerImpl{}
 	om.options = options
 	om.brokers = brokers
 	om.processor = NewForkProcess()
+	om.expirySweeper = newExpirySweeper(options)
+	om.reconciler = newReconciler(options)
+	om.orderScheduler = newOrderScheduler(options)
+	om.stopOrderTrigger = newStopOrderTrigger(options, trend)
+	om.dustSweeper = newDustSweeper(options)
+	om.shards = newShardPool(options.EventShards, options.EventQueueSize)
+	om.lifecycle = newLifecycle(options.PauseBufferSize, om.onRunning, om.onPaused)
+	om.admin = newAdminServer(options.AdminListen, om)
 	cutoffcache = common.NewCutoffCache(options.CutoffCacheCleanTime)
+	initMinerPolicies(options.MinerPolicies)
 
 	marketCapProvider = market
 	rds = db
@@ -85,21 +116,21 @@ func NewOrderManager(
 // Start start orderbook as a service
 func (om *OrderManagerImpl) Start() {
 	// order related
-	om.newOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.submitRingMethodWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.ringMinedWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.fillOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.cancelOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.cutoffOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
-	om.cutoffPairWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandlerOrderRelatedEvent}
+	om.newOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.submitRingMethodWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.ringMinedWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.fillOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.cancelOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.cutoffOrderWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
+	om.cutoffPairWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandlerOrderRelatedEvent))}
 
 	// order correlated
-	om.approveWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
-	om.depositWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
-	om.withdrawalWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
-	om.transferWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
-	om.ethTransferWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
-	om.unsupportedContractWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.HandleOrderCorrelatedEvent}
+	om.approveWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
+	om.depositWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
+	om.withdrawalWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
+	om.transferWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
+	om.ethTransferWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
+	om.unsupportedContractWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.lifecycle.dispatch(om.shards.dispatch(om.HandleOrderCorrelatedEvent))}
 
 	// procedure related
 	om.forkWatcher = &eventemitter.Watcher{Concurrent: false, Handle: om.handleFork}
@@ -122,6 +153,9 @@ func (om *OrderManagerImpl) Start() {
 
 	eventemitter.On(eventemitter.ChainForkDetected, om.forkWatcher)
 	eventemitter.On(eventemitter.ExtractorWarning, om.warningWatcher)
+
+	om.lifecycle.start()
+	om.admin.start()
 }
 
 func (om *OrderManagerImpl) Stop() {
@@ -133,32 +167,94 @@ func (om *OrderManagerImpl) Stop() {
 	eventemitter.Un(eventemitter.CutoffAll, om.cutoffOrderWatcher)
 	eventemitter.Un(eventemitter.CutoffPair, om.cutoffPairWatcher)
 
-	eventemitter.On(eventemitter.Approve, om.approveWatcher)
-	eventemitter.On(eventemitter.WethDeposit, om.depositWatcher)
-	eventemitter.On(eventemitter.WethWithdrawal, om.withdrawalWatcher)
-	eventemitter.On(eventemitter.Transfer, om.transferWatcher)
-	eventemitter.On(eventemitter.EthTransfer, om.ethTransferWatcher)
-	eventemitter.On(eventemitter.UnsupportedContract, om.unsupportedContractWatcher)
+	eventemitter.Un(eventemitter.Approve, om.approveWatcher)
+	eventemitter.Un(eventemitter.WethDeposit, om.depositWatcher)
+	eventemitter.Un(eventemitter.WethWithdrawal, om.withdrawalWatcher)
+	eventemitter.Un(eventemitter.Transfer, om.transferWatcher)
+	eventemitter.Un(eventemitter.EthTransfer, om.ethTransferWatcher)
+	eventemitter.Un(eventemitter.UnsupportedContract, om.unsupportedContractWatcher)
 
 	eventemitter.Un(eventemitter.ChainForkDetected, om.forkWatcher)
 	eventemitter.Un(eventemitter.ExtractorWarning, om.warningWatcher)
+
+	om.lifecycle.stop()
+	om.admin.stop()
+}
+
+// Status returns the lifecycle state of the order manager.
+func (om *OrderManagerImpl) Status() LifecycleStatus {
+	return om.lifecycle.current()
+}
+
+// Pause buffers order events until Resume, it returns false if the order manager isn't running.
+func (om *OrderManagerImpl) Pause(reason string) bool {
+	return om.lifecycle.pause(reason)
+}
+
+// Resume replays the events buffered while paused and runs again, resynced confirms the orders are resynced
+// after events were dropped.
+func (om *OrderManagerImpl) Resume(resynced bool) error {
+	return om.lifecycle.resume(resynced)
+}
+
+// onRunning and onPaused keep the jobs updating orders in step with event processing.
+func (om *OrderManagerImpl) onRunning() {
+	om.expirySweeper.start()
+	om.reconciler.start()
+	om.orderScheduler.start()
+	om.stopOrderTrigger.start()
+	om.dustSweeper.start()
+}
+
+// onPaused returns after the events queued in shards are processed as well.
+func (om *OrderManagerImpl) onPaused() {
+	om.shards.wait()
+	om.expirySweeper.stop()
+	om.reconciler.stop()
+	om.orderScheduler.stop()
+	om.stopOrderTrigger.stop()
+	om.dustSweeper.stop()
 }
 
 func (om *OrderManagerImpl) handleFork(input eventemitter.EventData) error {
 	log.Debugf("order manager processing chain fork......")
 
-	om.Stop()
-	if err := om.processor.Fork(input.(*types.ForkedEvent)); err != nil {
-		log.Fatalf("order manager,handle fork error:%s", err.Error())
+	if om.lifecycle.current().State == LIFECYCLE_STOPPED {
+		return nil
 	}
-	om.Start()
+	// an order manager already paused(eg. by extractor warning) keeps paused after the fork
+	paused := om.lifecycle.pause(LIFECYCLE_REASON_FORK)
 
-	return nil
+	// fork is processed in a db transaction, a failed try leaves nothing rolled back and can be retried
+	event := input.(*types.ForkedEvent)
+	var err error
+	for i := 0; i < forkRetryTimes; i++ {
+		if err = om.processor.Fork(event); err == nil {
+			// events of orphaned blocks buffered meanwhile, or before the fork if already paused, are rolled back
+			if dropped := om.lifecycle.dropForkedEvents(event.ForkBlock.Int64()); dropped > 0 {
+				log.Infof("order manager, %d buffered events of forked blocks dropped", dropped)
+			}
+			if paused {
+				return om.lifecycle.resume(false)
+			}
+			return nil
+		}
+		log.Errorf("order manager,handle fork from:%s to:%s, try:%d error:%s", event.ForkBlock.String(), event.DetectedBlock.String(), i+1, err.Error())
+		time.Sleep(forkRetryInterval)
+	}
+
+	// events are buffered until the fork is fixed and the order manager is resumed by admin
+	msg := fmt.Sprintf("fork from:%s to:%s error:%s, order manager keeps paused", event.ForkBlock.String(), event.DetectedBlock.String(), err.Error())
+	if err := sns.PublishSns(forkFailedTitle, msg); err != nil {
+		log.Error(err.Error())
+	}
+	return err
 }
 
+// handleWarning pauses the order manager until it's resumed by admin, events are buffered meanwhile.
 func (om *OrderManagerImpl) handleWarning(input eventemitter.EventData) error {
 	log.Debugf("order manager processing extractor warning")
-	om.Stop()
+	om.lifecycle.pause(LIFECYCLE_REASON_WARNING)
 	return nil
 }
 
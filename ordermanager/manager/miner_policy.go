@@ -0,0 +1,228 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-cluster/accountmanager"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	"github.com/Loopring/relay-lib/log"
+	util "github.com/Loopring/relay-lib/marketutil"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	MINER_EXCLUDED_OWNER_CAP            = "owner_cap"
+	MINER_EXCLUDED_INSUFFICIENT_BALANCE = "insufficient_balance"
+	MINER_EXCLUDED_BEYOND_LENGTH        = "beyond_length"
+
+	defaultMinerCandidateFactor = 1
+)
+
+// MinerOrderRank is the ranking of an order for miners, Excluded is the reason why it isn't provided.
+type MinerOrderRank struct {
+	OrderHash   common.Hash    `json:"orderHash"`
+	Owner       common.Address `json:"owner"`
+	Score       float64        `json:"score"`
+	PriceScore  float64        `json:"priceScore"`
+	LrcFeeScore float64        `json:"lrcFeeScore"`
+	AgeScore    float64        `json:"ageScore"`
+	Excluded    string         `json:"excluded"`
+}
+
+// minerPolicies are keyed by market, the default policy is keyed by empty string.
+var minerPolicies map[string]omcm.MinerPolicyOptions
+
+// orders are provided in price-time priority as before without any policy
+var defaultMinerPolicy = omcm.MinerPolicyOptions{PriceWeight: 1, CandidateFactor: defaultMinerCandidateFactor}
+
+func initMinerPolicies(list []omcm.MinerPolicyOptions) {
+	minerPolicies = make(map[string]omcm.MinerPolicyOptions)
+	for _, v := range list {
+		v.Market = strings.ToUpper(v.Market)
+		if v.CandidateFactor <= 0 {
+			v.CandidateFactor = defaultMinerCandidateFactor
+		}
+		minerPolicies[v.Market] = v
+	}
+}
+
+func getMinerPolicy(tokenS, tokenB common.Address) omcm.MinerPolicyOptions {
+	if market, err := util.WrapMarketByAddress(tokenS.Hex(), tokenB.Hex()); err == nil {
+		if policy, ok := minerPolicies[market]; ok {
+			return policy
+		}
+	}
+	if policy, ok := minerPolicies[""]; ok {
+		return policy
+	}
+	return defaultMinerPolicy
+}
+
+type minerCandidate struct {
+	state *types.OrderState
+	rank  MinerOrderRank
+	price float64
+}
+
+// rankMinerOrders scores the candidates by policy and returns them in ranking order, the first length orders
+// not excluded are provided to miners. Balances are only checked until length orders are selected if lazy.
+func rankMinerOrders(policy omcm.MinerPolicyOptions, states []*types.OrderState, length int, lazy bool) []*minerCandidate {
+	now := time.Now().Unix()
+	candidates := make([]*minerCandidate, 0, len(states))
+	prices := make([]float64, 0, len(states))
+	fees := make([]float64, 0, len(states))
+	ages := make([]float64, 0, len(states))
+	for _, state := range states {
+		c := &minerCandidate{state: state}
+		c.rank.OrderHash = state.RawOrder.Hash
+		c.rank.Owner = state.RawOrder.Owner
+		if state.RawOrder.Price != nil {
+			c.price, _ = state.RawOrder.Price.Float64()
+		}
+		candidates = append(candidates, c)
+		prices = append(prices, c.price)
+		if policy.LrcFeeWeight != 0 {
+			fees = append(fees, lrcFeePerValue(state))
+		} else {
+			fees = append(fees, 0)
+		}
+		ages = append(ages, float64(now-state.RawOrder.CreateTime))
+	}
+
+	normalize(prices)
+	normalize(fees)
+	normalize(ages)
+	for i, c := range candidates {
+		c.rank.PriceScore = prices[i]
+		c.rank.LrcFeeScore = fees[i]
+		c.rank.AgeScore = ages[i]
+		c.rank.Score = policy.PriceWeight*prices[i] + policy.LrcFeeWeight*fees[i] + policy.AgeWeight*ages[i]
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank.Score != b.rank.Score {
+			return a.rank.Score > b.rank.Score
+		}
+		if a.price != b.price {
+			return a.price > b.price
+		}
+		return a.state.RawOrder.CreateTime < b.state.RawOrder.CreateTime
+	})
+
+	selected := 0
+	owners := make(map[common.Address]int)
+	for _, c := range candidates {
+		if selected >= length {
+			c.rank.Excluded = MINER_EXCLUDED_BEYOND_LENGTH
+			if lazy {
+				continue
+			}
+		}
+		if policy.MaxOrdersPerOwner > 0 && owners[c.rank.Owner] >= policy.MaxOrdersPerOwner {
+			c.rank.Excluded = MINER_EXCLUDED_OWNER_CAP
+			continue
+		}
+		if policy.CheckBalance && !hasAvailableAmount(policy, c.state) {
+			c.rank.Excluded = MINER_EXCLUDED_INSUFFICIENT_BALANCE
+			continue
+		}
+		if c.rank.Excluded == MINER_EXCLUDED_BEYOND_LENGTH {
+			continue
+		}
+		owners[c.rank.Owner]++
+		selected++
+	}
+
+	return candidates
+}
+
+// lrcFeePerValue is the value of lrcFee divided by the value of the order, it's 0 if any value is unknown.
+func lrcFeePerValue(state *types.OrderState) float64 {
+	if state.RawOrder.LrcFee == nil || state.RawOrder.LrcFee.Sign() <= 0 {
+		return 0
+	}
+	feeValue, err := marketCapProvider.LegalCurrencyValue(util.AllTokens["LRC"].Protocol, new(big.Rat).SetInt(state.RawOrder.LrcFee))
+	if err != nil {
+		return 0
+	}
+
+	var orderValue *big.Rat
+	if marketCapProvider.IsSupport(state.RawOrder.TokenS) {
+		orderValue, err = marketCapProvider.LegalCurrencyValue(state.RawOrder.TokenS, new(big.Rat).SetInt(state.RawOrder.AmountS))
+	} else {
+		orderValue, err = marketCapProvider.LegalCurrencyValue(state.RawOrder.TokenB, new(big.Rat).SetInt(state.RawOrder.AmountB))
+	}
+	if err != nil || orderValue.Sign() <= 0 {
+		return 0
+	}
+
+	ratio, _ := new(big.Rat).Quo(feeValue, orderValue).Float64()
+	return ratio
+}
+
+// hasAvailableAmount returns false if the available balance/allowance of tokenS can't fill the order as required,
+// the order is kept when the balance is unknown.
+func hasAvailableAmount(policy omcm.MinerPolicyOptions, state *types.OrderState) bool {
+	balance, allowance, err := accountmanager.GetBalanceAndAllowance(state.RawOrder.Owner, state.RawOrder.TokenS, state.RawOrder.DelegateAddress)
+	if err != nil || balance == nil || allowance == nil {
+		log.Debugf("order manager, miner policy get balance of owner:%s token:%s failed", state.RawOrder.Owner.Hex(), state.RawOrder.TokenS.Hex())
+		return true
+	}
+
+	available := balance
+	if allowance.Cmp(balance) < 0 {
+		available = allowance
+	}
+	if available.Sign() <= 0 {
+		return false
+	}
+
+	remainedAmountS, _ := state.RemainedAmount()
+	required := new(big.Rat).Mul(remainedAmountS, new(big.Rat).SetFloat64(policy.MinAvailableRatio))
+	return new(big.Rat).SetInt(available).Cmp(required) >= 0
+}
+
+// normalize scales values to [0,1] by the min and max of them, all are 0 if they're equal.
+func normalize(values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	for i, v := range values {
+		if max == min {
+			values[i] = 0
+		} else {
+			values[i] = (v - min) / (max - min)
+		}
+	}
+}
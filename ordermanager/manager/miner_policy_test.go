@@ -0,0 +1,122 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		values []float64
+		want   []float64
+	}{
+		{[]float64{}, []float64{}},
+		{[]float64{3}, []float64{0}},
+		{[]float64{2, 2, 2}, []float64{0, 0, 0}},
+		{[]float64{1, 3, 2}, []float64{0, 1, 0.5}},
+		{[]float64{-1, 1, 0}, []float64{0, 1, 0.5}},
+	}
+	for _, c := range cases {
+		values := append([]float64{}, c.values...)
+		normalize(values)
+		if !reflect.DeepEqual(values, c.want) {
+			t.Errorf("normalize %v, got %v, want %v", c.values, values, c.want)
+		}
+	}
+}
+
+func minerOrder(seq byte, owner byte, price float64, age int64) *types.OrderState {
+	state := &types.OrderState{}
+	state.RawOrder.Hash = common.BytesToHash([]byte{seq})
+	state.RawOrder.Owner = common.BytesToAddress([]byte{owner})
+	state.RawOrder.Price = new(big.Rat).SetFloat64(price)
+	state.RawOrder.CreateTime = time.Now().Unix() - age
+	return state
+}
+
+func rankedOrders(candidates []*minerCandidate) (hashes []byte, excluded []string) {
+	for _, c := range candidates {
+		hashes = append(hashes, c.rank.OrderHash[common.HashLength-1])
+		excluded = append(excluded, c.rank.Excluded)
+	}
+	return hashes, excluded
+}
+
+func TestRankMinerOrders(t *testing.T) {
+	states := []*types.OrderState{
+		minerOrder(1, 1, 0.5, 10),
+		minerOrder(2, 1, 0.7, 20),
+		minerOrder(3, 2, 0.5, 30),
+		minerOrder(4, 1, 0.6, 1000),
+		minerOrder(5, 3, 0.7, 5),
+	}
+
+	cases := []struct {
+		name     string
+		policy   omcm.MinerPolicyOptions
+		length   int
+		lazy     bool
+		hashes   []byte
+		excluded []string
+	}{
+		{
+			name:     "price-time priority by default",
+			policy:   defaultMinerPolicy,
+			length:   3,
+			hashes:   []byte{2, 5, 4, 3, 1},
+			excluded: []string{"", "", "", MINER_EXCLUDED_BEYOND_LENGTH, MINER_EXCLUDED_BEYOND_LENGTH},
+		},
+		{
+			name:     "age outweighs price",
+			policy:   omcm.MinerPolicyOptions{PriceWeight: 1, AgeWeight: 50},
+			length:   5,
+			hashes:   []byte{4, 2, 3, 5, 1},
+			excluded: []string{"", "", "", "", ""},
+		},
+		{
+			name:     "orders of an owner capped",
+			policy:   omcm.MinerPolicyOptions{PriceWeight: 1, MaxOrdersPerOwner: 1},
+			length:   3,
+			hashes:   []byte{2, 5, 4, 3, 1},
+			excluded: []string{"", "", MINER_EXCLUDED_OWNER_CAP, "", MINER_EXCLUDED_OWNER_CAP},
+		},
+		{
+			name:     "orders beyond length aren't checked if lazy",
+			policy:   omcm.MinerPolicyOptions{PriceWeight: 1, MaxOrdersPerOwner: 1},
+			length:   1,
+			lazy:     true,
+			hashes:   []byte{2, 5, 4, 3, 1},
+			excluded: []string{"", MINER_EXCLUDED_BEYOND_LENGTH, MINER_EXCLUDED_BEYOND_LENGTH, MINER_EXCLUDED_BEYOND_LENGTH, MINER_EXCLUDED_BEYOND_LENGTH},
+		},
+	}
+
+	for _, c := range cases {
+		hashes, excluded := rankedOrders(rankMinerOrders(c.policy, states, c.length, c.lazy))
+		if !reflect.DeepEqual(hashes, c.hashes) || !reflect.DeepEqual(excluded, c.excluded) {
+			t.Errorf("%s: got %v %q, want %v %q", c.name, hashes, excluded, c.hashes, c.excluded)
+		}
+	}
+}
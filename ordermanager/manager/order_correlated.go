@@ -195,7 +195,7 @@ func (handler *OrderTxHandler) setOrderStatus(list []omtyp.OrderTx) error {
 			return nil
 		}
 		SettleOrderStatus(state, false)
-		return rds.UpdateOrderStatus(event.OrderHash, state.Status)
+		return handler.updateOrderStatus(state)
 	}
 
 	// order owner cancelling/cutoffing
@@ -204,7 +204,7 @@ func (handler *OrderTxHandler) setOrderStatus(list []omtyp.OrderTx) error {
 			return nil
 		}
 		state.Status = list[0].OrderStatus
-		return rds.UpdateOrderStatus(event.OrderHash, state.Status)
+		return handler.updateOrderStatus(state)
 	}
 
 	// miner submit ring pending
@@ -212,12 +212,21 @@ func (handler *OrderTxHandler) setOrderStatus(list []omtyp.OrderTx) error {
 		if omcm.IsPendingStatus(state.Status) {
 			return nil
 		}
-		return rds.UpdateOrderStatus(event.OrderHash, list[0].OrderStatus)
+		state.Status = list[0].OrderStatus
+		return handler.updateOrderStatus(state)
 	}
 
 	return nil
 }
 
+func (handler *OrderTxHandler) updateOrderStatus(state *types.OrderState) error {
+	if err := rds.UpdateOrderStatus(handler.Event.OrderHash, state.Status); err != nil {
+		return err
+	}
+	saveOrderHistory(state, HISTORY_EVT_TYPE_PENDING_TX, handler.Event.TxHash)
+	return nil
+}
+
 func (handler *OrderTxHandler) fullFilled(orderhash common.Hash) {
 	handler.Event.OrderHash = orderhash
 }
@@ -0,0 +1,130 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"sort"
+)
+
+const (
+	ORDER_GROUP_MIN_SIZE = 2
+	ORDER_GROUP_MAX_SIZE = 10
+)
+
+// LinkOrderGroup links new orders of the owner into an one-cancels-other group and returns the group id,
+// which is the hash of the sorted order hashes. Members must be of the same owner, so that their fills are
+// processed in the same event shard.
+func LinkOrderGroup(owner common.Address, orderhashList []common.Hash) (common.Hash, error) {
+	if types.IsZeroAddress(owner) {
+		return types.NilHash, fmt.Errorf("params owner invalid")
+	}
+	if len(orderhashList) < ORDER_GROUP_MIN_SIZE || len(orderhashList) > ORDER_GROUP_MAX_SIZE {
+		return types.NilHash, fmt.Errorf("params orderhash list invalid, length should be in [%d, %d]", ORDER_GROUP_MIN_SIZE, ORDER_GROUP_MAX_SIZE)
+	}
+
+	hashes := make([]common.Hash, len(orderhashList))
+	copy(hashes, orderhashList)
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i].Bytes(), hashes[j].Bytes()) < 0
+	})
+	for i, v := range hashes {
+		if types.IsZeroHash(v) || (i > 0 && v == hashes[i-1]) {
+			return types.NilHash, fmt.Errorf("params orderhash:%s invalid or duplicate", v.Hex())
+		}
+	}
+
+	models, err := rds.GetOrdersByHashes(hashes)
+	if err != nil {
+		return types.NilHash, err
+	}
+	if len(models) != len(hashes) {
+		return types.NilHash, fmt.Errorf("order not exist")
+	}
+	for _, v := range models {
+		if common.HexToAddress(v.Owner) != owner {
+			return types.NilHash, fmt.Errorf("order:%s isn't owned by %s", v.OrderHash, owner.Hex())
+		}
+		// a filled order would have triggered the group already
+		if types.OrderStatus(v.Status) != types.ORDER_NEW {
+			return types.NilHash, fmt.Errorf("order:%s status invalid, only new orders can be linked", v.OrderHash)
+		}
+	}
+
+	groups, err := rds.GetOrderGroupsByOrderHashes(hashes)
+	if err != nil {
+		return types.NilHash, err
+	}
+	if len(groups) > 0 {
+		return types.NilHash, fmt.Errorf("order:%s is linked in group:%s already", groups[0].OrderHash, groups[0].GroupId)
+	}
+
+	var data [][]byte
+	for _, v := range hashes {
+		data = append(data, v.Bytes())
+	}
+	groupId := crypto.Keccak256Hash(data...)
+	if err := rds.AddOrderGroup(groupId, owner, hashes); err != nil {
+		return types.NilHash, err
+	}
+
+	log.Debugf("order manager, owner:%s linked %d orders in group:%s", owner.Hex(), len(hashes), groupId.Hex())
+	return groupId, nil
+}
+
+// triggerOrderGroup flex-cancels the other members once any member of the group is filled, the group is
+// triggered only once. Cancelled members are pushed to the owner and removed from order books, and they're
+// not provided to miners any more. If the fill is rolled back by fork, the group is reset and the members
+// cancelled by it are restored, see forkRollback.RollBackOrderGroup.
+func triggerOrderGroup(state *types.OrderState, txhash common.Hash) {
+	group, err := rds.GetOrderGroupByOrderHash(state.RawOrder.Hash)
+	if err != nil {
+		return
+	}
+	if nums, err := rds.TriggerOrderGroup(group.GroupId, state.RawOrder.Hash, txhash); err != nil || nums == 0 {
+		return
+	}
+
+	members, err := rds.GetOrderGroupMembers(group.GroupId)
+	if err != nil {
+		log.Errorf("order manager, get members of order group:%s error:%s", group.GroupId, err.Error())
+		return
+	}
+	var others []common.Hash
+	for _, v := range members {
+		if orderhash := common.HexToHash(v.OrderHash); orderhash != state.RawOrder.Hash {
+			others = append(others, orderhash)
+		}
+	}
+
+	orderhashList := rds.FlexCancelOrderByHashes(state.RawOrder.Owner, others, omcm.ValidFlexCancelStatus, types.ORDER_FLEX_CANCEL)
+	if err := rds.SetOrderGroupCancelled(group.GroupId, orderhashList); err != nil {
+		log.Errorf("order manager, mark cancelled members of order group:%s error:%s", group.GroupId, err.Error())
+	}
+	log.Debugf("order manager, order group:%s triggered by order:%s tx:%s, %d orders cancelled", group.GroupId, state.RawOrder.Hash.Hex(), txhash.Hex(), len(orderhashList))
+
+	saveOrdersHistory(orderhashList, HISTORY_EVT_TYPE_OCO_CANCEL, txhash)
+	notifyOrdersUpdate(orderhashList)
+}
@@ -42,6 +42,7 @@ func HandleGatewayOrder(state *types.OrderState) error {
 	}
 
 	log.Debugf("order manager,handle gateway order,order.hash:%s amountS:%s", state.RawOrder.Hash.Hex(), state.RawOrder.AmountS.String())
+	saveOrderHistory(state, HISTORY_EVT_TYPE_NEW, types.NilHash)
 
 	return notify.NotifyOrderUpdate(state)
 }
@@ -151,6 +152,7 @@ func HandleOrderFilledEvent(event *types.OrderFilledEvent) error {
 	if err := rds.UpdateOrderWhileFill(state.RawOrder.Hash, state.Status, state.DealtAmountS, state.DealtAmountB, state.SplitAmountS, state.SplitAmountB, state.UpdatedBlock); err != nil {
 		return err
 	}
+	saveOrderHistory(state, HISTORY_EVT_TYPE_FILL, event.TxHash)
 
 	// update orderTx
 	txhandler := FullOrderTxHandler(event.TxInfo, state.RawOrder.Hash, types.ORDER_PENDING)
@@ -158,7 +160,9 @@ func HandleOrderFilledEvent(event *types.OrderFilledEvent) error {
 
 	log.Debugf("order manager fillHandler, tx:%s, fillIndex:%s, orderhash:%s, dealAmountS:%s, dealtAmountB:%s", event.TxHash.Hex(), event.FillIndex.String(), event.OrderHash.Hex(), state.DealtAmountS.String(), state.DealtAmountB.String())
 
+	notify.NotifyOrderUpdate(state)
 	notify.NotifyOrderFilled(newFillModel)
+	triggerOrderGroup(state, event.TxHash)
 
 	// 只需发送一次
 	if event.FillIndex.Int64() == 0 {
@@ -230,6 +234,7 @@ func HandleOrderCancelledEvent(event *types.OrderCancelledEvent) error {
 	if err := rds.UpdateOrderWhileCancel(state.RawOrder.Hash, state.Status, state.CancelledAmountS, state.CancelledAmountB, state.UpdatedBlock); err != nil {
 		return err
 	}
+	saveOrderHistory(state, HISTORY_EVT_TYPE_CANCEL, event.TxHash)
 
 	// process pending order status
 	if err := txhandler.HandlerOrderRelatedTx(); err != nil {
@@ -278,6 +283,7 @@ func HandleCutoffEvent(event *types.CutoffEvent) error {
 
 		cutoffcache.UpdateCutoff(event.Protocol, event.Owner, event.Cutoff)
 		rds.SetCutOffOrders(orderhashList, event.BlockNumber)
+		saveOrdersHistory(orderhashList, HISTORY_EVT_TYPE_CUTOFF, event.TxHash)
 
 		notify.NotifyCutoff(event)
 	}
@@ -331,6 +337,7 @@ func HandleCutoffPair(event *types.CutoffPairEvent) error {
 
 		cutoffcache.UpdateCutoffPair(event.Protocol, event.Owner, event.Token1, event.Token2, event.Cutoff)
 		rds.SetCutOffOrders(orderhashlist, event.BlockNumber)
+		saveOrdersHistory(orderhashlist, HISTORY_EVT_TYPE_CUTOFF_PAIR, event.TxHash)
 
 		notify.NotifyCutoffPair(event)
 	}
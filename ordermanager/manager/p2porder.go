@@ -39,6 +39,7 @@ type P2pOrderRelation struct {
 	Makerorderhash string
 	Takerorderhash string
 	PendingAmount  string
+	ValidUntil     int64
 }
 
 func init() {
@@ -63,7 +64,7 @@ func SaveP2POrderRelation(takerOwner, taker, makerOwner, maker, txHash, pendingA
 	cache.ZAdd(p2pTakerPreKey+maker, takerExpiredTime, []byte(strconv.FormatInt(nowTime, 10)), []byte(txHash+splitMark+pendingAmount))
 
 	//save txhash,maker,taker relations
-	p2pOrderRelationStr, _ := GetP2pOrderRelation(maker, taker, txHash, pendingAmount)
+	p2pOrderRelationStr, _ := GetP2pOrderRelation(maker, taker, txHash, pendingAmount, untilTime)
 	cache.Set(p2pRelationPreKey+txHash, p2pOrderRelationStr, takerExpiredTime)
 	cache.Set(p2pRelationPreKey+taker, []byte(txHash), takerExpiredTime)
 
@@ -121,15 +122,41 @@ func GetP2PPendingAmount(maker string) (pendingAmount *big.Rat, err error) {
 	}
 }
 
-func GetP2pOrderRelation(maker, taker, txHash, pendingAmount string) ([]byte, error) {
+func GetP2pOrderRelation(maker, taker, txHash, pendingAmount string, validUntil int64) ([]byte, error) {
 	var p2pOrderRelation P2pOrderRelation
 	p2pOrderRelation.Makerorderhash = maker
 	p2pOrderRelation.Takerorderhash = taker
 	p2pOrderRelation.Txhash = txHash
 	p2pOrderRelation.PendingAmount = pendingAmount
+	p2pOrderRelation.ValidUntil = validUntil
 	return json.Marshal(p2pOrderRelation)
 }
 
+// RollBackP2POrderFilled locks the pending amount of maker and the taker again when the filled p2p ring is forked,
+// rings not p2p or whose maker has expired are ignored.
+func RollBackP2POrderFilled(txHash string) error {
+	txHash = strings.ToLower(txHash)
+	jsonStr, err := cache.Get(p2pRelationPreKey + txHash)
+	if err != nil || len(jsonStr) == 0 {
+		return nil
+	}
+	p2pOrderRelation := P2pOrderRelation{}
+	if err := json.Unmarshal(jsonStr, &p2pOrderRelation); nil != err {
+		return err
+	}
+
+	nowTime := time.Now().Unix()
+	takerExpiredTime := p2pOrderRelation.ValidUntil - nowTime
+	if takerExpiredTime <= 0 {
+		return nil
+	}
+	maker := p2pOrderRelation.Makerorderhash
+	if err := cache.ZAdd(p2pTakerPreKey+maker, takerExpiredTime, []byte(strconv.FormatInt(nowTime, 10)), []byte(txHash+splitMark+p2pOrderRelation.PendingAmount)); err != nil {
+		return err
+	}
+	return cache.Set(p2pRelationPreKey+p2pOrderRelation.Takerorderhash, []byte(txHash), takerExpiredTime)
+}
+
 func IsP2PTakerLocked(taker string) bool {
 	exist, err := cache.Exists(p2pRelationPreKey + strings.ToLower(taker))
 	if err != nil || exist == true {
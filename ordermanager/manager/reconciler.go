@@ -0,0 +1,276 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Loopring/relay-cluster/dao"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	notify "github.com/Loopring/relay-cluster/util"
+	"github.com/Loopring/relay-lib/cloudwatch"
+	"github.com/Loopring/relay-lib/eth/accessor"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/sns"
+	"github.com/Loopring/relay-lib/types"
+	"math/big"
+	"time"
+)
+
+const (
+	reconcilerZkLock      = "orderManagerReconcilerZkLock"
+	reconcilerReportTitle = "order manager reconciler corrected orders"
+
+	defaultReconcileInterval      = 600
+	defaultReconcileBatchSize     = 200
+	defaultReconcileConfirmations = 12
+
+	metricOrderReconcileCorrected = "order_reconcile_corrected"
+	metricOrderReconcileFailed    = "order_reconcile_failed"
+)
+
+// errReconcileSkipped means the order is updated after the compared block, it's checked again in next round.
+var errReconcileSkipped = errors.New("order updated after the reconciled block")
+
+// reconcileCorrection is an order whose amounts in db differed from the contract.
+type reconcileCorrection struct {
+	OrderHash    string           `json:"orderHash"`
+	Owner        string           `json:"owner"`
+	StatusBefore uint8            `json:"statusBefore"`
+	StatusAfter  uint8            `json:"statusAfter"`
+	Before       reconciledAmount `json:"before"`
+	After        reconciledAmount `json:"after"`
+}
+
+// reconciledAmount is the dealt and cancelled amounts of both sides of an order.
+type reconciledAmount struct {
+	DealtAmountS     string `json:"dealtAmountS"`
+	DealtAmountB     string `json:"dealtAmountB"`
+	CancelledAmountS string `json:"cancelledAmountS"`
+	CancelledAmountB string `json:"cancelledAmountB"`
+}
+
+// reconcileReport is the result of a single reconciliation, it's logged after every run
+// and published by sns when any order is corrected.
+type reconcileReport struct {
+	BlockNumber int64                 `json:"blockNumber"`
+	StartTime   int64                 `json:"startTime"`
+	EndTime     int64                 `json:"endTime"`
+	Scanned     int                   `json:"scanned"`
+	Skipped     int                   `json:"skipped"`
+	Failed      int                   `json:"failed"`
+	Corrections []reconcileCorrection `json:"corrections"`
+}
+
+// reconciler compares amounts of valid orders in db with the contract and repairs the differences,
+// which are caused by missed events or failed handlers. Only the node holding the zklock reconciles,
+// every run checks a batch of orders after the cursor and the cursor restarts when all orders are checked.
+type reconciler struct {
+	*zkCronJob
+	batchSize     int
+	confirmations int64
+	cursor        int
+}
+
+func newReconciler(options *omcm.OrderManagerOptions) *reconciler {
+	r := &reconciler{batchSize: options.ReconcileBatchSize, confirmations: options.ReconcileConfirmations}
+	r.zkCronJob = &zkCronJob{name: "reconciler", lockName: reconcilerZkLock, interval: options.ReconcileInterval, run: r.reconcile}
+	if r.interval == 0 {
+		r.interval = defaultReconcileInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultReconcileBatchSize
+	}
+	if r.confirmations <= 0 {
+		r.confirmations = defaultReconcileConfirmations
+	}
+	return r
+}
+
+func (r *reconciler) reconcile() {
+	if r.isPaused() {
+		return
+	}
+
+	var latest types.Big
+	if err := accessor.BlockNumber(&latest); err != nil {
+		log.Errorf("order manager, reconciler get block number error:%s", err.Error())
+		return
+	}
+	// orders are compared at a confirmed block, events after it may not be handled yet
+	blockNumber := new(big.Int).Sub(latest.BigInt(), big.NewInt(r.confirmations))
+	if blockNumber.Sign() <= 0 {
+		return
+	}
+
+	orders, err := rds.GetOrdersAfterId(r.cursor, omcm.ValidReconcileStatus, r.batchSize)
+	if err != nil {
+		log.Errorf("order manager, reconciler get orders error:%s", err.Error())
+		return
+	}
+	if len(orders) < r.batchSize {
+		r.cursor = 0
+	} else {
+		r.cursor = orders[len(orders)-1].ID
+	}
+
+	report := &reconcileReport{BlockNumber: blockNumber.Int64(), StartTime: time.Now().Unix()}
+	for i := range orders {
+		if r.isPaused() {
+			break
+		}
+		report.Scanned++
+		correction, err := reconcileOrder(&orders[i], blockNumber)
+		switch {
+		case err == errReconcileSkipped:
+			report.Skipped++
+		case err != nil:
+			report.Failed++
+			cloudwatch.PutHeartBeatMetric(metricOrderReconcileFailed)
+			log.Errorf("order manager, reconciler reconcile order:%s error:%s", orders[i].OrderHash, err.Error())
+		case correction == nil:
+		default:
+			report.Corrections = append(report.Corrections, *correction)
+			cloudwatch.PutHeartBeatMetric(metricOrderReconcileCorrected)
+			log.Warnf("order manager, reconciler corrected order:%s status:%d->%d dealtS:%s->%s dealtB:%s->%s cancelledS:%s->%s cancelledB:%s->%s",
+				correction.OrderHash, correction.StatusBefore, correction.StatusAfter,
+				correction.Before.DealtAmountS, correction.After.DealtAmountS,
+				correction.Before.DealtAmountB, correction.After.DealtAmountB,
+				correction.Before.CancelledAmountS, correction.After.CancelledAmountS,
+				correction.Before.CancelledAmountB, correction.After.CancelledAmountB)
+		}
+	}
+	report.EndTime = time.Now().Unix()
+	report.emit()
+}
+
+func (report *reconcileReport) emit() {
+	log.Infof("order manager, reconciler checked %d orders at block:%d, skipped:%d failed:%d corrected:%d",
+		report.Scanned, report.BlockNumber, report.Skipped, report.Failed, len(report.Corrections))
+	if len(report.Corrections) == 0 {
+		return
+	}
+	bs, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("order manager, reconciler marshal report error:%s", err.Error())
+		return
+	}
+	if err := sns.PublishSns(reconcilerReportTitle, string(bs)); err != nil {
+		log.Error(err.Error())
+	}
+}
+
+// reconcileOrder repairs model if its amounts differ from the contract at blockNumber,
+// it returns nil correction when the order is consistent.
+func reconcileOrder(model *dao.Order, blockNumber *big.Int) (*reconcileCorrection, error) {
+	if model.UpdatedBlock > blockNumber.Int64() {
+		return nil, errReconcileSkipped
+	}
+
+	state := &types.OrderState{}
+	if err := model.ConvertUp(state); err != nil {
+		return nil, err
+	}
+	before := reconciledAmounts(state)
+	statusBefore := state.Status
+
+	if err := settleOrderAmountAtBlock(state, types.BigintToHex(blockNumber)); err != nil {
+		return nil, err
+	}
+	settleCounterpartAmounts(state, before)
+	after := reconciledAmounts(state)
+	if before == after {
+		return nil, nil
+	}
+
+	SettleOrderStatus(state, state.CancelledAmountS.Sign() > 0 || state.CancelledAmountB.Sign() > 0)
+	state.UpdatedBlock = blockNumber
+
+	nums, err := rds.UpdateOrderWhileReconcile(model, state.Status, state.DealtAmountS, state.DealtAmountB, state.CancelledAmountS, state.CancelledAmountB, state.UpdatedBlock)
+	if err != nil {
+		return nil, err
+	}
+	// the order is updated by an event meanwhile
+	if nums == 0 {
+		return nil, errReconcileSkipped
+	}
+
+	saveOrderHistory(state, HISTORY_EVT_TYPE_RECONCILE, types.NilHash)
+	notify.NotifyOrderUpdate(state)
+	notify.NotifyAccountBalanceUpdate(&types.BalanceUpdateEvent{DelegateAddress: model.DelegateAddress, Owner: model.Owner})
+
+	return &reconcileCorrection{
+		OrderHash:    model.OrderHash,
+		Owner:        model.Owner,
+		StatusBefore: uint8(statusBefore),
+		StatusAfter:  uint8(state.Status),
+		Before:       before,
+		After:        after,
+	}, nil
+}
+
+// reconciledAmounts returns the dealt and cancelled amounts of both sides, nil amounts are zero.
+func reconciledAmounts(state *types.OrderState) reconciledAmount {
+	return reconciledAmount{
+		DealtAmountS:     amountOrZero(state.DealtAmountS).String(),
+		DealtAmountB:     amountOrZero(state.DealtAmountB).String(),
+		CancelledAmountS: amountOrZero(state.CancelledAmountS).String(),
+		CancelledAmountB: amountOrZero(state.CancelledAmountB).String(),
+	}
+}
+
+// settleCounterpartAmounts recomputes the side not recorded by the contract from the side settled by
+// settleOrderAmountAtBlock, the contract records tokenB for orders buying no more than amountB and tokenS
+// for others. A counterpart is kept if its recorded side is unchanged, since events record the actual amounts,
+// and it's scaled by the ratio recorded before otherwise, or by the order price if there was none.
+func settleCounterpartAmounts(state *types.OrderState, before reconciledAmount) {
+	amountS, amountB := state.RawOrder.AmountS, state.RawOrder.AmountB
+	if amountS == nil || amountB == nil || amountS.Sign() <= 0 || amountB.Sign() <= 0 {
+		return
+	}
+
+	if state.RawOrder.BuyNoMoreThanAmountB {
+		state.DealtAmountS = counterpartAmount(state.DealtAmountB, before.DealtAmountB, before.DealtAmountS, amountS, amountB)
+		state.CancelledAmountS = counterpartAmount(state.CancelledAmountB, before.CancelledAmountB, before.CancelledAmountS, amountS, amountB)
+	} else {
+		state.DealtAmountB = counterpartAmount(state.DealtAmountS, before.DealtAmountS, before.DealtAmountB, amountB, amountS)
+		state.CancelledAmountB = counterpartAmount(state.CancelledAmountS, before.CancelledAmountS, before.CancelledAmountB, amountB, amountS)
+	}
+}
+
+// counterpartAmount converts amount of the recorded side to the other side, counterpart/recorded is the order price.
+func counterpartAmount(amount *big.Int, amountBefore, counterpartBefore string, counterpart, recorded *big.Int) *big.Int {
+	amount = amountOrZero(amount)
+	recordedAmount, _ := new(big.Int).SetString(amountBefore, 10)
+	counterpartBeforeAmount, _ := new(big.Int).SetString(counterpartBefore, 10)
+	if amount.Cmp(recordedAmount) == 0 {
+		return counterpartBeforeAmount
+	}
+	if recordedAmount.Sign() > 0 && counterpartBeforeAmount.Sign() > 0 {
+		return new(big.Int).Div(new(big.Int).Mul(amount, counterpartBeforeAmount), recordedAmount)
+	}
+	return new(big.Int).Div(new(big.Int).Mul(amount, counterpart), recorded)
+}
+
+func amountOrZero(amount *big.Int) *big.Int {
+	if amount == nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
@@ -0,0 +1,82 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-lib/types"
+	"math/big"
+	"testing"
+)
+
+func TestSettleCounterpartAmounts(t *testing.T) {
+	cases := []struct {
+		name                 string
+		buyNoMoreThanAmountB bool
+		before               reconciledAmount
+		settled              [2]int64 // dealt and cancelled of the recorded side
+		want                 reconciledAmount
+	}{
+		{
+			name:    "unchanged keeps counterparts of events",
+			before:  reconciledAmount{DealtAmountS: "100", DealtAmountB: "55", CancelledAmountS: "10", CancelledAmountB: "0"},
+			settled: [2]int64{100, 10},
+			want:    reconciledAmount{DealtAmountS: "100", DealtAmountB: "55", CancelledAmountS: "10", CancelledAmountB: "0"},
+		},
+		{
+			name:    "dealt scaled by fill price",
+			before:  reconciledAmount{DealtAmountS: "100", DealtAmountB: "55", CancelledAmountS: "0", CancelledAmountB: "0"},
+			settled: [2]int64{200, 0},
+			want:    reconciledAmount{DealtAmountS: "200", DealtAmountB: "110", CancelledAmountS: "0", CancelledAmountB: "0"},
+		},
+		{
+			name:    "missing counterparts converted by order price",
+			before:  reconciledAmount{DealtAmountS: "0", DealtAmountB: "0", CancelledAmountS: "10", CancelledAmountB: "0"},
+			settled: [2]int64{300, 40},
+			want:    reconciledAmount{DealtAmountS: "300", DealtAmountB: "150", CancelledAmountS: "40", CancelledAmountB: "20"},
+		},
+		{
+			name:                 "tokenB recorded",
+			buyNoMoreThanAmountB: true,
+			before:               reconciledAmount{DealtAmountS: "0", DealtAmountB: "0", CancelledAmountS: "0", CancelledAmountB: "0"},
+			settled:              [2]int64{50, 25},
+			want:                 reconciledAmount{DealtAmountS: "100", DealtAmountB: "50", CancelledAmountS: "50", CancelledAmountB: "25"},
+		},
+	}
+
+	for _, c := range cases {
+		state := &types.OrderState{}
+		state.RawOrder.AmountS = big.NewInt(1000)
+		state.RawOrder.AmountB = big.NewInt(500)
+		state.RawOrder.BuyNoMoreThanAmountB = c.buyNoMoreThanAmountB
+		state.DealtAmountS, _ = new(big.Int).SetString(c.before.DealtAmountS, 10)
+		state.DealtAmountB, _ = new(big.Int).SetString(c.before.DealtAmountB, 10)
+		state.CancelledAmountS, _ = new(big.Int).SetString(c.before.CancelledAmountS, 10)
+		state.CancelledAmountB, _ = new(big.Int).SetString(c.before.CancelledAmountB, 10)
+		if c.buyNoMoreThanAmountB {
+			state.DealtAmountB, state.CancelledAmountB = big.NewInt(c.settled[0]), big.NewInt(c.settled[1])
+		} else {
+			state.DealtAmountS, state.CancelledAmountS = big.NewInt(c.settled[0]), big.NewInt(c.settled[1])
+		}
+
+		settleCounterpartAmounts(state, c.before)
+		if got := reconciledAmounts(state); got != c.want {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/Loopring/relay-cluster/accountmanager"
+	"github.com/Loopring/relay-cluster/dao"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"time"
+)
+
+const (
+	orderSchedulerZkLock = "orderManagerOrderSchedulerZkLock"
+
+	defaultScheduleInterval   = 10
+	defaultScheduleBatchSize  = 500
+	defaultScheduleMaxRetries = 10
+
+	SCHEDULED_REASON_INSUFFICIENT_FUNDS = "balance or allowance of tokenS is less than amountS"
+	SCHEDULED_REASON_ORDER_EXISTED      = "order existed"
+	SCHEDULED_REASON_INVALID_ORDER      = "invalid order"
+	SCHEDULED_REASON_RETRIES_EXCEEDED   = "release failed too many times"
+)
+
+// ScheduleOrder saves an order valid since far in future, which has passed the gateway filters.
+// It's excluded from order books and miners until released by the order scheduler.
+func ScheduleOrder(order *types.Order) error {
+	if _, err := rds.GetScheduledOrderByHash(order.Hash); err == nil {
+		return fmt.Errorf("order existed, please not submit again")
+	}
+
+	model := &dao.ScheduledOrder{}
+	if err := model.ConvertDown(order); err != nil {
+		return err
+	}
+	if err := rds.Add(model); err != nil {
+		return err
+	}
+
+	log.Debugf("order manager, order:%s of owner:%s scheduled, validSince:%d", model.OrderHash, model.Owner, model.ValidSince)
+	return nil
+}
+
+// orderScheduler releases scheduled orders as new orders when their validSince arrives, funds of the owner are
+// checked again at that time. Only the node holding the zklock releases orders.
+type orderScheduler struct {
+	*zkCronJob
+	batchSize  int
+	maxRetries int
+}
+
+func newOrderScheduler(options *omcm.OrderManagerOptions) *orderScheduler {
+	s := &orderScheduler{batchSize: options.ScheduleBatchSize, maxRetries: options.ScheduleMaxRetries}
+	s.zkCronJob = &zkCronJob{name: "order scheduler", lockName: orderSchedulerZkLock, interval: options.ScheduleInterval, run: s.release}
+	if s.interval == 0 {
+		s.interval = defaultScheduleInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultScheduleBatchSize
+	}
+	if s.maxRetries <= 0 {
+		s.maxRetries = defaultScheduleMaxRetries
+	}
+	return s
+}
+
+// release walks the due orders by id, an order failed to release doesn't block the orders behind it.
+func (s *orderScheduler) release() {
+	now := time.Now().Unix()
+	afterId, failed := 0, 0
+	for !s.isPaused() {
+		orders, err := rds.GetDueScheduledOrders(now, afterId, s.batchSize)
+		if err != nil {
+			log.Errorf("order manager, order scheduler get due orders error:%s", err.Error())
+			break
+		}
+
+		for _, v := range orders {
+			afterId = v.ID
+			if err := releaseScheduledOrder(v, now); err != nil {
+				failed++
+				s.retry(v, err)
+			}
+		}
+		if len(orders) < s.batchSize {
+			break
+		}
+	}
+	if failed > 0 {
+		log.Errorf("order manager, order scheduler failed to release %d orders", failed)
+	}
+}
+
+// retry keeps the order failed to release pending to retry next time, it's rejected after maxRetries failures.
+func (s *orderScheduler) retry(model dao.ScheduledOrder, err error) {
+	log.Errorf("order manager, order scheduler release order:%s retries:%d error:%s", model.OrderHash, model.Retries, err.Error())
+	if model.Retries+1 < s.maxRetries {
+		if err := rds.AddScheduledOrderRetry(model.OrderHash); err != nil {
+			log.Errorf("order manager, order scheduler count retry of order:%s error:%s", model.OrderHash, err.Error())
+		}
+		return
+	}
+	if _, err := rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_REJECTED, SCHEDULED_REASON_RETRIES_EXCEEDED); err != nil {
+		log.Errorf("order manager, order scheduler reject order:%s error:%s", model.OrderHash, err.Error())
+	}
+}
+
+func releaseScheduledOrder(model dao.ScheduledOrder, now int64) error {
+	order := &types.Order{}
+	if err := model.ConvertUp(order); err != nil {
+		_, err = rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_REJECTED, SCHEDULED_REASON_INVALID_ORDER)
+		return err
+	}
+	// kms errors are retried next time
+	if err := model.DecryptPrivateKey(order); err != nil {
+		return err
+	}
+
+	if order.ValidUntil.Int64() < now {
+		_, err := rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_EXPIRED, "")
+		return err
+	}
+	if _, err := rds.GetOrderByHash(order.Hash); err == nil {
+		_, err = rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_REJECTED, SCHEDULED_REASON_ORDER_EXISTED)
+		return err
+	}
+
+	balance, allowance, err := accountmanager.GetBalanceAndAllowance(order.Owner, order.TokenS, order.DelegateAddress)
+	if err != nil {
+		return err
+	}
+	if balance == nil || allowance == nil {
+		return fmt.Errorf("balance or allowance of owner:%s token:%s unknown", order.Owner.Hex(), order.TokenS.Hex())
+	}
+	if balance.Cmp(order.AmountS) < 0 || allowance.Cmp(order.AmountS) < 0 {
+		_, err = rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_REJECTED, SCHEDULED_REASON_INSUFFICIENT_FUNDS)
+		log.Debugf("order manager, order scheduler rejected order:%s, balance:%s allowance:%s amountS:%s", model.OrderHash, balance.String(), allowance.String(), order.AmountS.String())
+		return err
+	}
+
+	// released before emitted, so that the order isn't submitted twice
+	if nums, err := rds.SetScheduledOrderStatus(model.OrderHash, omtyp.SCHEDULED_ORDER_RELEASED, ""); err != nil || nums == 0 {
+		return err
+	}
+	state := &types.OrderState{}
+	state.RawOrder = *order
+	eventemitter.Emit(eventemitter.NewOrder, state)
+
+	log.Debugf("order manager, order scheduler released order:%s, validSince:%d", model.OrderHash, model.ValidSince)
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"github.com/Loopring/relay-cluster/ordermanager/cache"
+	"github.com/Loopring/relay-lib/cloudwatch"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	defaultEventShards    = 8
+	defaultEventQueueSize = 1000
+
+	metricOrderManagerQueueFull = "order_manager_queue_full"
+)
+
+type shardTask struct {
+	handle func(input eventemitter.EventData) error
+	input  eventemitter.EventData
+}
+
+// shardPool processes order events in parallel, events are sharded by the owner of the orders they update,
+// so that events of an order(and the cutoffs of its owner) are processed in the order they arrived.
+// Events updating orders of different owners, eg. ring submitted by a miner, are processed as a barrier
+// after all the queued events. A full shard queue blocks the emitter, which holds the kafka consumer as well.
+type shardPool struct {
+	mtx     sync.RWMutex
+	queues  []chan shardTask
+	pending sync.WaitGroup
+}
+
+func newShardPool(shards, queueSize int) *shardPool {
+	if shards <= 0 {
+		shards = defaultEventShards
+	}
+	if queueSize <= 0 {
+		queueSize = defaultEventQueueSize
+	}
+
+	p := &shardPool{}
+	for i := 0; i < shards; i++ {
+		queue := make(chan shardTask, queueSize)
+		p.queues = append(p.queues, queue)
+		go p.work(queue)
+	}
+	log.Infof("order manager, shard pool started, shards:%d queueSize:%d", shards, queueSize)
+	return p
+}
+
+// dispatch wraps handle, it returns after the event is queued, or after it's processed for barrier events.
+func (p *shardPool) dispatch(handle func(input eventemitter.EventData) error) func(input eventemitter.EventData) error {
+	return func(input eventemitter.EventData) error {
+		owner, ok := shardOwner(input)
+		if !ok {
+			return p.barrier(shardTask{handle: handle, input: input})
+		}
+		p.enqueue(owner, shardTask{handle: handle, input: input})
+		return nil
+	}
+}
+
+func (p *shardPool) enqueue(owner common.Address, task shardTask) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	h := fnv.New32a()
+	h.Write(owner.Bytes())
+	queue := p.queues[h.Sum32()%uint32(len(p.queues))]
+
+	p.pending.Add(1)
+	select {
+	case queue <- task:
+	default:
+		cloudwatch.PutHeartBeatMetric(metricOrderManagerQueueFull)
+		log.Debugf("order manager, shard queue of owner:%s is full, waiting", owner.Hex())
+		queue <- task
+	}
+}
+
+// barrier processes the event after all queued events, no event is queued meanwhile.
+func (p *shardPool) barrier(task shardTask) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.pending.Wait()
+	return p.run(task)
+}
+
+// wait returns after all queued events are processed.
+func (p *shardPool) wait() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.pending.Wait()
+}
+
+func (p *shardPool) work(queue chan shardTask) {
+	for task := range queue {
+		p.run(task)
+		p.pending.Done()
+	}
+}
+
+func (p *shardPool) run(task shardTask) error {
+	if err := task.handle(task.input); err != nil {
+		log.Errorf("order manager, shard pool handle event error:%s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// shardOwner returns the owner whose orders are updated by the event, it returns false
+// if orders of more than one owner may be updated.
+func shardOwner(input eventemitter.EventData) (common.Address, bool) {
+	switch event := input.(type) {
+	case *types.OrderState:
+		return event.RawOrder.Owner, true
+	case *types.OrderFilledEvent:
+		return event.Owner, true
+	case *types.OrderCancelledEvent:
+		// only the owner can cancel its order
+		return event.From, true
+	case *types.CutoffEvent:
+		return event.Owner, true
+	case *types.CutoffPairEvent:
+		return event.Owner, true
+	case *types.ApprovalEvent:
+		return correlatedShardOwner(event.TxInfo)
+	case *types.WethDepositEvent:
+		return correlatedShardOwner(event.TxInfo)
+	case *types.WethWithdrawalEvent:
+		return correlatedShardOwner(event.TxInfo)
+	case *types.TransferEvent:
+		return correlatedShardOwner(event.TxInfo)
+	case *types.EthTransferEvent:
+		return correlatedShardOwner(event.TxInfo)
+	case *types.UnsupportedContractEvent:
+		return correlatedShardOwner(event.TxInfo)
+	default:
+		// ring submitted and mined
+		return types.NilAddress, false
+	}
+}
+
+// correlatedShardOwner shards tx of the sender without pending orders, whose orders are all its own.
+// Pending orders of a miner belong to other owners.
+func correlatedShardOwner(txinfo types.TxInfo) (common.Address, bool) {
+	for _, orderhash := range cache.GetPendingOrders(txinfo.From) {
+		state, err := cache.BaseInfo(orderhash)
+		if err != nil || state.RawOrder.Owner != txinfo.From {
+			return types.NilAddress, false
+		}
+	}
+	return txinfo.From, true
+}
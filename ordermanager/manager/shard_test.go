@@ -0,0 +1,182 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"errors"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func filledOf(owner common.Address, seq int64) *types.OrderFilledEvent {
+	event := filledAt(seq)
+	event.Owner = owner
+	return event
+}
+
+func minedAt(seq int64) *types.RingMinedEvent {
+	event := &types.RingMinedEvent{}
+	event.BlockNumber = big.NewInt(seq)
+	return event
+}
+
+// shardRecorder records events as they are handled, events of the same owner are slowed down differently,
+// so that events would be reordered if processed concurrently.
+type shardRecorder struct {
+	mtx      sync.Mutex
+	handled  []eventemitter.EventData
+	inflight int
+	overlap  bool
+}
+
+func (r *shardRecorder) handle(input eventemitter.EventData) error {
+	r.mtx.Lock()
+	r.inflight++
+	r.mtx.Unlock()
+
+	seq, _ := eventBlockNumber(input)
+	time.Sleep(time.Duration(seq%3) * time.Millisecond)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := input.(*types.RingMinedEvent); ok && r.inflight > 1 {
+		r.overlap = true
+	}
+	r.inflight--
+	r.handled = append(r.handled, input)
+	return nil
+}
+
+func (r *shardRecorder) list() []eventemitter.EventData {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]eventemitter.EventData{}, r.handled...)
+}
+
+func TestShardOwner(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+	cancel := &types.OrderCancelledEvent{}
+	cancel.From = owner
+	state := &types.OrderState{}
+	state.RawOrder.Owner = owner
+
+	cases := []struct {
+		name  string
+		input eventemitter.EventData
+		ok    bool
+	}{
+		{"order submitted", state, true},
+		{"filled", filledOf(owner, 1), true},
+		{"cancelled by owner", cancel, true},
+		{"cutoff", &types.CutoffEvent{Owner: owner}, true},
+		{"cutoff pair", &types.CutoffPairEvent{Owner: owner}, true},
+		{"ring mined", minedAt(1), false},
+		{"ring submitted", &types.SubmitRingMethodEvent{}, false},
+	}
+
+	for _, c := range cases {
+		shard, ok := shardOwner(c.input)
+		if ok != c.ok || (ok && shard != owner) {
+			t.Errorf("%s: expected ok:%t, got %s %t", c.name, c.ok, shard.Hex(), ok)
+		}
+	}
+}
+
+func TestShardPoolOrderPerOwner(t *testing.T) {
+	r := &shardRecorder{}
+	p := newShardPool(4, 2)
+	handle := p.dispatch(r.handle)
+
+	owners := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+	for seq := int64(1); seq <= 30; seq++ {
+		for _, owner := range owners {
+			handle(filledOf(owner, seq))
+		}
+	}
+	p.wait()
+
+	last := make(map[common.Address]int64)
+	handled := r.list()
+	if len(handled) != 90 {
+		t.Fatalf("expected 90 events handled, got %d", len(handled))
+	}
+	for _, input := range handled {
+		event := input.(*types.OrderFilledEvent)
+		seq := event.BlockNumber.Int64()
+		if seq != last[event.Owner]+1 {
+			t.Fatalf("events of %s handled out of order, %d after %d", event.Owner.Hex(), seq, last[event.Owner])
+		}
+		last[event.Owner] = seq
+	}
+}
+
+func TestShardPoolBarrier(t *testing.T) {
+	r := &shardRecorder{}
+	p := newShardPool(4, 10)
+	handle := p.dispatch(r.handle)
+
+	owners := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	for seq := int64(1); seq <= 10; seq++ {
+		handle(filledOf(owners[seq%2], seq))
+	}
+	if err := handle(minedAt(11)); err != nil {
+		t.Fatal(err)
+	}
+	// the barrier returns after it's processed
+	if handled := r.list(); len(handled) != 11 {
+		t.Fatalf("expected events queued before the barrier and the barrier handled, got %d", len(handled))
+	}
+	for seq := int64(12); seq <= 20; seq++ {
+		handle(filledOf(owners[seq%2], seq))
+	}
+	p.wait()
+
+	handled := r.list()
+	if _, ok := handled[10].(*types.RingMinedEvent); !ok {
+		t.Fatalf("barrier should be handled after the events queued before it")
+	}
+	for _, input := range handled[11:] {
+		if seq, _ := eventBlockNumber(input); seq <= 11 {
+			t.Fatalf("event %d handled after the barrier", seq)
+		}
+	}
+	if r.overlap {
+		t.Fatal("barrier handled while other events in process")
+	}
+}
+
+func TestShardPoolBarrierError(t *testing.T) {
+	p := newShardPool(2, 10)
+	failed := errors.New("failed")
+	handle := p.dispatch(func(input eventemitter.EventData) error { return failed })
+
+	if err := handle(minedAt(1)); err != failed {
+		t.Fatalf("expected error of barrier returned, got %v", err)
+	}
+	// errors of queued events are only logged
+	if err := handle(filledOf(common.HexToAddress("0x1"), 2)); err != nil {
+		t.Fatalf("expected nil for queued events, got %v", err)
+	}
+	p.wait()
+}
@@ -0,0 +1,194 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package manager
+
+import (
+	"fmt"
+	"github.com/Loopring/relay-cluster/dao"
+	"github.com/Loopring/relay-cluster/market"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	"github.com/Loopring/relay-lib/eventemitter"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"time"
+)
+
+const (
+	stopOrderTriggerZkLock = "orderManagerStopOrderTriggerZkLock"
+
+	defaultStopOrderTriggerInterval = 5
+	defaultStopOrderBatchSize       = 500
+	defaultStopOrderMaxRetries      = 10
+
+	STOP_ORDER_REASON_ORDER_EXISTED    = "order existed"
+	STOP_ORDER_REASON_INVALID_ORDER    = "invalid order"
+	STOP_ORDER_REASON_RETRIES_EXCEEDED = "trigger failed too many times"
+)
+
+// AddStopOrder saves an order which has passed the gateway filters, it's excluded from order books and miners
+// until the last trade price of its market meets the condition.
+func AddStopOrder(order *types.Order, condition omtyp.StopCondition, triggerPrice float64) error {
+	if condition != omtyp.STOP_CONDITION_GTE && condition != omtyp.STOP_CONDITION_LTE {
+		return fmt.Errorf("stop condition:%s invalid", condition)
+	}
+	if triggerPrice <= 0 {
+		return fmt.Errorf("trigger price invalid")
+	}
+	if _, err := rds.GetStopOrderByHash(order.Hash); err == nil {
+		return fmt.Errorf("order existed, please not submit again")
+	}
+
+	model := &dao.StopOrder{}
+	if err := model.ConvertDown(order, condition, triggerPrice); err != nil {
+		return err
+	}
+	if err := rds.Add(model); err != nil {
+		return err
+	}
+
+	log.Debugf("order manager, stop order:%s of owner:%s added, market:%s condition:%s triggerPrice:%f", model.OrderHash, model.Owner, model.Market, model.Condition, model.TriggerPrice)
+	return nil
+}
+
+// stopOrderTrigger compares pending stop orders with the last trade price of their markets, orders met are
+// submitted as new orders, which are pushed to owners by socketio. Stop orders are kept in db, so that they
+// survive restarts, and only the node holding the zklock triggers them.
+type stopOrderTrigger struct {
+	*zkCronJob
+	batchSize int
+	trend     *market.TrendManager
+}
+
+func newStopOrderTrigger(options *omcm.OrderManagerOptions, trend *market.TrendManager) *stopOrderTrigger {
+	t := &stopOrderTrigger{batchSize: defaultStopOrderBatchSize, trend: trend}
+	t.zkCronJob = &zkCronJob{name: "stop order trigger", lockName: stopOrderTriggerZkLock, interval: options.StopOrderTriggerInterval, run: t.trigger}
+	if t.interval == 0 {
+		t.interval = defaultStopOrderTriggerInterval
+	}
+	// last prices are read from trend, orders are never triggered without it
+	if trend == nil {
+		t.interval = -1
+	}
+	return t
+}
+
+func (t *stopOrderTrigger) trigger() {
+	if t.isPaused() {
+		return
+	}
+
+	now := time.Now().Unix()
+	if nums, err := rds.ExpireStopOrders(now); err != nil {
+		log.Errorf("order manager, stop order trigger expire orders error:%s", err.Error())
+	} else if nums > 0 {
+		log.Debugf("order manager, stop order trigger expired %d orders", nums)
+	}
+
+	markets, err := rds.GetStopOrderMarkets()
+	if err != nil {
+		log.Errorf("order manager, stop order trigger get markets error:%s", err.Error())
+		return
+	}
+	for _, mkt := range markets {
+		ticker, err := t.trend.GetTickerByMarket(mkt)
+		if err != nil || ticker.Last <= 0 {
+			continue
+		}
+		t.triggerMarket(mkt, ticker.Last, now)
+	}
+}
+
+// triggerMarket walks the orders met by id, an order failed to trigger doesn't block the orders behind it.
+func (t *stopOrderTrigger) triggerMarket(mkt string, lastPrice float64, now int64) {
+	afterId, failed := 0, 0
+	for !t.isPaused() {
+		orders, err := rds.GetTriggeredStopOrders(mkt, lastPrice, afterId, t.batchSize)
+		if err != nil {
+			log.Errorf("order manager, stop order trigger get orders of market:%s error:%s", mkt, err.Error())
+			break
+		}
+
+		for _, v := range orders {
+			afterId = v.ID
+			if err := triggerStopOrder(v, lastPrice, now); err != nil {
+				failed++
+				retryStopOrder(v, lastPrice, err)
+			}
+		}
+		if len(orders) < t.batchSize {
+			break
+		}
+	}
+	if failed > 0 {
+		log.Errorf("order manager, stop order trigger failed to trigger %d orders of market:%s", failed, mkt)
+	}
+}
+
+// retryStopOrder keeps the order failed to trigger pending to retry next time, it's rejected after
+// defaultStopOrderMaxRetries failures.
+func retryStopOrder(model dao.StopOrder, lastPrice float64, err error) {
+	log.Errorf("order manager, stop order trigger order:%s retries:%d error:%s", model.OrderHash, model.Retries, err.Error())
+	if model.Retries+1 < defaultStopOrderMaxRetries {
+		if err := rds.AddStopOrderRetry(model.OrderHash); err != nil {
+			log.Errorf("order manager, stop order trigger count retry of order:%s error:%s", model.OrderHash, err.Error())
+		}
+		return
+	}
+	if _, err := rds.SetStopOrderStatus(model.OrderHash, omtyp.STOP_ORDER_REJECTED, STOP_ORDER_REASON_RETRIES_EXCEEDED, lastPrice); err != nil {
+		log.Errorf("order manager, stop order trigger reject order:%s error:%s", model.OrderHash, err.Error())
+	}
+}
+
+func triggerStopOrder(model dao.StopOrder, lastPrice float64, now int64) error {
+	// selected by db, it's only checked again before the order is submitted
+	if !omtyp.StopCondition(model.Condition).IsMet(model.TriggerPrice, lastPrice) {
+		log.Debugf("order manager, stop order:%s condition:%s triggerPrice:%f isn't met by lastPrice:%f", model.OrderHash, model.Condition, model.TriggerPrice, lastPrice)
+		return nil
+	}
+
+	order := &types.Order{}
+	if err := model.ConvertUp(order); err != nil {
+		_, err = rds.SetStopOrderStatus(model.OrderHash, omtyp.STOP_ORDER_REJECTED, STOP_ORDER_REASON_INVALID_ORDER, lastPrice)
+		return err
+	}
+	if err := model.DecryptPrivateKey(order); err != nil {
+		return err
+	}
+
+	if order.ValidUntil.Int64() < now {
+		_, err := rds.SetStopOrderStatus(model.OrderHash, omtyp.STOP_ORDER_EXPIRED, "", lastPrice)
+		return err
+	}
+	if _, err := rds.GetOrderByHash(order.Hash); err == nil {
+		_, err = rds.SetStopOrderStatus(model.OrderHash, omtyp.STOP_ORDER_REJECTED, STOP_ORDER_REASON_ORDER_EXISTED, lastPrice)
+		return err
+	}
+
+	// triggered before emitted, so that the order isn't submitted twice
+	if nums, err := rds.SetStopOrderStatus(model.OrderHash, omtyp.STOP_ORDER_TRIGGERED, "", lastPrice); err != nil || nums == 0 {
+		return err
+	}
+	state := &types.OrderState{}
+	state.RawOrder = *order
+	eventemitter.Emit(eventemitter.NewOrder, state)
+
+	log.Debugf("order manager, stop order:%s triggered, market:%s condition:%s triggerPrice:%f lastPrice:%f", model.OrderHash, model.Market, model.Condition, model.TriggerPrice, lastPrice)
+	return nil
+}
@@ -20,36 +20,69 @@ package manager
 
 import (
 	"fmt"
-	"github.com/Loopring/relay-cluster/dao"
 	cm "github.com/Loopring/relay-cluster/ordermanager/common"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
+	notify "github.com/Loopring/relay-cluster/util"
 	"github.com/Loopring/relay-lib/log"
 	util "github.com/Loopring/relay-lib/marketutil"
 	"github.com/Loopring/relay-lib/types"
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// MinerOrders provides orders to miners ranked by the miner policy of the market, orders delayed by miners
+// are marked first and excluded until the block range covers them.
 func MinerOrders(delegate, tokenS, tokenB common.Address, length int, reservedTime, startBlockNumber, endBlockNumber int64, filterOrderHashLists ...*types.OrderDelayList) []*types.OrderState {
 	var list []*types.OrderState
 
-	var (
-		modelList []*dao.Order
-		err       error
-	)
-
 	for _, orderDelay := range filterOrderHashLists {
 		orderHashes := []string{}
 		for _, hash := range orderDelay.OrderHash {
 			orderHashes = append(orderHashes, hash.Hex())
 		}
 		if len(orderHashes) > 0 && orderDelay.DelayedCount != 0 {
-			if err = rds.MarkMinerOrders(orderHashes, orderDelay.DelayedCount); err != nil {
+			if err := rds.MarkMinerOrders(orderHashes, orderDelay.DelayedCount); err != nil {
 				log.Debugf("order manager,provide orders for miner error:%s", err.Error())
 			}
 		}
 	}
 
-	// 从数据库获取订单
-	if modelList, err = rds.GetOrdersForMiner(delegate.Hex(), tokenS.Hex(), tokenB.Hex(), length, cm.ValidMinerStatus, reservedTime, startBlockNumber, endBlockNumber); err != nil {
+	policy := getMinerPolicy(tokenS, tokenB)
+	candidates := rankMinerOrders(policy, getMinerCandidates(policy, delegate, tokenS, tokenB, length, reservedTime, startBlockNumber, endBlockNumber, true), length, true)
+	for _, v := range candidates {
+		if len(v.rank.Excluded) == 0 {
+			list = append(list, v.state)
+		}
+
+		//if um.InWhiteList(state.RawOrder.Owner) {
+		//	list = append(list, state)
+		//} else {
+		//	log.Debugf("order manager,owner:%s not in white list", state.RawOrder.Owner.Hex())
+		//}
+	}
+
+	return list
+}
+
+// RankMinerOrders returns the ranking of all candidates of MinerOrders without marking any order.
+func RankMinerOrders(delegate, tokenS, tokenB common.Address, length int, reservedTime, startBlockNumber, endBlockNumber int64) []MinerOrderRank {
+	policy := getMinerPolicy(tokenS, tokenB)
+	candidates := rankMinerOrders(policy, getMinerCandidates(policy, delegate, tokenS, tokenB, length, reservedTime, startBlockNumber, endBlockNumber, false), length, false)
+
+	list := make([]MinerOrderRank, 0, len(candidates))
+	for _, v := range candidates {
+		list = append(list, v.rank)
+	}
+	return list
+}
+
+// getMinerCandidates decrypts auth private keys of market orders only if withPrivateKey, which is for orders provided
+// to miners, keys of orders failed to decrypt are left empty.
+func getMinerCandidates(policy cm.MinerPolicyOptions, delegate, tokenS, tokenB common.Address, length int, reservedTime, startBlockNumber, endBlockNumber int64, withPrivateKey bool) []*types.OrderState {
+	var list []*types.OrderState
+
+	// 从数据库获取订单, 按策略排序后截取
+	modelList, err := rds.GetOrdersForMiner(delegate.Hex(), tokenS.Hex(), tokenB.Hex(), length*policy.CandidateFactor, cm.ValidMinerStatus, reservedTime, startBlockNumber, endBlockNumber)
+	if err != nil {
 		log.Errorf("err:%s", err.Error())
 		return list
 	}
@@ -57,15 +90,15 @@ func MinerOrders(delegate, tokenS, tokenB common.Address, length int, reservedTi
 	for _, v := range modelList {
 		state := &types.OrderState{}
 		v.ConvertUp(state)
+		if withPrivateKey {
+			if key, err := v.DecryptPrivateKey(); err != nil {
+				log.Errorf("order manager, decrypt private key of order:%s error:%s", v.OrderHash, err.Error())
+			} else {
+				state.RawOrder.AuthPrivateKey = key
+			}
+		}
 		list = append(list, state)
-
-		//if um.InWhiteList(state.RawOrder.Owner) {
-		//	list = append(list, state)
-		//} else {
-		//	log.Debugf("order manager,owner:%s not in white list", state.RawOrder.Owner.Hex())
-		//}
 	}
-
 	return list
 }
 
@@ -73,45 +106,108 @@ func UpdateBroadcastTimeByHash(hash common.Hash, bt int) error {
 	return rds.UpdateBroadcastTimeByHash(hash.Hex(), bt)
 }
 
-func FlexCancelOrder(event *types.FlexCancelOrderEvent) error {
+// FlexCancelOrder cancels orders in relay only, it returns hashes of the cancelled orders, each of which is pushed.
+func FlexCancelOrder(event *omtyp.FlexCancelOrderEvent) ([]common.Hash, error) {
 	if types.IsZeroAddress(event.Owner) {
-		return fmt.Errorf("params owner invalid")
+		return nil, fmt.Errorf("params owner invalid")
 	}
 
 	validStatus := cm.ValidFlexCancelStatus
 	status := types.ORDER_FLEX_CANCEL
 
-	var nums int64 = 0
+	var (
+		orderhashList []common.Hash
+		market        string
+		err           error
+	)
 	switch event.Type {
-	case types.FLEX_CANCEL_BY_HASH:
+	case omtyp.FLEX_CANCEL_BY_HASH:
 		if types.IsZeroHash(event.OrderHash) {
-			return fmt.Errorf("params orderhash invalid")
+			return nil, fmt.Errorf("params orderhash invalid")
 		}
-		nums = rds.FlexCancelOrderByHash(event.Owner, event.OrderHash, validStatus, status)
+		orderhashList = rds.FlexCancelOrderByHash(event.Owner, event.OrderHash, validStatus, status)
 
-	case types.FLEX_CANCEL_BY_OWNER:
-		nums = rds.FlexCancelOrderByOwner(event.Owner, validStatus, status)
+	case omtyp.FLEX_CANCEL_BY_OWNER:
+		orderhashList = rds.FlexCancelOrderByOwner(event.Owner, validStatus, status)
 
-	case types.FLEX_CANCEL_BY_TIME:
+	case omtyp.FLEX_CANCEL_BY_TIME:
 		if event.CutoffTime <= 0 {
-			return fmt.Errorf("params cutoffTimeStamp invalid")
+			return nil, fmt.Errorf("params cutoffTimeStamp invalid")
 		}
-		nums = rds.FlexCancelOrderByTime(event.Owner, event.CutoffTime, validStatus, status)
+		orderhashList = rds.FlexCancelOrderByTime(event.Owner, event.CutoffTime, validStatus, status)
 
-	case types.FLEX_CANCEL_BY_MARKET:
-		market, err := util.WrapMarketByAddress(event.TokenS.Hex(), event.TokenB.Hex())
+	case omtyp.FLEX_CANCEL_BY_MARKET:
+		market, err = util.WrapMarketByAddress(event.TokenS.Hex(), event.TokenB.Hex())
 		if err != nil {
-			return fmt.Errorf("params market invalid")
+			return nil, fmt.Errorf("params market invalid")
+		}
+		orderhashList = rds.FlexCancelOrderByMarket(event.Owner, event.CutoffTime, market, validStatus, status)
+
+	case omtyp.FLEX_CANCEL_BY_HASHES:
+		if len(event.OrderHashList) == 0 || len(event.OrderHashList) > omtyp.FLEX_CANCEL_MAX_HASHES {
+			return nil, fmt.Errorf("params orderhash list invalid, length should be in [1, %d]", omtyp.FLEX_CANCEL_MAX_HASHES)
+		}
+		for _, v := range event.OrderHashList {
+			if types.IsZeroHash(v) {
+				return nil, fmt.Errorf("params orderhash invalid")
+			}
+		}
+		orderhashList = rds.FlexCancelOrderByHashes(event.Owner, event.OrderHashList, validStatus, status)
+
+	case omtyp.FLEX_CANCEL_BY_PAIR:
+		if types.IsZeroAddress(event.TokenS) || types.IsZeroAddress(event.TokenB) || event.TokenS == event.TokenB {
+			return nil, fmt.Errorf("params token pair invalid")
 		}
-		nums = rds.FlexCancelOrderByMarket(event.Owner, event.CutoffTime, market, validStatus, status)
+		orderhashList = rds.FlexCancelOrderByPair(event.Owner, event.TokenS, event.TokenB, validStatus, status)
+		// orders of an unsupported pair can't be queued
+		market, _ = util.WrapMarketByAddress(event.TokenS.Hex(), event.TokenB.Hex())
 
 	default:
-		return fmt.Errorf("event type invalid")
+		return nil, fmt.Errorf("event type invalid")
 	}
 
-	if nums == 0 {
-		return fmt.Errorf("no valid order exist")
+	saveOrdersHistory(orderhashList, HISTORY_EVT_TYPE_FLEX_CANCEL, types.NilHash)
+	notifyOrdersUpdate(orderhashList)
+
+	// scheduled and stop orders aren't submitted yet, they're only cancelled in queue
+	var (
+		queuedHashList []common.Hash
+		cutoff         int64
+	)
+	switch event.Type {
+	case omtyp.FLEX_CANCEL_BY_HASH:
+		queuedHashList = []common.Hash{event.OrderHash}
+	case omtyp.FLEX_CANCEL_BY_HASHES:
+		queuedHashList = event.OrderHashList
+	case omtyp.FLEX_CANCEL_BY_TIME, omtyp.FLEX_CANCEL_BY_MARKET:
+		cutoff = event.CutoffTime
+	}
+	if event.Type != omtyp.FLEX_CANCEL_BY_PAIR || market != "" {
+		orderhashList = append(orderhashList, rds.CancelScheduledOrders(event.Owner, queuedHashList, market, cutoff)...)
+		orderhashList = append(orderhashList, rds.CancelStopOrders(event.Owner, queuedHashList, market, cutoff)...)
 	}
 
-	return nil
+	if len(orderhashList) == 0 {
+		return nil, fmt.Errorf("no valid order exist")
+	}
+	return orderhashList, nil
+}
+
+// notifyOrdersUpdate pushes the orders updated in batch, which are removed from order books of all nodes as well.
+func notifyOrdersUpdate(orderhashList []common.Hash) {
+	if len(orderhashList) == 0 {
+		return
+	}
+	models, err := rds.GetOrdersByHashes(orderhashList)
+	if err != nil {
+		log.Errorf("order manager, get orders for notify error:%s", err.Error())
+		return
+	}
+	for _, v := range models {
+		state := &types.OrderState{}
+		if err := v.ConvertUp(state); err != nil {
+			continue
+		}
+		notify.NotifyOrderUpdate(state)
+	}
 }
@@ -11,16 +11,18 @@ import (
 // MarshalJSON marshals as JSON.
 func (f FlexCancelOrderEvent) MarshalJSON() ([]byte, error) {
 	type FlexCancelOrderEvent struct {
-		Owner      common.Address `json:"owner"`
-		OrderHash  common.Hash    `json:"order_hash"`
-		CutoffTime int64          `json:"cutoff_time"`
-		TokenS     common.Address `json:"token_s"`
-		TokenB     common.Address `json:"token_b"`
-		Type       FlexCancelType `json:"type"`
+		Owner         common.Address `json:"owner"`
+		OrderHash     common.Hash    `json:"order_hash"`
+		OrderHashList []common.Hash  `json:"order_hash_list"`
+		CutoffTime    int64          `json:"cutoff_time"`
+		TokenS        common.Address `json:"token_s"`
+		TokenB        common.Address `json:"token_b"`
+		Type          FlexCancelType `json:"type"`
 	}
 	var enc FlexCancelOrderEvent
 	enc.Owner = f.Owner
 	enc.OrderHash = f.OrderHash
+	enc.OrderHashList = f.OrderHashList
 	enc.CutoffTime = f.CutoffTime
 	enc.TokenS = f.TokenS
 	enc.TokenB = f.TokenB
@@ -31,12 +33,13 @@ func (f FlexCancelOrderEvent) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (f *FlexCancelOrderEvent) UnmarshalJSON(input []byte) error {
 	type FlexCancelOrderEvent struct {
-		Owner      *common.Address `json:"owner"`
-		OrderHash  *common.Hash    `json:"order_hash"`
-		CutoffTime *int64          `json:"cutoff_time"`
-		TokenS     *common.Address `json:"token_s"`
-		TokenB     *common.Address `json:"token_b"`
-		Type       *FlexCancelType `json:"type"`
+		Owner         *common.Address `json:"owner"`
+		OrderHash     *common.Hash    `json:"order_hash"`
+		OrderHashList []common.Hash   `json:"order_hash_list"`
+		CutoffTime    *int64          `json:"cutoff_time"`
+		TokenS        *common.Address `json:"token_s"`
+		TokenB        *common.Address `json:"token_b"`
+		Type          *FlexCancelType `json:"type"`
 	}
 	var dec FlexCancelOrderEvent
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -48,6 +51,9 @@ func (f *FlexCancelOrderEvent) UnmarshalJSON(input []byte) error {
 	if dec.OrderHash != nil {
 		f.OrderHash = *dec.OrderHash
 	}
+	if dec.OrderHashList != nil {
+		f.OrderHashList = dec.OrderHashList
+	}
 	if dec.CutoffTime != nil {
 		f.CutoffTime = *dec.CutoffTime
 	}
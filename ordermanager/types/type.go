@@ -32,6 +32,10 @@ type OrderTx struct {
 	Nonce       int64             `json:"nonce"`
 }
 
+// ORDER_DUST is ORDER_FINISHED set by the dust sweeper, whose remained value became dust as prices changed
+// rather than by fills. It's returned as ORDER_FINISHED with finishReason "dust" by the gateway.
+const ORDER_DUST types.OrderStatus = 11
+
 type FlexCancelType uint8
 
 const (
@@ -39,14 +43,85 @@ const (
 	FLEX_CANCEL_BY_OWNER  FlexCancelType = 2
 	FLEX_CANCEL_BY_TIME   FlexCancelType = 3
 	FLEX_CANCEL_BY_MARKET FlexCancelType = 4
+	FLEX_CANCEL_BY_HASHES FlexCancelType = 5
+	FLEX_CANCEL_BY_PAIR   FlexCancelType = 6
 )
 
+// FLEX_CANCEL_MAX_HASHES limits the orders cancelled by hashes in one request
+const FLEX_CANCEL_MAX_HASHES = 100
+
 //go:generate gencodec -type FlexCancelOrderEvent -out gen_flex_cancel_order_event_json.go
 type FlexCancelOrderEvent struct {
-	Owner      common.Address `json:"owner"`
-	OrderHash  common.Hash    `json:"order_hash"`
-	CutoffTime int64          `json:"cutoff_time"`
-	TokenS     common.Address `json:"token_s"`
-	TokenB     common.Address `json:"token_b"`
-	Type       FlexCancelType `json:"type"`
+	Owner         common.Address `json:"owner"`
+	OrderHash     common.Hash    `json:"order_hash"`
+	OrderHashList []common.Hash  `json:"order_hash_list"`
+	CutoffTime    int64          `json:"cutoff_time"`
+	TokenS        common.Address `json:"token_s"`
+	TokenB        common.Address `json:"token_b"`
+	Type          FlexCancelType `json:"type"`
+}
+
+type ScheduledOrderStatus uint8
+
+// pending: validSince未到, 不进入订单簿, 也不提供给miner
+// released: validSince到达后作为新订单提交
+// rejected: 激活时余额或授权不足
+// expired: 激活前已过validUntil
+// cancelled: 激活前被flex cancel
+const (
+	SCHEDULED_ORDER_PENDING   ScheduledOrderStatus = 1
+	SCHEDULED_ORDER_RELEASED  ScheduledOrderStatus = 2
+	SCHEDULED_ORDER_REJECTED  ScheduledOrderStatus = 3
+	SCHEDULED_ORDER_EXPIRED   ScheduledOrderStatus = 4
+	SCHEDULED_ORDER_CANCELLED ScheduledOrderStatus = 5
+)
+
+type StopOrderStatus uint8
+
+// pending: 条件未触发, 不进入订单簿, 也不提供给miner
+// triggered: 条件触发后作为新订单提交
+// rejected: 触发时订单已存在
+// expired: 触发前已过validUntil
+// cancelled: 触发前被flex cancel
+const (
+	STOP_ORDER_PENDING   StopOrderStatus = 1
+	STOP_ORDER_TRIGGERED StopOrderStatus = 2
+	STOP_ORDER_REJECTED  StopOrderStatus = 3
+	STOP_ORDER_EXPIRED   StopOrderStatus = 4
+	STOP_ORDER_CANCELLED StopOrderStatus = 5
+)
+
+// StopCondition compares the last trade price of the market with the trigger price.
+type StopCondition string
+
+const (
+	STOP_CONDITION_GTE StopCondition = "gte"
+	STOP_CONDITION_LTE StopCondition = "lte"
+)
+
+// IsMet is the same as the condition of dao.GetTriggeredStopOrders.
+func (c StopCondition) IsMet(triggerPrice, lastPrice float64) bool {
+	switch c {
+	case STOP_CONDITION_GTE:
+		return lastPrice >= triggerPrice
+	case STOP_CONDITION_LTE:
+		return lastPrice <= triggerPrice
+	}
+	return false
+}
+
+// ForkRolledBack is emitted after the order manager committed the rollback of events in forked blocks.
+const ForkRolledBack = "OrderManager_ForkRolledBack"
+
+// ForkRolledBackEvent carries the fills rolled back, data calculated from fills(eg. trends and tickers) should be recalculated.
+type ForkRolledBackEvent struct {
+	ForkBlock     int64
+	DetectedBlock int64
+	Fills         []ForkedFill
+}
+
+type ForkedFill struct {
+	DelegateAddress common.Address
+	Market          string
+	BlockTime       int64
 }
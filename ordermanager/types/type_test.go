@@ -0,0 +1,44 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package types
+
+import "testing"
+
+func TestStopConditionIsMet(t *testing.T) {
+	cases := []struct {
+		condition    StopCondition
+		triggerPrice float64
+		lastPrice    float64
+		met          bool
+	}{
+		{STOP_CONDITION_GTE, 0.5, 0.6, true},
+		{STOP_CONDITION_GTE, 0.5, 0.5, true},
+		{STOP_CONDITION_GTE, 0.5, 0.4, false},
+		{STOP_CONDITION_LTE, 0.5, 0.4, true},
+		{STOP_CONDITION_LTE, 0.5, 0.5, true},
+		{STOP_CONDITION_LTE, 0.5, 0.6, false},
+		{StopCondition("eq"), 0.5, 0.5, false},
+		{StopCondition(""), 0.5, 0.5, false},
+	}
+	for _, c := range cases {
+		if met := c.condition.IsMet(c.triggerPrice, c.lastPrice); met != c.met {
+			t.Errorf("condition:%s triggerPrice:%f lastPrice:%f, got %t, want %t", c.condition, c.triggerPrice, c.lastPrice, met, c.met)
+		}
+	}
+}
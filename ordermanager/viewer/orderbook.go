@@ -0,0 +1,283 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package viewer
+
+import (
+	"fmt"
+	"github.com/Loopring/relay-cluster/dao"
+	omcm "github.com/Loopring/relay-cluster/ordermanager/common"
+	"github.com/Loopring/relay-lib/log"
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/robfig/cron"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOrderBookSize           = 1000
+	defaultOrderBookResyncInterval = 60
+	orderBookLoadAttempts          = 3
+)
+
+type orderBookKey struct {
+	delegate common.Address
+	tokenS   common.Address
+	tokenB   common.Address
+}
+
+// orderBookSide is the orders selling tokenS for tokenB sorted by price desc, version is increased
+// on every update, so that a load or resync started before the update doesn't overwrite it.
+// ready is closed once the side is loaded, err is the error of loading.
+type orderBookSide struct {
+	orders  []types.OrderState
+	version int64
+	ready   chan struct{}
+	err     error
+}
+
+func (side *orderBookSide) isReady() bool {
+	select {
+	case <-side.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// orderBook keeps the market orders of the sides requested in memory, the same as dao.GetOrderBook.
+// A side is loaded from db on first request, then it's updated by order events and reloaded periodically
+// to catch up the changes without events, eg. status of orders with pending tx.
+type orderBook struct {
+	mtx      sync.RWMutex
+	rds      *dao.RdsService
+	size     int
+	interval int64
+	sides    map[orderBookKey]*orderBookSide
+	index    map[common.Hash]orderBookKey
+	cron     *cron.Cron
+}
+
+func newOrderBook(options *omcm.OrderManagerOptions, rds *dao.RdsService) *orderBook {
+	book := &orderBook{rds: rds, size: options.OrderBookSize, interval: options.OrderBookResyncInterval}
+	if book.size <= 0 {
+		book.size = defaultOrderBookSize
+	}
+	if book.interval == 0 {
+		book.interval = defaultOrderBookResyncInterval
+	}
+	book.sides = make(map[orderBookKey]*orderBookSide)
+	book.index = make(map[common.Hash]orderBookKey)
+
+	if book.interval > 0 {
+		book.cron = cron.New()
+		book.cron.AddFunc(fmt.Sprintf("@every %ds", book.interval), book.resync)
+		book.cron.Start()
+	}
+	return book
+}
+
+// get returns the first length orders valid now, all valid orders are returned if length <= 0.
+func (book *orderBook) get(delegate, tokenS, tokenB common.Address, length int) ([]types.OrderState, error) {
+	key := orderBookKey{delegate: delegate, tokenS: tokenS, tokenB: tokenB}
+
+	side, err := book.load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	book.mtx.RLock()
+	defer book.mtx.RUnlock()
+
+	now := big.NewInt(time.Now().Unix())
+	list := make([]types.OrderState, 0)
+	for _, v := range side.orders {
+		if length > 0 && len(list) >= length {
+			break
+		}
+		if v.RawOrder.ValidSince.Cmp(now) >= 0 || v.RawOrder.ValidUntil.Cmp(now) < 0 {
+			continue
+		}
+		// price is changed in place while calculating depth
+		v.RawOrder.Price = new(big.Rat).Set(v.RawOrder.Price)
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// load returns the side, it's registered before querying db, so that updates arriving meanwhile change its version,
+// and the query is retried as resync does. Requests of a side being loaded wait for it.
+func (book *orderBook) load(key orderBookKey) (*orderBookSide, error) {
+	book.mtx.Lock()
+	side, ok := book.sides[key]
+	if ok {
+		book.mtx.Unlock()
+		<-side.ready
+		return side, side.err
+	}
+	side = &orderBookSide{ready: make(chan struct{})}
+	book.sides[key] = side
+	book.mtx.Unlock()
+
+	defer close(side.ready)
+	for i := 1; ; i++ {
+		book.mtx.RLock()
+		version := side.version
+		book.mtx.RUnlock()
+
+		orders, err := book.query(key)
+
+		book.mtx.Lock()
+		if err != nil {
+			book.replace(key, side, nil)
+			delete(book.sides, key)
+			side.err = err
+			book.mtx.Unlock()
+			return side, err
+		}
+		// updated too often, orders changed meanwhile are corrected by the next resync
+		if side.version == version || i >= orderBookLoadAttempts {
+			book.replace(key, side, orders)
+			book.mtx.Unlock()
+			return side, nil
+		}
+		book.mtx.Unlock()
+	}
+}
+
+// query selects orders valid now in db, orders valid since later are loaded by the next resync.
+func (book *orderBook) query(key orderBookKey) ([]types.OrderState, error) {
+	models, err := book.rds.GetOrderBook(key.delegate, key.tokenS, key.tokenB, book.size)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []types.OrderState
+	for _, v := range models {
+		var state types.OrderState
+		if err := v.ConvertUp(&state); err != nil {
+			continue
+		}
+		orders = append(orders, state)
+	}
+	return orders, nil
+}
+
+// replace is called with mtx held.
+func (book *orderBook) replace(key orderBookKey, side *orderBookSide, orders []types.OrderState) {
+	for _, v := range side.orders {
+		delete(book.index, v.RawOrder.Hash)
+	}
+	side.orders = orders
+	for _, v := range side.orders {
+		book.index[v.RawOrder.Hash] = key
+	}
+}
+
+// update inserts, updates or removes the order by its status, sides not requested are skipped.
+func (book *orderBook) update(state *types.OrderState) {
+	if state.RawOrder.Price == nil || state.RawOrder.ValidSince == nil || state.RawOrder.ValidUntil == nil {
+		return
+	}
+
+	book.mtx.Lock()
+	defer book.mtx.Unlock()
+
+	book.remove(state.RawOrder.Hash)
+
+	if state.RawOrder.OrderType != types.ORDER_TYPE_MARKET || !omcm.IsValidOrderBookStatus(state.Status) {
+		return
+	}
+	key := orderBookKey{delegate: state.RawOrder.DelegateAddress, tokenS: state.RawOrder.TokenS, tokenB: state.RawOrder.TokenB}
+	side, ok := book.sides[key]
+	if !ok {
+		return
+	}
+
+	// prices are compared as saved in db
+	order := *state
+	price, _ := order.RawOrder.Price.Float64()
+	order.RawOrder.Price = new(big.Rat).SetFloat64(price)
+
+	// orders at the same price are kept in the order they arrived
+	i := sort.Search(len(side.orders), func(i int) bool {
+		return side.orders[i].RawOrder.Price.Cmp(order.RawOrder.Price) < 0
+	})
+	side.orders = append(side.orders, types.OrderState{})
+	copy(side.orders[i+1:], side.orders[i:])
+	side.orders[i] = order
+	side.version++
+	book.index[order.RawOrder.Hash] = key
+}
+
+// removeOrders removes the orders, eg. cutoff, from all sides.
+func (book *orderBook) removeOrders(orderhashList []common.Hash) {
+	book.mtx.Lock()
+	defer book.mtx.Unlock()
+
+	for _, orderhash := range orderhashList {
+		book.remove(orderhash)
+	}
+}
+
+// remove is called with mtx held.
+func (book *orderBook) remove(orderhash common.Hash) {
+	key, ok := book.index[orderhash]
+	if !ok {
+		return
+	}
+	delete(book.index, orderhash)
+
+	side := book.sides[key]
+	for i, v := range side.orders {
+		if v.RawOrder.Hash == orderhash {
+			side.orders = append(side.orders[:i], side.orders[i+1:]...)
+			break
+		}
+	}
+	side.version++
+}
+
+// resync reloads all sides from db, a side updated while reloading is reloaded next time.
+func (book *orderBook) resync() {
+	book.mtx.RLock()
+	versions := make(map[orderBookKey]int64)
+	for key, side := range book.sides {
+		if side.isReady() {
+			versions[key] = side.version
+		}
+	}
+	book.mtx.RUnlock()
+
+	for key, version := range versions {
+		orders, err := book.query(key)
+		if err != nil {
+			log.Errorf("order viewer, resync order book of delegate:%s tokenS:%s tokenB:%s error:%s", key.delegate.Hex(), key.tokenS.Hex(), key.tokenB.Hex(), err.Error())
+			continue
+		}
+
+		book.mtx.Lock()
+		if side, ok := book.sides[key]; ok && side.version == version {
+			book.replace(key, side, orders)
+		}
+		book.mtx.Unlock()
+	}
+}
@@ -0,0 +1,135 @@
+/*
+
+  Copyright 2017 Loopring Project Ltd (Loopring Foundation).
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package viewer
+
+import (
+	"github.com/Loopring/relay-lib/types"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+var (
+	testDelegate = common.HexToAddress("0x17233e07c67d086464fD408148c3ABB56245FA64")
+	testTokenS   = common.HexToAddress("0xEF68e7C694F40c8202821eDF525dE3782458639f")
+	testTokenB   = common.HexToAddress("0x88d50B466BE55222019D71F9E8fAe17f5f45FCA1")
+)
+
+func newTestOrderBook(keys ...orderBookKey) *orderBook {
+	book := &orderBook{size: defaultOrderBookSize}
+	book.sides = make(map[orderBookKey]*orderBookSide)
+	book.index = make(map[common.Hash]orderBookKey)
+	for _, key := range keys {
+		side := &orderBookSide{ready: make(chan struct{})}
+		close(side.ready)
+		book.sides[key] = side
+	}
+	return book
+}
+
+func bookOrder(seq byte, tokenS common.Address, price float64, status types.OrderStatus) *types.OrderState {
+	state := &types.OrderState{Status: status}
+	state.RawOrder.Hash = common.BytesToHash([]byte{seq})
+	state.RawOrder.DelegateAddress = testDelegate
+	state.RawOrder.TokenS = tokenS
+	state.RawOrder.TokenB = testTokenB
+	state.RawOrder.OrderType = types.ORDER_TYPE_MARKET
+	state.RawOrder.Price = new(big.Rat).SetFloat64(price)
+	state.RawOrder.ValidSince = big.NewInt(0)
+	state.RawOrder.ValidUntil = big.NewInt(1 << 40)
+	return state
+}
+
+func sideOrders(book *orderBook, key orderBookKey) []byte {
+	var list []byte
+	for _, v := range book.sides[key].orders {
+		list = append(list, v.RawOrder.Hash[common.HashLength-1])
+	}
+	return list
+}
+
+func TestOrderBookUpdate(t *testing.T) {
+	key := orderBookKey{delegate: testDelegate, tokenS: testTokenS, tokenB: testTokenB}
+	book := newTestOrderBook(key)
+
+	// sorted by price desc, orders at the same price in the order they arrived
+	book.update(bookOrder(1, testTokenS, 0.5, types.ORDER_NEW))
+	book.update(bookOrder(2, testTokenS, 0.7, types.ORDER_NEW))
+	book.update(bookOrder(3, testTokenS, 0.5, types.ORDER_PENDING))
+	book.update(bookOrder(4, testTokenS, 0.6, types.ORDER_PARTIAL))
+	book.update(bookOrder(5, testTokenS, 0.7, types.ORDER_NEW))
+	if got, want := sideOrders(book, key), []byte{2, 5, 4, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("orders:%v, want %v", got, want)
+	}
+
+	// an order updated moves behind the orders at the same price
+	book.update(bookOrder(2, testTokenS, 0.7, types.ORDER_PARTIAL))
+	if got, want := sideOrders(book, key), []byte{5, 2, 4, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders:%v, want %v", got, want)
+	}
+
+	// orders not valid for order books are removed
+	book.update(bookOrder(4, testTokenS, 0.6, types.ORDER_FINISHED))
+	book.update(bookOrder(1, testTokenS, 0.5, types.ORDER_FLEX_CANCEL))
+	if got, want := sideOrders(book, key), []byte{5, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders:%v, want %v", got, want)
+	}
+	if _, ok := book.index[common.BytesToHash([]byte{4})]; ok {
+		t.Error("order removed should be removed from index")
+	}
+
+	// p2p orders and sides not requested are skipped
+	p2p := bookOrder(6, testTokenS, 0.9, types.ORDER_NEW)
+	p2p.RawOrder.OrderType = types.ORDER_TYPE_P2P
+	book.update(p2p)
+	book.update(bookOrder(7, testTokenB, 0.9, types.ORDER_NEW))
+	if got, want := sideOrders(book, key), []byte{5, 2, 3}; !reflect.DeepEqual(got, want) || len(book.sides) != 1 || len(book.index) != 3 {
+		t.Errorf("orders:%v sides:%d index:%d, want %v, 1 and 3", got, len(book.sides), len(book.index), want)
+	}
+}
+
+func TestOrderBookRemoveOrders(t *testing.T) {
+	key := orderBookKey{delegate: testDelegate, tokenS: testTokenS, tokenB: testTokenB}
+	other := orderBookKey{delegate: testDelegate, tokenS: testTokenB, tokenB: testTokenS}
+	book := newTestOrderBook(key, other)
+
+	for i, price := range []float64{0.5, 0.6, 0.7} {
+		book.update(bookOrder(byte(i+1), testTokenS, price, types.ORDER_NEW))
+	}
+	order := bookOrder(4, testTokenB, 2, types.ORDER_NEW)
+	order.RawOrder.TokenB = testTokenS
+	book.update(order)
+	version := book.sides[key].version
+
+	book.removeOrders([]common.Hash{common.BytesToHash([]byte{2}), common.BytesToHash([]byte{4}), common.BytesToHash([]byte{9})})
+	if got, want := sideOrders(book, key), []byte{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders:%v, want %v", got, want)
+	}
+	if got := sideOrders(book, other); len(got) != 0 {
+		t.Errorf("orders of other side:%v, want none", got)
+	}
+	if len(book.index) != 2 {
+		t.Errorf("index:%d, want 2", len(book.index))
+	}
+	// a resync started before is discarded
+	if book.sides[key].version == version {
+		t.Error("version should be increased by remove")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/Loopring/relay-cluster/dao"
 	"github.com/Loopring/relay-cluster/ordermanager/cache"
 	. "github.com/Loopring/relay-cluster/ordermanager/common"
+	omtyp "github.com/Loopring/relay-cluster/ordermanager/types"
 	"github.com/Loopring/relay-lib/log"
 	"github.com/Loopring/relay-lib/marketcap"
 	"github.com/Loopring/relay-lib/types"
@@ -31,14 +32,22 @@ import (
 
 type OrderViewer interface {
 	GetOrderBook(protocol, tokenS, tokenB common.Address, length int) ([]types.OrderState, error)
+	UpdateOrderBook(state *types.OrderState)
+	RemoveFromOrderBook(orderhashList []common.Hash)
 	GetOrders(query map[string]interface{}, statusList []types.OrderStatus, pageIndex, pageSize int) (dao.PageResult, error)
+	GetOrdersByCursor(query map[string]interface{}, statusList []types.OrderStatus, cursor string, pageSize int) (dao.PageResult, error)
 	GetLatestOrders(query map[string]interface{}, length int) ([]types.OrderState, error)
 	GetOrderByHash(hash common.Hash) (*types.OrderState, error)
 	GetOrdersByHashes(hash []common.Hash) ([]types.OrderState, error)
+	GetOrderHistory(hash common.Hash) ([]dao.OrderHistory, error)
+	GetScheduledOrders(owner common.Address, statusList []omtyp.ScheduledOrderStatus) ([]dao.ScheduledOrder, error)
+	GetStopOrders(owner common.Address, statusList []omtyp.StopOrderStatus) ([]dao.StopOrder, error)
 	FillsPageQuery(query map[string]interface{}, pageIndex, pageSize int) (dao.PageResult, error)
+	FillsCursorQuery(query map[string]interface{}, cursor string, pageSize int) (dao.PageResult, error)
 	GetLatestFills(query map[string]interface{}, limit int) ([]dao.FillEvent, error)
 	FindFillsByRingHash(ringHash common.Hash) (result []dao.FillEvent, err error)
 	RingMinedPageQuery(query map[string]interface{}, pageIndex, pageSize int) (dao.PageResult, error)
+	RingMinedCursorQuery(query map[string]interface{}, cursor string, pageSize int) (dao.PageResult, error)
 	IsOrderCutoff(protocol, owner, token1, token2 common.Address, validsince *big.Int) bool
 	GetFrozenAmount(owner common.Address, token common.Address, statusSet []types.OrderStatus, delegateAddress common.Address) (*big.Int, error)
 	GetFrozenLRCFee(owner common.Address, statusSet []types.OrderStatus) (*big.Int, error)
@@ -48,6 +57,7 @@ type OrderViewerImpl struct {
 	mc          marketcap.MarketCapProvider
 	rds         *dao.RdsService
 	cutoffCache *CutoffCache
+	orderBook   *orderBook
 }
 
 func NewOrderViewer(options *OrderManagerOptions,
@@ -58,6 +68,7 @@ func NewOrderViewer(options *OrderManagerOptions,
 	viewer.mc = market
 	viewer.rds = rds
 	viewer.cutoffCache = NewCutoffCache(options.CutoffCacheCleanTime)
+	viewer.orderBook = newOrderBook(options, rds)
 
 	if cache.Invalid() {
 		cache.Initialize(viewer.rds)
@@ -66,25 +77,52 @@ func NewOrderViewer(options *OrderManagerOptions,
 	return &viewer
 }
 
+// GetOrderBook returns the orders selling tokenS for tokenB from memory sorted by price desc,
+// all orders in memory are returned if length <= 0.
 func (om *OrderViewerImpl) GetOrderBook(protocol, tokenS, tokenB common.Address, length int) ([]types.OrderState, error) {
-	var list []types.OrderState
-	models, err := om.rds.GetOrderBook(protocol, tokenS, tokenB, length)
+	return om.orderBook.get(protocol, tokenS, tokenB, length)
+}
+
+// UpdateOrderBook applies an updated order to the order book in memory.
+func (om *OrderViewerImpl) UpdateOrderBook(state *types.OrderState) {
+	om.orderBook.update(state)
+}
+
+// RemoveFromOrderBook removes orders cutoff from the order book in memory.
+func (om *OrderViewerImpl) RemoveFromOrderBook(orderhashList []common.Hash) {
+	om.orderBook.removeOrders(orderhashList)
+}
+
+func (om *OrderViewerImpl) GetOrders(query map[string]interface{}, statusList []types.OrderStatus, pageIndex, pageSize int) (dao.PageResult, error) {
+	var (
+		pageRes dao.PageResult
+	)
+	sL := make([]int, 0)
+	for _, s := range statusList {
+		sL = append(sL, int(s))
+	}
+	tmp, err := om.rds.OrderPageQuery(query, sL, pageIndex, pageSize)
+
 	if err != nil {
-		return list, err
+		return pageRes, err
 	}
+	pageRes.PageIndex = tmp.PageIndex
+	pageRes.PageSize = tmp.PageSize
+	pageRes.Total = tmp.Total
 
-	for _, v := range models {
+	for _, v := range tmp.Data {
 		var state types.OrderState
-		if err := v.ConvertUp(&state); err != nil {
+		model := v.(dao.Order)
+		if err := model.ConvertUp(&state); err != nil {
+			log.Debug("convertUp error occurs " + err.Error())
 			continue
 		}
-		list = append(list, state)
+		pageRes.Data = append(pageRes.Data, state)
 	}
-
-	return list, nil
+	return pageRes, nil
 }
 
-func (om *OrderViewerImpl) GetOrders(query map[string]interface{}, statusList []types.OrderStatus, pageIndex, pageSize int) (dao.PageResult, error) {
+func (om *OrderViewerImpl) GetOrdersByCursor(query map[string]interface{}, statusList []types.OrderStatus, cursor string, pageSize int) (dao.PageResult, error) {
 	var (
 		pageRes dao.PageResult
 	)
@@ -92,14 +130,13 @@ func (om *OrderViewerImpl) GetOrders(query map[string]interface{}, statusList []
 	for _, s := range statusList {
 		sL = append(sL, int(s))
 	}
-	tmp, err := om.rds.OrderPageQuery(query, sL, pageIndex, pageSize)
+	tmp, err := om.rds.OrderCursorQuery(query, sL, cursor, pageSize)
 
 	if err != nil {
 		return pageRes, err
 	}
-	pageRes.PageIndex = tmp.PageIndex
 	pageRes.PageSize = tmp.PageSize
-	pageRes.Total = tmp.Total
+	pageRes.NextCursor = tmp.NextCursor
 
 	for _, v := range tmp.Data {
 		var state types.OrderState
@@ -163,10 +200,26 @@ func (om *OrderViewerImpl) GetOrdersByHashes(orders []common.Hash) (orderState [
 	return rst, nil
 }
 
+func (om *OrderViewerImpl) GetOrderHistory(hash common.Hash) ([]dao.OrderHistory, error) {
+	return om.rds.GetOrderHistory(hash)
+}
+
+func (om *OrderViewerImpl) GetScheduledOrders(owner common.Address, statusList []omtyp.ScheduledOrderStatus) ([]dao.ScheduledOrder, error) {
+	return om.rds.GetScheduledOrders(owner, statusList)
+}
+
+func (om *OrderViewerImpl) GetStopOrders(owner common.Address, statusList []omtyp.StopOrderStatus) ([]dao.StopOrder, error) {
+	return om.rds.GetStopOrders(owner, statusList)
+}
+
 func (om *OrderViewerImpl) FillsPageQuery(query map[string]interface{}, pageIndex, pageSize int) (result dao.PageResult, err error) {
 	return om.rds.FillsPageQuery(query, pageIndex, pageSize)
 }
 
+func (om *OrderViewerImpl) FillsCursorQuery(query map[string]interface{}, cursor string, pageSize int) (result dao.PageResult, err error) {
+	return om.rds.FillsCursorQuery(query, cursor, pageSize)
+}
+
 func (om *OrderViewerImpl) GetLatestFills(query map[string]interface{}, limit int) (result []dao.FillEvent, err error) {
 	return om.rds.GetLatestFills(query, limit)
 }
@@ -179,6 +232,10 @@ func (om *OrderViewerImpl) RingMinedPageQuery(query map[string]interface{}, page
 	return om.rds.RingMinedPageQuery(query, pageIndex, pageSize)
 }
 
+func (om *OrderViewerImpl) RingMinedCursorQuery(query map[string]interface{}, cursor string, pageSize int) (result dao.PageResult, err error) {
+	return om.rds.RingMinedCursorQuery(query, cursor, pageSize)
+}
+
 func (om *OrderViewerImpl) IsOrderCutoff(protocol, owner, token1, token2 common.Address, validsince *big.Int) bool {
 	return om.cutoffCache.IsOrderCutoff(protocol, owner, token1, token2, validsince)
 }
@@ -16,6 +16,8 @@
 
 */
 
+// Package test loads config/debug.toml and connects mysql, redis and the eth node at init,
+// tests importing it are built with the integration tag: go test -tags integration.
 package test
 
 import (
@@ -178,7 +180,7 @@ func unlockAccounts() {
 
 	creator = accounts.Account{Address: entity.Creator.Address}
 	if err := ks.Unlock(creator, entity.Creator.Passphrase); err != nil {
-		fmt.Printf(err.Error())
+		fmt.Println(err.Error())
 	}
 
 	for _, accTmp := range entity.Accounts {
@@ -216,7 +218,7 @@ func LrcAddress() common.Address {
 
 func GenerateOrderManager() *ordermanager.OrderManagerImpl {
 	mc := GenerateMarketCap()
-	ob := ordermanager.NewOrderManager(&cfg.OrderManager, rds, mc, cfg.Kafka.Brokers)
+	ob := ordermanager.NewOrderManager(&cfg.OrderManager, rds, mc, nil, cfg.Kafka.Brokers)
 	return ob
 }
 
@@ -440,11 +442,11 @@ func SetTokenBalances() {
 		sendTransactionMethod := accessor.ContractSendTransactionMethod("latest", dummyTokenAbi, tokenAddress)
 		for _, acc := range orderAccounts {
 			if balance, err := loopringaccessor.Erc20Balance(tokenAddress, acc.Address, "latest"); nil != err {
-				fmt.Errorf(err.Error())
+				fmt.Println(err.Error())
 			} else if balance.Cmp(big.NewInt(int64(0))) <= 0 {
 				hash, _, err := sendTransactionMethod(sender.Address, "setBalance", big.NewInt(106762), big.NewInt(21000000000), nil, acc.Address, amount)
 				if nil != err {
-					fmt.Errorf(err.Error())
+					fmt.Println(err.Error())
 				}
 				fmt.Printf("sendhash:%s", hash)
 			} else {
@@ -465,7 +467,7 @@ func SetTokenBalance(account, tokenAddress common.Address, amount *big.Int) {
 
 	hash, _, err := sendTransactionMethod(sender.Address, "setBalance", big.NewInt(1000000), big.NewInt(21000000000), nil, account, amount)
 	if nil != err {
-		fmt.Errorf(err.Error())
+		fmt.Println(err.Error())
 	}
 	fmt.Printf("sendhash:%s", hash)
 }
@@ -36,6 +36,9 @@ func GetAllTransactionCount(ownerStr, symbol, status, typ string) (int, error) {
 func GetAllTransactions(owner, symbol, status, typ string, limit, offset int) ([]txtyp.TransactionJsonResult, error) {
 	return impl.GetAllTransactions(owner, symbol, status, typ, limit, offset)
 }
+func GetAllTransactionsByCursor(owner, symbol, status, typ string, cursor string, limit int) ([]txtyp.TransactionJsonResult, string, error) {
+	return impl.GetAllTransactionsByCursor(owner, symbol, status, typ, cursor, limit)
+}
 func GetNonce(owner string) (*big.Int, error) {
 	return impl.GetNonce(owner)
 }
@@ -47,6 +50,7 @@ type TransactionViewer interface {
 	GetPendingTransactions(owner string) ([]txtyp.TransactionJsonResult, error)
 	GetAllTransactionCount(owner, symbol, status, typ string) (int, error)
 	GetAllTransactions(owner, symbol, status, typ string, limit, offset int) ([]txtyp.TransactionJsonResult, error)
+	GetAllTransactionsByCursor(owner, symbol, status, typ string, cursor string, limit int) ([]txtyp.TransactionJsonResult, string, error)
 	GetTransactionsByHash(owner string, hashList []string) ([]txtyp.TransactionJsonResult, error)
 	GetNonce(owner string) (*big.Int, error)
 	ValidateNonce(owner string, nonce *big.Int) error
@@ -21,6 +21,7 @@ package util
 import (
 	"github.com/Loopring/relay-cluster/dao"
 	"github.com/Loopring/relay-cluster/txmanager/types"
+	"github.com/Loopring/relay-lib/crypto"
 	"github.com/Loopring/relay-lib/kafka"
 	"github.com/Loopring/relay-lib/log"
 	libTypes "github.com/Loopring/relay-lib/types"
@@ -28,8 +29,11 @@ import (
 
 // todo delete return after test
 
+// NotifyOrderUpdate pushes the order without its auth private key.
 func NotifyOrderUpdate(o *libTypes.OrderState) error {
-	err := ProducerSocketIOMessage(kafka.Kafka_Topic_SocketIO_Order_Updated, o)
+	state := *o
+	state.RawOrder.AuthPrivateKey = crypto.EthPrivateKeyCrypto{}
+	err := ProducerSocketIOMessage(kafka.Kafka_Topic_SocketIO_Order_Updated, &state)
 	if err != nil {
 		log.Error("notify new order failed. " + o.RawOrder.Hash.Hex())
 	} else {